@@ -0,0 +1,62 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nate3d/go-toolbox/internal/cli"
+)
+
+// referenceDir holds the committed Markdown reference for this binary
+const referenceDir = "../../../docs/reference"
+
+var update = flag.Bool("update", false, "regenerate docs/reference")
+
+// TestReferenceDocs fails when docs/reference no longer matches the command
+// tree. Run "make docs" to regenerate it.
+func TestReferenceDocs(t *testing.T) {
+	if *update {
+		if err := os.RemoveAll(referenceDir); err != nil {
+			t.Fatal(err)
+		}
+		if err := cli.GenerateDocs(createRootCommand(), cli.DocsMarkdown, referenceDir); err != nil {
+			t.Fatalf("GenerateDocs() error = %v", err)
+		}
+		return
+	}
+
+	dir := t.TempDir()
+	if err := cli.GenerateDocs(createRootCommand(), cli.DocsMarkdown, dir); err != nil {
+		t.Fatalf("GenerateDocs() error = %v", err)
+	}
+
+	generated, err := filepath.Glob(filepath.Join(dir, "*.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	committed, err := filepath.Glob(filepath.Join(referenceDir, "*.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(generated) != len(committed) {
+		t.Errorf("docs/reference has %d pages, the command tree has %d; run make docs", len(committed), len(generated))
+	}
+
+	for _, path := range generated {
+		name := filepath.Base(path)
+		want, readErr := os.ReadFile(path) // #nosec G304 - generated in a test temp dir
+		if readErr != nil {
+			t.Fatal(readErr)
+		}
+		got, readErr := os.ReadFile(filepath.Join(referenceDir, name)) // #nosec G304 - committed reference page
+		if readErr != nil {
+			t.Errorf("docs/reference/%s is missing; run make docs", name)
+			continue
+		}
+		if string(got) != string(want) {
+			t.Errorf("docs/reference/%s is stale; run make docs", name)
+		}
+	}
+}
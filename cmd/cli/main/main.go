@@ -2,28 +2,47 @@
 package main
 
 import (
-	"fmt"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/nate3d/go-toolbox/internal/cli"
 	"github.com/nate3d/go-toolbox/internal/config"
+	"github.com/nate3d/go-toolbox/internal/filecmd"
 	"github.com/nate3d/go-toolbox/internal/logger"
+	"github.com/nate3d/go-toolbox/internal/profile"
+	"github.com/nate3d/go-toolbox/internal/theme"
 )
 
 const (
 	appName    = "toolbox"
 	appVersion = "0.1.0"
+
+	defaultNetworkTimeout = 5 * time.Second
 )
 
+// stringOperations lists the operations accepted by "utils string"
+var stringOperations = []string{"reverse", "upper", "lower", "camel", "snake", "kebab"}
+
 func main() {
+	// Start profiling first so it covers configuration and logger setup
+	session, err := profile.Start(profile.FromArgs(os.Args[1:]), os.Stderr)
+	if err != nil {
+		cli.Fatal(err)
+	}
+
 	// Initialize configuration
 	if err := config.Init(appName); err != nil {
-		fmt.Fprintf(os.Stderr, "Error initializing config: %v\n", err)
-		os.Exit(1)
+		cli.Fatal(cli.WrapError(cli.KindConfig, err, "error initializing config"))
 	}
 
+	// Apply the configured color theme
+	if err := theme.Load(); err != nil {
+		cli.Fatal(cli.WrapError(cli.KindConfig, err, "error loading theme"))
+	}
+	session.Mark("config init")
+
 	// Initialize logger
 	logConfig := logger.Config{
 		Level:      logger.LogLevel(config.GetString("log_level")),
@@ -33,18 +52,21 @@ func main() {
 		WithTime:   true,
 	}
 	if err := logger.Init(logConfig); err != nil {
-		fmt.Fprintf(os.Stderr, "Error initializing logger: %v\n", err)
-		os.Exit(1)
+		cli.Fatal(cli.WrapError(cli.KindConfig, err, "error initializing logger"))
 	}
+	session.Mark("logger init")
 
 	// Create root command
 	rootCmd := createRootCommand()
 
-	// Execute
-	if err := rootCmd.Execute(); err != nil {
-		logger.Error("Command execution failed", "error", err)
-		os.Exit(1)
+	// Execute and exit with the code for the error kind, if any
+	code := cli.Execute(rootCmd)
+	session.Mark("command run")
+	if err := session.Stop(); err != nil {
+		cli.ReportError(os.Stderr, err, cli.OutputTable)
+		code = max(code, cli.ExitGeneral)
 	}
+	os.Exit(code)
 }
 
 func createRootCommand() *cobra.Command {
@@ -59,66 +81,57 @@ func createRootCommand() *cobra.Command {
 	}
 
 	// Add subcommands
-	cmd.AddCommand(createFileCommand())
+	cmd.AddCommand(filecmd.NewCommand())
 	cmd.AddCommand(createNetworkCommand())
 	cmd.AddCommand(createSystemCommand())
 	cmd.AddCommand(createUtilsCommand())
+	cmd.AddCommand(cli.NewConfigCommand())
+	cmd.AddCommand(cli.NewAliasCommand(cmd))
+	cmd.AddCommand(cli.NewShellCommand(cmd))
+	cmd.AddCommand(cli.NewBatchCommand(cmd, createRootCommand))
+	cmd.AddCommand(cli.NewCompletionCommand(cmd))
+	cmd.AddCommand(cli.NewDocsCommand(cmd))
 
-	return cmd
-}
-
-func createFileCommand() *cobra.Command {
-	baseCmd := cli.NewBaseCommand("file", "File operations and utilities")
-
-	// File hash command
-	hashCmd := &cobra.Command{
-		Use:   "hash [file]",
-		Short: "Calculate file hashes",
-		Args:  cobra.ExactArgs(1),
-		RunE: func(_ *cobra.Command, args []string) error {
-			return runFileHash(baseCmd, args[0])
-		},
-	}
-
-	// File info command
-	infoCmd := &cobra.Command{
-		Use:   "info [file]",
-		Short: "Show file information",
-		Args:  cobra.ExactArgs(1),
-		RunE: func(_ *cobra.Command, args []string) error {
-			return runFileInfo(baseCmd, args[0])
-		},
-	}
-
-	baseCmd.AddCommand(hashCmd)
-	baseCmd.AddCommand(infoCmd)
+	profile.AddFlags(cmd.PersistentFlags())
 
-	return baseCmd.Command
+	return cmd
 }
 
 func createNetworkCommand() *cobra.Command {
 	baseCmd := cli.NewBaseCommand("network", "Network utilities")
 
+	// Timeout defaults to network.timeout and accepts values like 500ms, 5s or 1m30s
+	timeout, err := cli.ConfigDuration("network.timeout")
+	if err != nil {
+		timeout = defaultNetworkTimeout
+	}
+	cli.DurationVar(baseCmd.PersistentFlags(), &timeout, "timeout", timeout, "Network operation timeout")
+
 	// Ping command
 	pingCmd := &cobra.Command{
-		Use:   "ping [host]",
-		Short: "Ping a host",
-		Args:  cobra.ExactArgs(1),
+		Use:               "ping [host]",
+		Short:             "Ping a host",
+		Example:           `  toolbox network ping example.com --timeout 2s`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: cli.CompletePositional(cli.CompleteKnownHosts),
 		RunE: func(_ *cobra.Command, args []string) error {
-			return runNetworkPing(baseCmd, args[0])
+			return runNetworkPing(baseCmd, args[0], timeout)
 		},
 	}
 
 	// Port scan command
 	portScanCmd := &cobra.Command{
-		Use:   "portscan [host]",
-		Short: "Scan ports on a host",
-		Args:  cobra.ExactArgs(1),
+		Use:               "portscan [host]",
+		Short:             "Scan ports on a host",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: cli.CompletePositional(cli.CompleteKnownHosts),
 		RunE: func(_ *cobra.Command, args []string) error {
 			return runPortScan(baseCmd, args[0])
 		},
 	}
 
+	baseCmd.AddWatchFlag(pingCmd)
+	baseCmd.AddWatchFlag(portScanCmd)
 	baseCmd.AddCommand(pingCmd)
 	baseCmd.AddCommand(portScanCmd)
 
@@ -146,6 +159,8 @@ func createSystemCommand() *cobra.Command {
 		},
 	}
 
+	baseCmd.AddWatchFlag(infoCmd)
+	baseCmd.AddWatchFlag(psCmd)
 	baseCmd.AddCommand(infoCmd)
 	baseCmd.AddCommand(psCmd)
 
@@ -168,7 +183,11 @@ func createUtilsCommand() *cobra.Command {
 	stringCmd := &cobra.Command{
 		Use:   "string [operation] [text]",
 		Short: "String manipulation utilities",
-		Args:  cobra.MinimumNArgs(2),
+		Long:  "String manipulation utilities. Operations: reverse, upper, lower, camel, snake, kebab.",
+		Example: `  toolbox utils string upper "hello world"
+  toolbox utils string snake "HelloWorld" --output json`,
+		Args:              cobra.MinimumNArgs(2),
+		ValidArgsFunction: cli.CompletePositional(cli.CompleteValues(stringOperations...)),
 		RunE: func(_ *cobra.Command, args []string) error {
 			return runStringUtils(baseCmd, args[0], args[1])
 		},
@@ -182,36 +201,11 @@ func createUtilsCommand() *cobra.Command {
 
 // Command implementations
 
-func runFileHash(cmd *cli.BaseCommand, filename string) error {
-	cmd.PrintHeaderf("File Hash Calculator")
-	cmd.PrintInfof("Calculating hashes for: %s", filename)
-
-	// This would be implemented using pkg/file utilities
-	cmd.PrintSuccessf("MD5: [would calculate MD5]")
-	cmd.PrintSuccessf("SHA256: [would calculate SHA256]")
-
-	return nil
-}
-
-func runFileInfo(cmd *cli.BaseCommand, filename string) error {
-	cmd.PrintHeaderf("File Information")
-
-	// This would be implemented using pkg/file utilities
-	table := cli.NewTable([]string{"Property", "Value"})
-	table.AddRow("Name", filename)
-	table.AddRow("Size", "[would get size]")
-	table.AddRow("Modified", "[would get mod time]")
-	table.AddRow("Permissions", "[would get permissions]")
-
-	table.Render()
-	return nil
-}
-
-func runNetworkPing(cmd *cli.BaseCommand, host string) error {
+func runNetworkPing(cmd *cli.BaseCommand, host string, timeout time.Duration) error {
 	cmd.PrintHeaderf("Ping %s", host)
 
 	// This would be implemented using pkg/network utilities
-	cmd.PrintInfof("PING %s", host)
+	cmd.PrintInfof("PING %s (timeout %s)", host, cli.FormatDuration(timeout))
 	cmd.PrintSuccessf("64 bytes from %s: icmp_seq=1 time=1.234ms", host)
 
 	return nil
@@ -221,7 +215,7 @@ func runPortScan(cmd *cli.BaseCommand, host string) error {
 	cmd.PrintHeaderf("Port Scan: %s", host)
 
 	// This would be implemented using pkg/network utilities
-	table := cli.NewTable([]string{"Port", "State", "Service"})
+	table := cmd.NewTable([]string{"Port", "State", "Service"})
 	table.AddRow("22", "open", "ssh")
 	table.AddRow("80", "open", "http")
 	table.AddRow("443", "open", "https")
@@ -234,7 +228,7 @@ func runSystemInfo(cmd *cli.BaseCommand) error {
 	cmd.PrintHeaderf("System Information")
 
 	// This would be implemented using pkg/system utilities
-	table := cli.NewTable([]string{"Property", "Value"})
+	table := cmd.NewTable([]string{"Property", "Value"})
 	table.AddRow("OS", "[would get OS]")
 	table.AddRow("Architecture", "[would get arch]")
 	table.AddRow("CPU Cores", "[would get cores]")
@@ -248,7 +242,7 @@ func runProcessList(cmd *cli.BaseCommand) error {
 	cmd.PrintHeaderf("Running Processes")
 
 	// This would be implemented using pkg/system utilities
-	table := cli.NewTable([]string{"PID", "Name", "CPU%", "Memory"})
+	table := cmd.NewTable([]string{"PID", "Name", "CPU%", "Memory"})
 	table.AddRow("1234", "example", "1.2%", "45MB")
 	table.AddRow("5678", "another", "0.5%", "23MB")
 
@@ -259,9 +253,12 @@ func runProcessList(cmd *cli.BaseCommand) error {
 func runRandomGenerator(cmd *cli.BaseCommand) error {
 	cmd.PrintHeaderf("Random String Generator")
 
-	prompt := cli.NewPrompt()
+	prompt, err := cmd.Prompter()
+	if err != nil {
+		return err
+	}
 
-	lengthStr, err := prompt.String("Enter length (default: 16)", "16")
+	lengthStr, err := prompt.String("Length", "16")
 	if err != nil {
 		return err
 	}
@@ -290,9 +287,8 @@ func runStringUtils(cmd *cli.BaseCommand, operation, text string) error {
 	case "kebab":
 		cmd.PrintSuccessf("Result: [would convert '%s' to kebab-case]", text)
 	default:
-		cmd.PrintErrorf("Unknown operation: %s", operation)
-		cmd.PrintInfof("Available operations: reverse, upper, lower, camel, snake, kebab")
-		return fmt.Errorf("unknown operation: %s", operation)
+		return cli.UsageErrorf("unknown operation %q", operation).
+			WithSuggestions(operation, stringOperations)
 	}
 
 	return nil
@@ -11,10 +11,14 @@ import (
 	"fmt"
 	"log"
 	"math/big"
+	"mime"
 	"net"
 	"net/http"
 	"os"
+	"path"
 	"time"
+
+	"github.com/nate3d/go-toolbox/pkg/utils"
 )
 
 //nolint:cyclop,gocognit,nestif // This function handles CLI argument parsing and server setup
@@ -61,7 +65,8 @@ func main() {
 	}
 
 	fmt.Printf("Serving %s on %s://%s:%d (accessible on your LAN)\n", dir, scheme, ip, listenPort)
-	http.Handle("/", http.FileServer(http.Dir(dir)))
+	root := http.Dir(dir)
+	http.Handle("/", contentTypeHandler(root, http.FileServer(root)))
 
 	addr := fmt.Sprintf("0.0.0.0:%d", listenPort)
 	if *tlsFlag {
@@ -133,6 +138,40 @@ func generateSelfSignedCert() (string, string) {
 	return string(certPEM), string(keyPEM)
 }
 
+// contentTypeHandler types files whose extension is not registered by
+// their content, which recognises more formats than the sniffing built
+// into http.FileServer.
+func contentTypeHandler(root http.FileSystem, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := path.Clean("/" + r.URL.Path)
+		if mime.TypeByExtension(path.Ext(name)) == "" {
+			if contentType := detectContentType(root, name); contentType != "" {
+				w.Header().Set("Content-Type", contentType)
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// detectContentType returns the MIME type of a regular file, or an empty
+// string to leave the choice to http.FileServer.
+func detectContentType(root http.FileSystem, name string) string {
+	file, err := root.Open(name)
+	if err != nil {
+		return ""
+	}
+	defer func() { _ = file.Close() }()
+	info, err := file.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		return ""
+	}
+	detection, err := utils.Detect().Reader(file)
+	if err != nil || detection.Kind == "empty" {
+		return ""
+	}
+	return detection.MIMEType
+}
+
 // getLocalIP returns the first non-loopback, non-link-local IPv4 address
 func getLocalIP() string {
 	interfaces, err := net.Interfaces()
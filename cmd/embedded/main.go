@@ -7,21 +7,26 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/lipgloss"
 	"github.com/spf13/cobra"
 
 	"github.com/nate3d/go-toolbox/internal/cli"
 	"github.com/nate3d/go-toolbox/internal/config"
+	"github.com/nate3d/go-toolbox/internal/filecmd"
 	"github.com/nate3d/go-toolbox/internal/generator"
 	"github.com/nate3d/go-toolbox/internal/logger"
+	"github.com/nate3d/go-toolbox/internal/profile"
+	"github.com/nate3d/go-toolbox/internal/theme"
 )
 
 const (
 	appName    = "go-toolbox-embedded"
 	appVersion = "0.1.0"
 
+	defaultNetworkTimeout = 5 * time.Second
+
 	modeTUI    = "tui"
 	modeUI     = "ui"
 	modeServe  = "serve"
@@ -29,6 +34,14 @@ const (
 	modeCLI    = "cli"
 )
 
+var (
+	// stringOperations lists the operations accepted by "utils string"
+	stringOperations = []string{"reverse", "upper", "lower", "camel", "snake", "kebab"}
+
+	// templateNames lists the templates accepted by "generate template"
+	templateNames = []string{"go-project", "go-cli", "go-tui"}
+)
+
 // Import TUI model components from the existing TUI implementation
 type embeddedTUIModel struct {
 	choices  []string
@@ -37,46 +50,31 @@ type embeddedTUIModel struct {
 	quitting bool
 }
 
-// TUI styling constants (reusing the same style as existing TUI)
+// TUI key bindings (reusing the same keys as existing TUI)
 const (
-	paddingSmall  = 2
-	paddingMedium = 4
-	keyCtrlC      = "ctrl+c"
-	keyEsc        = "esc"
-	keyQ          = "q"
-	keyB          = "b"
-)
-
-// TUI Styles (matching existing TUI styles)
-var (
-	titleStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("#FAFAFA")).
-			Background(lipgloss.Color("#7D56F4")).
-			Padding(0, 1)
-
-	itemStyle = lipgloss.NewStyle().
-			PaddingLeft(paddingMedium)
-
-	selectedItemStyle = lipgloss.NewStyle().
-				PaddingLeft(paddingSmall).
-				Foreground(lipgloss.Color("170"))
-
-	helpStyle = lipgloss.NewStyle().
-			PaddingLeft(paddingMedium).
-			PaddingTop(1).
-			Foreground(lipgloss.Color("241"))
-
-	quitTextStyle = lipgloss.NewStyle().
-			Margin(1, 0, 2, 4)
+	keyCtrlC = "ctrl+c"
+	keyEsc   = "esc"
+	keyQ     = "q"
+	keyB     = "b"
 )
 
 func main() {
+	// Start profiling first so it covers configuration and logger setup
+	session, err := profile.Start(profile.FromArgs(os.Args[1:]), os.Stderr)
+	if err != nil {
+		cli.Fatal(err)
+	}
+
 	// Initialize configuration
 	if err := config.Init(appName); err != nil {
-		fmt.Fprintf(os.Stderr, "Error initializing config: %v\n", err)
-		os.Exit(1)
+		cli.Fatal(cli.WrapError(cli.KindConfig, err, "error initializing config"))
+	}
+
+	// Apply the configured color theme
+	if err := theme.Load(); err != nil {
+		cli.Fatal(cli.WrapError(cli.KindConfig, err, "error loading theme"))
 	}
+	session.Mark("config init")
 
 	// Initialize logger
 	logConfig := logger.Config{
@@ -87,9 +85,9 @@ func main() {
 		WithTime:   true,
 	}
 	if err := logger.Init(logConfig); err != nil {
-		fmt.Fprintf(os.Stderr, "Error initializing logger: %v\n", err)
-		os.Exit(1)
+		cli.Fatal(cli.WrapError(cli.KindConfig, err, "error initializing logger"))
 	}
+	session.Mark("logger init")
 
 	// Detect execution mode based on binary name or first argument
 	mode := detectMode()
@@ -100,9 +98,9 @@ func main() {
 	case modeServe, modeServer:
 		runServerMode(os.Args[1:])
 	case modeCLI, "":
-		runCLIMode()
+		runCLIMode(session)
 	default:
-		runCLIMode() // Default to CLI mode
+		runCLIMode(session) // Default to CLI mode
 	}
 }
 
@@ -138,13 +136,16 @@ func detectMode() string {
 }
 
 // runCLIMode reuses the existing CLI implementation
-func runCLIMode() {
+func runCLIMode(session *profile.Session) {
 	rootCmd := createRootCommand()
 
-	if err := rootCmd.Execute(); err != nil {
-		logger.Error("Command execution failed", "error", err)
-		os.Exit(1)
+	code := cli.Execute(rootCmd)
+	session.Mark("command run")
+	if err := session.Stop(); err != nil {
+		cli.ReportError(os.Stderr, err, cli.OutputTable)
+		code = max(code, cli.ExitGeneral)
 	}
+	os.Exit(code)
 }
 
 // createRootCommand reuses the CLI command structure from cmd/cli/main/main.go
@@ -164,11 +165,19 @@ func createRootCommand() *cobra.Command {
 	cmd.AddCommand(createServeCommand())
 
 	// Add CLI tool subcommands (reusing existing implementations)
-	cmd.AddCommand(createFileCommand())
+	cmd.AddCommand(filecmd.NewCommand())
 	cmd.AddCommand(createNetworkCommand())
 	cmd.AddCommand(createSystemCommand())
 	cmd.AddCommand(createUtilsCommand())
 	cmd.AddCommand(createGenerateCommand())
+	cmd.AddCommand(cli.NewConfigCommand())
+	cmd.AddCommand(cli.NewAliasCommand(cmd))
+	cmd.AddCommand(cli.NewShellCommand(cmd))
+	cmd.AddCommand(cli.NewBatchCommand(cmd, createRootCommand))
+	cmd.AddCommand(cli.NewCompletionCommand(cmd))
+	cmd.AddCommand(cli.NewDocsCommand(cmd))
+
+	profile.AddFlags(cmd.PersistentFlags())
 
 	return cmd
 }
@@ -210,59 +219,41 @@ func createServeCommand() *cobra.Command {
 	return cmd
 }
 
-// Reuse existing CLI command implementations from cmd/cli/main/main.go
-func createFileCommand() *cobra.Command {
-	baseCmd := cli.NewBaseCommand("file", "File operations and utilities")
-
-	// File hash command (reusing the implementation pattern)
-	hashCmd := &cobra.Command{
-		Use:   "hash [file]",
-		Short: "Calculate file hashes",
-		Args:  cobra.ExactArgs(1),
-		RunE: func(_ *cobra.Command, args []string) error {
-			return runFileHash(baseCmd, args[0])
-		},
-	}
-
-	// File info command (reusing the implementation pattern)
-	infoCmd := &cobra.Command{
-		Use:   "info [file]",
-		Short: "Show file information",
-		Args:  cobra.ExactArgs(1),
-		RunE: func(_ *cobra.Command, args []string) error {
-			return runFileInfo(baseCmd, args[0])
-		},
-	}
-
-	baseCmd.AddCommand(hashCmd)
-	baseCmd.AddCommand(infoCmd)
-
-	return baseCmd.Command
-}
-
 func createNetworkCommand() *cobra.Command {
 	baseCmd := cli.NewBaseCommand("network", "Network utilities")
 
+	// Timeout defaults to network.timeout and accepts values like 500ms, 5s or 1m30s
+	timeout, err := cli.ConfigDuration("network.timeout")
+	if err != nil {
+		timeout = defaultNetworkTimeout
+	}
+	cli.DurationVar(baseCmd.PersistentFlags(), &timeout, "timeout", timeout, "Network operation timeout")
+
 	// Ping command (reusing the implementation pattern)
 	pingCmd := &cobra.Command{
-		Use:   "ping [host]",
-		Short: "Ping a host",
-		Args:  cobra.ExactArgs(1),
+		Use:               "ping [host]",
+		Short:             "Ping a host",
+		Example:           `  go-toolbox-embedded network ping example.com --timeout 2s`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: cli.CompletePositional(cli.CompleteKnownHosts),
 		RunE: func(_ *cobra.Command, args []string) error {
-			return runNetworkPing(baseCmd, args[0])
+			return runNetworkPing(baseCmd, args[0], timeout)
 		},
 	}
 
 	// Port scan command (reusing the implementation pattern)
 	portScanCmd := &cobra.Command{
-		Use:   "portscan [host]",
-		Short: "Scan ports on a host",
-		Args:  cobra.ExactArgs(1),
+		Use:               "portscan [host]",
+		Short:             "Scan ports on a host",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: cli.CompletePositional(cli.CompleteKnownHosts),
 		RunE: func(_ *cobra.Command, args []string) error {
 			return runPortScan(baseCmd, args[0])
 		},
 	}
 
+	baseCmd.AddWatchFlag(pingCmd)
+	baseCmd.AddWatchFlag(portScanCmd)
 	baseCmd.AddCommand(pingCmd)
 	baseCmd.AddCommand(portScanCmd)
 	return baseCmd.Command
@@ -289,6 +280,8 @@ func createSystemCommand() *cobra.Command {
 		},
 	}
 
+	baseCmd.AddWatchFlag(infoCmd)
+	baseCmd.AddWatchFlag(psCmd)
 	baseCmd.AddCommand(infoCmd)
 	baseCmd.AddCommand(psCmd)
 	return baseCmd.Command
@@ -310,7 +303,11 @@ func createUtilsCommand() *cobra.Command {
 	stringCmd := &cobra.Command{
 		Use:   "string [operation] [text]",
 		Short: "String manipulation utilities",
-		Args:  cobra.MinimumNArgs(2),
+		Long:  "String manipulation utilities. Operations: reverse, upper, lower, camel, snake, kebab.",
+		Example: `  go-toolbox-embedded utils string upper "hello world"
+  go-toolbox-embedded utils string snake "HelloWorld" --output json`,
+		Args:              cobra.MinimumNArgs(2),
+		ValidArgsFunction: cli.CompletePositional(cli.CompleteValues(stringOperations...)),
 		RunE: func(_ *cobra.Command, args []string) error {
 			return runStringUtils(baseCmd, args[0], args[1])
 		},
@@ -330,9 +327,10 @@ func createGenerateCommand() *cobra.Command {
 
 	// Template generation command
 	templateCmd := &cobra.Command{
-		Use:   "template [name]",
-		Short: "Generate a code template",
-		Args:  cobra.ExactArgs(1),
+		Use:               "template [name]",
+		Short:             "Generate a code template",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: cli.CompletePositional(cli.CompleteValues(templateNames...)),
 		RunE: func(_ *cobra.Command, args []string) error {
 			return runTemplateGeneration(args[0])
 		},
@@ -344,36 +342,11 @@ func createGenerateCommand() *cobra.Command {
 
 // Command implementations - reusing the exact implementations from cmd/cli/main/main.go
 
-func runFileHash(cmd *cli.BaseCommand, filename string) error {
-	cmd.PrintHeaderf("File Hash Calculator")
-	cmd.PrintInfof("Calculating hashes for: %s", filename)
-
-	// This would be implemented using pkg/file utilities
-	cmd.PrintSuccessf("MD5: [would calculate MD5]")
-	cmd.PrintSuccessf("SHA256: [would calculate SHA256]")
-
-	return nil
-}
-
-func runFileInfo(cmd *cli.BaseCommand, filename string) error {
-	cmd.PrintHeaderf("File Information")
-
-	// This would be implemented using pkg/file utilities
-	table := cli.NewTable([]string{"Property", "Value"})
-	table.AddRow("Name", filename)
-	table.AddRow("Size", "[would get size]")
-	table.AddRow("Modified", "[would get mod time]")
-	table.AddRow("Permissions", "[would get permissions]")
-
-	table.Render()
-	return nil
-}
-
-func runNetworkPing(cmd *cli.BaseCommand, host string) error {
+func runNetworkPing(cmd *cli.BaseCommand, host string, timeout time.Duration) error {
 	cmd.PrintHeaderf("Ping %s", host)
 
 	// This would be implemented using pkg/network utilities
-	cmd.PrintInfof("PING %s", host)
+	cmd.PrintInfof("PING %s (timeout %s)", host, cli.FormatDuration(timeout))
 	cmd.PrintSuccessf("64 bytes from %s: icmp_seq=1 time=1.234ms", host)
 
 	return nil
@@ -383,7 +356,7 @@ func runPortScan(cmd *cli.BaseCommand, host string) error {
 	cmd.PrintHeaderf("Port Scan: %s", host)
 
 	// This would be implemented using pkg/network utilities
-	table := cli.NewTable([]string{"Port", "State", "Service"})
+	table := cmd.NewTable([]string{"Port", "State", "Service"})
 	table.AddRow("22", "open", "ssh")
 	table.AddRow("80", "open", "http")
 	table.AddRow("443", "open", "https")
@@ -396,7 +369,7 @@ func runSystemInfo(cmd *cli.BaseCommand) error {
 	cmd.PrintHeaderf("System Information")
 
 	// This would be implemented using pkg/system utilities
-	table := cli.NewTable([]string{"Property", "Value"})
+	table := cmd.NewTable([]string{"Property", "Value"})
 	table.AddRow("OS", "[would get OS]")
 	table.AddRow("Architecture", "[would get arch]")
 	table.AddRow("CPU Cores", "[would get cores]")
@@ -410,7 +383,7 @@ func runProcessList(cmd *cli.BaseCommand) error {
 	cmd.PrintHeaderf("Running Processes")
 
 	// This would be implemented using pkg/system utilities
-	table := cli.NewTable([]string{"PID", "Name", "CPU%", "Memory"})
+	table := cmd.NewTable([]string{"PID", "Name", "CPU%", "Memory"})
 	table.AddRow("1234", "example", "1.2%", "45MB")
 	table.AddRow("5678", "another", "0.5%", "23MB")
 
@@ -421,9 +394,12 @@ func runProcessList(cmd *cli.BaseCommand) error {
 func runRandomGenerator(cmd *cli.BaseCommand) error {
 	cmd.PrintHeaderf("Random String Generator")
 
-	prompt := cli.NewPrompt()
+	prompt, err := cmd.Prompter()
+	if err != nil {
+		return err
+	}
 
-	lengthStr, err := prompt.String("Enter length (default: 16)", "16")
+	lengthStr, err := prompt.String("Length", "16")
 	if err != nil {
 		return err
 	}
@@ -452,9 +428,8 @@ func runStringUtils(cmd *cli.BaseCommand, operation, text string) error {
 	case "kebab":
 		cmd.PrintSuccessf("Result: [would convert '%s' to kebab-case]", text)
 	default:
-		cmd.PrintErrorf("Unknown operation: %s", operation)
-		cmd.PrintInfof("Available operations: reverse, upper, lower, camel, snake, kebab")
-		return fmt.Errorf("unknown operation: %s", operation)
+		return cli.UsageErrorf("unknown operation %q", operation).
+			WithSuggestions(operation, stringOperations)
 	}
 
 	return nil
@@ -474,9 +449,8 @@ func runTemplateGeneration(templateName string) error {
 		fmt.Printf("Generator model initialized: %+v\n", genModel != nil)
 		return nil
 	default:
-		fmt.Printf("Unknown template: %s\n", templateName)
-		fmt.Println("Available templates: go-project, go-cli, go-tui")
-		return fmt.Errorf("unknown template: %s", templateName)
+		return cli.UsageErrorf("unknown template %q", templateName).
+			WithSuggestions(templateName, templateNames)
 	}
 }
 
@@ -535,11 +509,12 @@ func (m embeddedTUIModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m embeddedTUIModel) View() string {
+	styles := theme.Current().Styles()
 	if m.quitting {
-		return quitTextStyle.Render("Thanks for using Toolbox TUI!")
+		return styles.Quit.Render("Thanks for using Toolbox TUI!")
 	}
 
-	s := titleStyle.Render("Toolbox Embedded TUI") + "\n\n"
+	s := styles.Title.Render("Toolbox Embedded TUI") + "\n\n"
 
 	for i, choice := range m.choices {
 		cursor := " "
@@ -553,14 +528,14 @@ func (m embeddedTUIModel) View() string {
 		}
 
 		if m.cursor == i {
-			s += selectedItemStyle.Render(fmt.Sprintf("%s [%s] %s", cursor, checked, choice))
+			s += styles.Selected.Render(fmt.Sprintf("%s [%s] %s", cursor, checked, choice))
 		} else {
-			s += itemStyle.Render(fmt.Sprintf("%s [%s] %s", cursor, checked, choice))
+			s += styles.Item.Render(fmt.Sprintf("%s [%s] %s", cursor, checked, choice))
 		}
 		s += "\n"
 	}
 
-	s += helpStyle.Render("\nPress q to quit, enter to select.")
+	s += styles.Help.Render("\nPress q to quit, enter to select.")
 
 	return s
 }
@@ -602,9 +577,11 @@ func (m messageModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m messageModel) View() string {
-	s := titleStyle.Render("Toolbox Feature") + "\n\n"
-	s += itemStyle.Render(m.message) + "\n\n"
-	s += helpStyle.Render("Press b/esc to go back, q to quit")
+	styles := theme.Current().Styles()
+
+	s := styles.Title.Render("Toolbox Feature") + "\n\n"
+	s += styles.Item.Render(m.message) + "\n\n"
+	s += styles.Help.Render("Press b/esc to go back, q to quit")
 	return s
 }
 
@@ -612,8 +589,7 @@ func (m messageModel) View() string {
 func runTUIMode(_ []string) {
 	p := tea.NewProgram(initialEmbeddedTUIModel(), tea.WithAltScreen())
 	if _, err := p.Run(); err != nil {
-		fmt.Printf("Error running TUI: %v", err)
-		os.Exit(1)
+		cli.Fatal(cli.WrapError(cli.KindGeneral, err, "error running TUI"))
 	}
 }
 
@@ -4,54 +4,27 @@ package main
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/lipgloss"
 
 	"github.com/nate3d/go-toolbox/internal/config"
+	"github.com/nate3d/go-toolbox/internal/filecmd"
 	"github.com/nate3d/go-toolbox/internal/generator"
 	"github.com/nate3d/go-toolbox/internal/logger"
+	"github.com/nate3d/go-toolbox/internal/theme"
 )
 
 const appName = "toolbox-tui"
 
-// Constants for UI styling
+// Key bindings
 const (
-	// Padding values
-	paddingSmall  = 2
-	paddingMedium = 4
-
-	// Key bindings
 	keyCtrlC = "ctrl+c"
 	keyEsc   = "esc"
 	keyQ     = "q"
 	keyB     = "b"
 )
 
-// Styles.
-var (
-	titleStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("#FAFAFA")).
-			Background(lipgloss.Color("#7D56F4")).
-			Padding(0, 1)
-
-	itemStyle = lipgloss.NewStyle().
-			PaddingLeft(paddingMedium)
-
-	selectedItemStyle = lipgloss.NewStyle().
-				PaddingLeft(paddingSmall).
-				Foreground(lipgloss.Color("170"))
-
-	helpStyle = lipgloss.NewStyle().
-			PaddingLeft(paddingMedium).
-			PaddingTop(1).
-			Foreground(lipgloss.Color("241"))
-
-	quitTextStyle = lipgloss.NewStyle().
-			Margin(1, 0, paddingSmall, paddingMedium)
-)
-
 // Model represents the application state
 type model struct {
 	choices  []string
@@ -117,11 +90,12 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 // View implements tea.Model
 func (m model) View() string {
+	styles := theme.Current().Styles()
 	if m.quitting {
-		return quitTextStyle.Render("Thanks for using Toolbox TUI!")
+		return styles.Quit.Render("Thanks for using Toolbox TUI!")
 	}
 
-	s := titleStyle.Render("Toolbox TUI") + "\n\n"
+	s := styles.Title.Render("Toolbox TUI") + "\n\n"
 
 	for i, choice := range m.choices {
 		cursor := " "
@@ -135,14 +109,14 @@ func (m model) View() string {
 		}
 
 		if m.cursor == i {
-			s += selectedItemStyle.Render(fmt.Sprintf("%s [%s] %s", cursor, checked, choice))
+			s += styles.Selected.Render(fmt.Sprintf("%s [%s] %s", cursor, checked, choice))
 		} else {
-			s += itemStyle.Render(fmt.Sprintf("%s [%s] %s", cursor, checked, choice))
+			s += styles.Item.Render(fmt.Sprintf("%s [%s] %s", cursor, checked, choice))
 		}
 		s += "\n"
 	}
 
-	s += helpStyle.Render("\nPress q to quit, enter to select.")
+	s += styles.Help.Render("\nPress q to quit, enter to select.")
 
 	return s
 }
@@ -168,8 +142,14 @@ func (m model) handleMenuSelection() (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// treeViewDepth is how many levels the directory tree view shows
+const treeViewDepth = 2
+
 // File Operations Model
-type fileOpsModel struct{}
+type fileOpsModel struct {
+	// tree is the rendered directory tree view, empty while it is hidden
+	tree string
+}
 
 func NewFileOperationsModel() tea.Model {
 	return fileOpsModel{}
@@ -187,23 +167,53 @@ func (m fileOpsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, tea.Quit
 		case keyEsc, keyB:
 			return initialModel(), nil
+		case "t":
+			if m.tree != "" {
+				m.tree = ""
+			} else {
+				m.tree = renderDirectoryTree(".")
+			}
 		}
 	}
 	return m, nil
 }
 
 func (m fileOpsModel) View() string {
-	s := titleStyle.Render("File Operations") + "\n\n"
-	s += itemStyle.Render("This is where file operations would be implemented.") + "\n"
-	s += itemStyle.Render("Features could include:") + "\n"
-	s += itemStyle.Render("  • File hash calculation") + "\n"
-	s += itemStyle.Render("  • File size analysis") + "\n"
-	s += itemStyle.Render("  • Directory tree view") + "\n"
-	s += itemStyle.Render("  • File search") + "\n\n"
-	s += helpStyle.Render("Press 'b' or 'esc' to go back, 'q' to quit.")
+	styles := theme.Current().Styles()
+
+	if m.tree != "" {
+		s := styles.Title.Render("Directory Tree") + "\n\n"
+		s += m.tree + "\n"
+		s += styles.Help.Render("Press 't' to hide the tree, 'b' or 'esc' to go back, 'q' to quit.")
+		return s
+	}
+
+	s := styles.Title.Render("File Operations") + "\n\n"
+	s += styles.Item.Render("This is where file operations would be implemented.") + "\n"
+	s += styles.Item.Render("Features could include:") + "\n"
+	s += styles.Item.Render("  • File hash calculation") + "\n"
+	s += styles.Item.Render("  • File size analysis") + "\n"
+	s += styles.Item.Render("  • Directory tree view (press 't')") + "\n"
+	s += styles.Item.Render("  • File search") + "\n\n"
+	s += styles.Help.Render("Press 't' for the directory tree, 'b' or 'esc' to go back, 'q' to quit.")
 	return s
 }
 
+// renderDirectoryTree draws the top levels of dir as "toolbox file tree" does.
+func renderDirectoryTree(dir string) string {
+	root, err := filecmd.BuildTree(dir, filecmd.TreeOptions{
+		Depth:      treeViewDepth,
+		SortBy:     filecmd.TreeSortName,
+		ShowHidden: config.GetBool("file.show_hidden"),
+	})
+	if err != nil {
+		return theme.Current().Styles().Error.Render(err.Error())
+	}
+	var b strings.Builder
+	filecmd.RenderTree(&b, root, filecmd.TreeOptions{})
+	return b.String()
+}
+
 // Network Tools Model
 type networkToolsModel struct{}
 
@@ -229,14 +239,16 @@ func (m networkToolsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m networkToolsModel) View() string {
-	s := titleStyle.Render("Network Tools") + "\n\n"
-	s += itemStyle.Render("Network utilities would be implemented here.") + "\n"
-	s += itemStyle.Render("Features could include:") + "\n"
-	s += itemStyle.Render("  • Ping tool") + "\n"
-	s += itemStyle.Render("  • Port scanner") + "\n"
-	s += itemStyle.Render("  • Network interface info") + "\n"
-	s += itemStyle.Render("  • DNS lookup") + "\n\n"
-	s += helpStyle.Render("Press 'b' or 'esc' to go back, 'q' to quit.")
+	styles := theme.Current().Styles()
+
+	s := styles.Title.Render("Network Tools") + "\n\n"
+	s += styles.Item.Render("Network utilities would be implemented here.") + "\n"
+	s += styles.Item.Render("Features could include:") + "\n"
+	s += styles.Item.Render("  • Ping tool") + "\n"
+	s += styles.Item.Render("  • Port scanner") + "\n"
+	s += styles.Item.Render("  • Network interface info") + "\n"
+	s += styles.Item.Render("  • DNS lookup") + "\n\n"
+	s += styles.Help.Render("Press 'b' or 'esc' to go back, 'q' to quit.")
 	return s
 }
 
@@ -265,15 +277,17 @@ func (m systemInfoModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m systemInfoModel) View() string {
-	s := titleStyle.Render("System Information") + "\n\n"
-	s += itemStyle.Render("System information would be displayed here.") + "\n"
-	s += itemStyle.Render("Information could include:") + "\n"
-	s += itemStyle.Render("  • OS and version") + "\n"
-	s += itemStyle.Render("  • CPU information") + "\n"
-	s += itemStyle.Render("  • Memory usage") + "\n"
-	s += itemStyle.Render("  • Disk usage") + "\n"
-	s += itemStyle.Render("  • Running processes") + "\n\n"
-	s += helpStyle.Render("Press 'b' or 'esc' to go back, 'q' to quit.")
+	styles := theme.Current().Styles()
+
+	s := styles.Title.Render("System Information") + "\n\n"
+	s += styles.Item.Render("System information would be displayed here.") + "\n"
+	s += styles.Item.Render("Information could include:") + "\n"
+	s += styles.Item.Render("  • OS and version") + "\n"
+	s += styles.Item.Render("  • CPU information") + "\n"
+	s += styles.Item.Render("  • Memory usage") + "\n"
+	s += styles.Item.Render("  • Disk usage") + "\n"
+	s += styles.Item.Render("  • Running processes") + "\n\n"
+	s += styles.Help.Render("Press 'b' or 'esc' to go back, 'q' to quit.")
 	return s
 }
 
@@ -302,14 +316,16 @@ func (m stringUtilsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m stringUtilsModel) View() string {
-	s := titleStyle.Render("String Utilities") + "\n\n"
-	s += itemStyle.Render("String manipulation tools would be here.") + "\n"
-	s += itemStyle.Render("Operations could include:") + "\n"
-	s += itemStyle.Render("  • Case conversions") + "\n"
-	s += itemStyle.Render("  • String reversal") + "\n"
-	s += itemStyle.Render("  • Text encoding/decoding") + "\n"
-	s += itemStyle.Render("  • Regular expression testing") + "\n\n"
-	s += helpStyle.Render("Press 'b' or 'esc' to go back, 'q' to quit.")
+	styles := theme.Current().Styles()
+
+	s := styles.Title.Render("String Utilities") + "\n\n"
+	s += styles.Item.Render("String manipulation tools would be here.") + "\n"
+	s += styles.Item.Render("Operations could include:") + "\n"
+	s += styles.Item.Render("  • Case conversions") + "\n"
+	s += styles.Item.Render("  • String reversal") + "\n"
+	s += styles.Item.Render("  • Text encoding/decoding") + "\n"
+	s += styles.Item.Render("  • Regular expression testing") + "\n\n"
+	s += styles.Help.Render("Press 'b' or 'esc' to go back, 'q' to quit.")
 	return s
 }
 
@@ -338,14 +354,16 @@ func (m randomGenModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m randomGenModel) View() string {
-	s := titleStyle.Render("Random Generators") + "\n\n"
-	s += itemStyle.Render("Random generation tools would be here.") + "\n"
-	s += itemStyle.Render("Generators could include:") + "\n"
-	s += itemStyle.Render("  • Random strings") + "\n"
-	s += itemStyle.Render("  • UUIDs") + "\n"
-	s += itemStyle.Render("  • Passwords") + "\n"
-	s += itemStyle.Render("  • Random numbers") + "\n\n"
-	s += helpStyle.Render("Press 'b' or 'esc' to go back, 'q' to quit.")
+	styles := theme.Current().Styles()
+
+	s := styles.Title.Render("Random Generators") + "\n\n"
+	s += styles.Item.Render("Random generation tools would be here.") + "\n"
+	s += styles.Item.Render("Generators could include:") + "\n"
+	s += styles.Item.Render("  • Random strings") + "\n"
+	s += styles.Item.Render("  • UUIDs") + "\n"
+	s += styles.Item.Render("  • Passwords") + "\n"
+	s += styles.Item.Render("  • Random numbers") + "\n\n"
+	s += styles.Help.Render("Press 'b' or 'esc' to go back, 'q' to quit.")
 	return s
 }
 
@@ -374,14 +392,16 @@ func (m configModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m configModel) View() string {
-	s := titleStyle.Render("Configuration") + "\n\n"
-	s += itemStyle.Render("Configuration settings would be here.") + "\n"
-	s += itemStyle.Render("Settings could include:") + "\n"
-	s += itemStyle.Render("  • Theme selection") + "\n"
-	s += itemStyle.Render("  • Default output formats") + "\n"
-	s += itemStyle.Render("  • Logging preferences") + "\n"
-	s += itemStyle.Render("  • Key bindings") + "\n\n"
-	s += helpStyle.Render("Press 'b' or 'esc' to go back, 'q' to quit.")
+	styles := theme.Current().Styles()
+
+	s := styles.Title.Render("Configuration") + "\n\n"
+	s += styles.Item.Render("Configuration settings would be here.") + "\n"
+	s += styles.Item.Render("Settings could include:") + "\n"
+	s += styles.Item.Render("  • Theme selection") + "\n"
+	s += styles.Item.Render("  • Default output formats") + "\n"
+	s += styles.Item.Render("  • Logging preferences") + "\n"
+	s += styles.Item.Render("  • Key bindings") + "\n\n"
+	s += styles.Help.Render("Press 'b' or 'esc' to go back, 'q' to quit.")
 	return s
 }
 
@@ -392,6 +412,12 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Apply the configured color theme
+	if err := theme.Load(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading theme: %v\n", err)
+		os.Exit(1)
+	}
+
 	// Initialize logger
 	logConfig := logger.Config{
 		Level:      logger.LogLevel(config.GetString("log_level")),
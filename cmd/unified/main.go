@@ -8,12 +8,22 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
+
+	"github.com/nate3d/go-toolbox/internal/cli"
+	"github.com/nate3d/go-toolbox/internal/config"
+	"github.com/nate3d/go-toolbox/internal/plugin"
+	"github.com/nate3d/go-toolbox/internal/profile"
+	"github.com/nate3d/go-toolbox/internal/theme"
 )
 
 const (
 	appName    = "go-toolbox"
 	appVersion = "0.1.0"
 
+	// configName selects the configuration shared with the toolbox binary,
+	// ~/.config/toolbox/config.yaml and TOOLBOX_ variables
+	configName = "toolbox"
+
 	modeTUI    = "tui"
 	modeUI     = "ui"
 	modeServe  = "serve"
@@ -21,7 +31,16 @@ const (
 	modeCLI    = "cli"
 )
 
+// templateNames lists the templates accepted by "generate template"
+var templateNames = []string{"go-project", "go-cli", "go-tui"}
+
 func main() {
+	// Start profiling first so it covers configuration setup
+	session, err := profile.Start(profile.FromArgs(os.Args[1:]), os.Stderr)
+	if err != nil {
+		cli.Fatal(err)
+	}
+
 	// Detect execution mode based on binary name or first argument
 	mode := detectMode()
 
@@ -31,9 +50,9 @@ func main() {
 	case modeServe, modeServer:
 		runServerMode(os.Args[1:])
 	case modeCLI, "":
-		runCLIMode()
+		runCLIMode(session)
 	default:
-		runCLIMode() // Default to CLI mode
+		runCLIMode(session) // Default to CLI mode
 	}
 }
 
@@ -69,7 +88,12 @@ func detectMode() string {
 }
 
 // runCLIMode starts the CLI interface with subcommands
-func runCLIMode() {
+func runCLIMode(session *profile.Session) {
+	if err := config.Init(configName); err != nil {
+		cli.Fatal(cli.WrapError(cli.KindConfig, err, "error initializing config"))
+	}
+	session.Mark("config init")
+
 	rootCmd := &cobra.Command{
 		Use:     appName,
 		Short:   "Go Toolbox - A collection of useful Go tools",
@@ -89,7 +113,23 @@ Examples:
   
 You can also create symlinks for convenience:
   ln -s go-toolbox toolbox-tui
-  ln -s go-toolbox toolbox-serve`,
+  ln -s go-toolbox toolbox-serve
+
+Plugins:
+  Executables named toolbox-<name> on PATH or in ~/.config/toolbox/plugins
+  run as "go-toolbox <name>". Global flags reach them as TOOLBOX_CONFIG,
+  TOOLBOX_LOG_LEVEL, TOOLBOX_OUTPUT, TOOLBOX_VERBOSE and TOOLBOX_COLOR.`,
+		// Plugins parse their own flags and load --config themselves
+		PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+			file, _ := cmd.Flags().GetString("config")
+			if file == "" {
+				return nil
+			}
+			if err := config.LoadFile(file); err != nil {
+				return cli.WrapError(cli.KindConfig, err, "cannot load %s", file)
+			}
+			return nil
+		},
 	}
 
 	// Add mode subcommands
@@ -97,16 +137,30 @@ You can also create symlinks for convenience:
 	rootCmd.AddCommand(createServeCommand())
 	rootCmd.AddCommand(createGenerateCommand())
 	rootCmd.AddCommand(createVersionCommand())
+	rootCmd.AddCommand(cli.NewCompletionCommand(rootCmd))
+	rootCmd.AddCommand(cli.NewDocsCommand(rootCmd))
 
 	// Add global flags
 	rootCmd.PersistentFlags().StringP("config", "c", "", "config file path")
 	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "verbose output")
 	rootCmd.PersistentFlags().Bool("debug", false, "debug mode")
+	rootCmd.PersistentFlags().String("output", "table", "output format (table, json, yaml)")
+	rootCmd.PersistentFlags().Var(theme.NewColorFlag(), "color", "colorize output: auto, always or never")
+	_ = rootCmd.RegisterFlagCompletionFunc("output", cli.CompleteOutputFormats)
+	_ = rootCmd.RegisterFlagCompletionFunc("color", cli.CompleteValues(theme.ColorModes...))
+
+	profile.AddFlags(rootCmd.PersistentFlags())
+
+	// Add toolbox-<name> executables from PATH and ~/.config/toolbox/plugins
+	plugin.Register(rootCmd, os.Args[1:])
 
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+	code := cli.Execute(rootCmd)
+	session.Mark("command run")
+	if err := session.Stop(); err != nil {
+		cli.ReportError(os.Stderr, err, cli.OutputTable)
+		code = max(code, cli.ExitGeneral)
 	}
+	os.Exit(code)
 }
 
 // createTUICommand creates the TUI subcommand
@@ -151,9 +205,10 @@ func createGenerateCommand() *cobra.Command {
 
 	// Add generate subcommands
 	cmd.AddCommand(&cobra.Command{
-		Use:   "template [name]",
-		Short: "Generate a code template",
-		Args:  cobra.ExactArgs(1),
+		Use:               "template [name]",
+		Short:             "Generate a code template",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: cli.CompletePositional(cli.CompleteValues(templateNames...)),
 		Run: func(_ *cobra.Command, args []string) {
 			fmt.Printf("Generating template: %s\n", args[0])
 			// TODO: Implement template generation
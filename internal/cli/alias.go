@@ -0,0 +1,391 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/nate3d/go-toolbox/internal/config"
+)
+
+// Alias is a user-defined command from the aliases section of the config.
+// A single step is a plain alias; several steps make a macro run in order.
+type Alias struct {
+	Name  string   `json:"name"  yaml:"name"`
+	Steps []string `json:"steps" yaml:"steps"`
+}
+
+var (
+	aliasNamePattern   = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+	aliasArgPattern    = regexp.MustCompile(`\$(\d+)`)
+	errUnterminatedArg = errors.New("unterminated quote")
+)
+
+// Aliases returns the aliases defined in the configuration, sorted by name.
+// Each alias is a command line or a list of command lines:
+//
+//	aliases:
+//	  h512: "file hash --algo sha512"
+//	  checkup:
+//	    - "system info"
+//	    - "network ping $1"
+func Aliases() ([]Alias, error) {
+	raw, ok := config.Value("aliases").(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+
+	aliases := make([]Alias, 0, len(raw))
+	for name, value := range raw {
+		var steps []string
+		switch v := value.(type) {
+		case string:
+			steps = []string{v}
+		case []interface{}:
+			for _, step := range v {
+				steps = append(steps, fmt.Sprint(step))
+			}
+		default:
+			return nil, NewError(KindConfig, "alias %q must be a command line or a list of them", name)
+		}
+		aliases = append(aliases, Alias{Name: name, Steps: steps})
+	}
+	sort.Slice(aliases, func(i, j int) bool { return aliases[i].Name < aliases[j].Name })
+	return aliases, nil
+}
+
+// Shadows reports whether the alias has the name of a built-in command of
+// root. Built-in commands always win over such aliases.
+func (a Alias) Shadows(root *cobra.Command) bool {
+	if a.Name == "help" {
+		return true
+	}
+	for _, cmd := range root.Commands() {
+		if cmd.Name() == a.Name || cmd.HasAlias(a.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Expand returns the argument lists to run for the alias invoked with args.
+// $1, $2, ... are replaced by single arguments and a "$@" word by all of
+// them. If no step uses a placeholder, args are appended to the last step.
+func (a Alias) Expand(args []string) ([][]string, error) {
+	steps := make([][]string, 0, len(a.Steps))
+	used := false
+	for _, step := range a.Steps {
+		words, err := SplitArgs(step)
+		if err != nil {
+			return nil, NewError(KindConfig, "alias %q: %v", a.Name, err)
+		}
+
+		expanded := make([]string, 0, len(words))
+		for _, word := range words {
+			if word == "$@" {
+				expanded = append(expanded, args...)
+				used = true
+				continue
+			}
+			var missing int
+			word = aliasArgPattern.ReplaceAllStringFunc(word, func(match string) string {
+				n, _ := strconv.Atoi(match[1:])
+				used = true
+				if n < 1 || n > len(args) {
+					missing = max(missing, n)
+					return ""
+				}
+				return args[n-1]
+			})
+			if missing > 0 {
+				return nil, UsageErrorf("alias %q needs at least %d argument(s), got %d", a.Name, missing, len(args))
+			}
+			expanded = append(expanded, word)
+		}
+		steps = append(steps, expanded)
+	}
+
+	if !used && len(steps) > 0 {
+		last := len(steps) - 1
+		steps[last] = append(steps[last], args...)
+	}
+	return steps, nil
+}
+
+// expandAliases turns the command line args into the argument lists to run.
+// Only the first argument is considered; built-in commands are never
+// replaced by an alias.
+func expandAliases(root *cobra.Command, args []string) ([][]string, error) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return [][]string{args}, nil
+	}
+	if found, _, err := root.Find(args); err == nil && found != root {
+		return [][]string{args}, nil
+	}
+
+	aliases, err := Aliases()
+	if err != nil {
+		return nil, err
+	}
+	for _, alias := range aliases {
+		if alias.Name == args[0] && !alias.Shadows(root) {
+			return alias.Expand(args[1:])
+		}
+	}
+	return [][]string{args}, nil
+}
+
+// SplitArgs splits a command line into words. Single and double quotes
+// group words and a backslash escapes the next character outside single
+// quotes.
+func SplitArgs(line string) ([]string, error) {
+	var (
+		words   []string
+		word    strings.Builder
+		inWord  bool
+		quote   rune
+		escaped bool
+	)
+	for _, r := range line {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inWord = true
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			word.WriteRune(r)
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, fmt.Errorf("%w in %q", errUnterminatedArg, line)
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// resetFlags restores every flag in the command tree to its default so
+// root can execute another command line in the same process.
+func resetFlags(root *cobra.Command) {
+	reset := func(f *pflag.Flag) {
+		if f.Changed {
+			resetFlag(f)
+		}
+	}
+
+	root.Flags().VisitAll(reset)
+	root.PersistentFlags().VisitAll(reset)
+	for _, cmd := range root.Commands() {
+		resetFlags(cmd)
+	}
+}
+
+// resetFlag restores f to its default value.
+func resetFlag(f *pflag.Flag) {
+	if slice, ok := f.Value.(pflag.SliceValue); ok {
+		defaults := strings.Trim(f.DefValue, "[]")
+		values := []string{}
+		if defaults != "" {
+			values = strings.Split(defaults, ",")
+		}
+		_ = slice.Replace(values)
+	} else {
+		_ = f.Value.Set(f.DefValue)
+	}
+	f.Changed = false
+}
+
+// NewAliasCommand creates the "alias" command for managing aliases of root.
+func NewAliasCommand(root *cobra.Command) *cobra.Command {
+	baseCmd := NewBaseCommand("alias", "Manage command aliases and macros")
+	baseCmd.Long = `Aliases are user-defined commands stored under "aliases" in the config file.
+An alias with several command lines is a macro that runs them in order and
+stops at the first failure. $1, $2, ... are replaced by the arguments given
+to the alias and "$@" by all of them; without placeholders the arguments are
+appended to the last command line. Aliases cannot replace built-in commands.`
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List aliases",
+		Args:  cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return runAliasList(baseCmd, root)
+		},
+	}
+
+	addCmd := &cobra.Command{
+		Use:   "add <name> <command line>...",
+		Short: "Add or replace an alias",
+		Example: `  toolbox alias add h512 'file hash --algo sha512'
+  toolbox alias add checkup 'system info' 'network ping $1'`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return runAliasAdd(baseCmd, root, args[0], args[1:])
+		},
+	}
+
+	removeCmd := &cobra.Command{
+		Use:               "remove <name>",
+		Aliases:           []string{"rm"},
+		Short:             "Remove an alias",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: CompletePositional(completeAliasNames),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return runAliasRemove(baseCmd, root, args[0])
+		},
+	}
+
+	baseCmd.AddCommand(listCmd)
+	baseCmd.AddCommand(addCmd)
+	baseCmd.AddCommand(removeCmd)
+
+	return baseCmd.Command
+}
+
+// completeAliasNames completes the names of defined aliases.
+func completeAliasNames(_ *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	aliases, _ := Aliases()
+	names := make([]string, 0, len(aliases))
+	for _, alias := range aliases {
+		names = append(names, alias.Name)
+	}
+	return filterPrefix(names, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+func runAliasList(cmd *BaseCommand, root *cobra.Command) error {
+	aliases, err := Aliases()
+	if err != nil {
+		return err
+	}
+	if printed, err := cmd.PrintData(aliases); printed {
+		return err
+	}
+	if len(aliases) == 0 {
+		cmd.PrintInfof("No aliases defined")
+		return nil
+	}
+
+	table := cmd.NewTable([]string{"Alias", "Command", "Status"})
+	for _, alias := range aliases {
+		status := "ok"
+		if alias.Shadows(root) {
+			status = "shadowed by built-in"
+		}
+		table.AddRow(alias.Name, strings.Join(alias.Steps, " && "), status)
+	}
+	table.Render()
+	return nil
+}
+
+func runAliasAdd(cmd *BaseCommand, root *cobra.Command, name string, steps []string) error {
+	alias := Alias{Name: name, Steps: steps}
+	if !aliasNamePattern.MatchString(name) {
+		return UsageErrorf("invalid alias name %q", name).
+			WithHint("Use lower-case letters, digits, '-' and '_'.")
+	}
+	if alias.Shadows(root) {
+		return UsageErrorf("alias %q would shadow the built-in command", name)
+	}
+	for _, step := range steps {
+		if _, err := SplitArgs(step); err != nil {
+			return WrapError(KindUsage, err, "")
+		}
+	}
+
+	aliases, err := aliasMap()
+	if err != nil {
+		return err
+	}
+	if len(steps) == 1 {
+		aliases[name] = steps[0]
+	} else {
+		aliases[name] = steps
+	}
+	if err := saveAliases(root, aliases); err != nil {
+		return err
+	}
+	cmd.PrintSuccessf("Alias %q saved", name)
+	return nil
+}
+
+func runAliasRemove(cmd *BaseCommand, root *cobra.Command, name string) error {
+	aliases, err := aliasMap()
+	if err != nil {
+		return err
+	}
+	if _, ok := aliases[name]; !ok {
+		names := make([]string, 0, len(aliases))
+		for existing := range aliases {
+			names = append(names, existing)
+		}
+		return NewError(KindNotFound, "unknown alias %q", name).WithSuggestions(name, names)
+	}
+
+	delete(aliases, name)
+	if err := config.Unset("aliases." + name); err != nil {
+		return WrapError(KindConfig, err, "error removing alias")
+	}
+	if err := saveAliases(root, aliases); err != nil {
+		return err
+	}
+	cmd.PrintSuccessf("Alias %q removed", name)
+	return nil
+}
+
+// aliasMap returns the aliases in their config form for editing.
+func aliasMap() (map[string]interface{}, error) {
+	aliases, err := Aliases()
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]interface{}, len(aliases))
+	for _, alias := range aliases {
+		if len(alias.Steps) == 1 {
+			m[alias.Name] = alias.Steps[0]
+		} else {
+			m[alias.Name] = alias.Steps
+		}
+	}
+	return m, nil
+}
+
+// saveAliases stores aliases in the config file that was read, or in the
+// user config directory if there is none yet.
+func saveAliases(root *cobra.Command, aliases map[string]interface{}) error {
+	file := config.FileUsed()
+	if file == "" {
+		dir, err := config.GetConfigDir(root.Name())
+		if err != nil {
+			return WrapError(KindConfig, err, "error saving aliases")
+		}
+		file = filepath.Join(dir, "config.yaml")
+	}
+	if err := config.SetInFile(file, "aliases", aliases); err != nil {
+		return WrapError(KindConfig, err, "error saving aliases")
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"go.yaml.in/yaml/v3"
+
+	"github.com/nate3d/go-toolbox/internal/config"
+)
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		line    string
+		want    []string
+		wantErr bool
+	}{
+		{"file hash --algo sha512", []string{"file", "hash", "--algo", "sha512"}, false},
+		{`  utils string upper "hello world" `, []string{"utils", "string", "upper", "hello world"}, false},
+		{`echo 'a "b"' c\ d`, []string{"echo", `a "b"`, "c d"}, false},
+		{`empty ""`, []string{"empty", ""}, false},
+		{`broken "quote`, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			got, err := SplitArgs(tt.line)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SplitArgs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SplitArgs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAliasExpand(t *testing.T) {
+	tests := []struct {
+		name    string
+		steps   []string
+		args    []string
+		want    [][]string
+		wantErr bool
+	}{
+		{
+			name:  "appends arguments",
+			steps: []string{"file hash --algo sha512"},
+			args:  []string{"a.txt"},
+			want:  [][]string{{"file", "hash", "--algo", "sha512", "a.txt"}},
+		},
+		{
+			name:  "positional placeholders",
+			steps: []string{"system info", "network ping $1 --timeout=$2"},
+			args:  []string{"example.com", "2s"},
+			want:  [][]string{{"system", "info"}, {"network", "ping", "example.com", "--timeout=2s"}},
+		},
+		{
+			name:  "all arguments",
+			steps: []string{"utils string $@"},
+			args:  []string{"upper", "hello world"},
+			want:  [][]string{{"utils", "string", "upper", "hello world"}},
+		},
+		{
+			name:    "missing argument",
+			steps:   []string{"network ping $2"},
+			args:    []string{"only-one"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Alias{Name: "a", Steps: tt.steps}.Expand(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expand() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expand() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func newAliasTestRoot(t *testing.T) *cobra.Command {
+	t.Helper()
+	config.Set("aliases", map[string]interface{}{
+		"sys":  "system info",
+		"file": "system ps",
+		"both": []interface{}{"system info", "system ps"},
+	})
+	t.Cleanup(func() { config.Set("aliases", map[string]interface{}{}) })
+
+	root := &cobra.Command{Use: "toolbox"}
+	system := &cobra.Command{Use: "system"}
+	system.AddCommand(&cobra.Command{Use: "info", Run: func(*cobra.Command, []string) {}})
+	root.AddCommand(system, &cobra.Command{Use: "file", Aliases: []string{"f"}})
+	return root
+}
+
+func TestExpandAliases(t *testing.T) {
+	root := newAliasTestRoot(t)
+
+	tests := []struct {
+		args []string
+		want [][]string
+	}{
+		{[]string{"sys", "-v"}, [][]string{{"system", "info", "-v"}}},
+		{[]string{"both"}, [][]string{{"system", "info"}, {"system", "ps"}}},
+		{[]string{"file", "x"}, [][]string{{"file", "x"}}},
+		{[]string{"system", "info"}, [][]string{{"system", "info"}}},
+		{[]string{"unknown"}, [][]string{{"unknown"}}},
+	}
+	for _, tt := range tests {
+		got, err := expandAliases(root, tt.args)
+		if err != nil {
+			t.Fatalf("expandAliases(%q) error = %v", tt.args, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("expandAliases(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestAliasShadows(t *testing.T) {
+	root := newAliasTestRoot(t)
+	for name, want := range map[string]bool{"file": true, "f": true, "help": true, "sys": false} {
+		if got := (Alias{Name: name}).Shadows(root); got != want {
+			t.Errorf("Shadows(%q) = %v, want %v", name, got, want)
+		}
+	}
+
+	if err := runAliasAdd(NewBaseCommand("alias", ""), root, "system", []string{"system ps"}); ExitCode(err) != ExitUsage {
+		t.Errorf("adding a shadowing alias: error = %v, want usage error", err)
+	}
+}
+
+func TestResetFlags(t *testing.T) {
+	root := &cobra.Command{Use: "toolbox"}
+	var name string
+	var tags []string
+	root.PersistentFlags().StringVar(&name, "name", "default", "")
+	root.Flags().StringSliceVar(&tags, "tag", nil, "")
+	root.SetArgs([]string{"--name", "x", "--tag", "a,b"})
+	root.Run = func(*cobra.Command, []string) {}
+	if err := root.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	resetFlags(root)
+	if name != "default" || len(tags) != 0 {
+		t.Errorf("after reset name = %q, tags = %q", name, tags)
+	}
+	if root.Flag("name").Changed {
+		t.Error("flag still marked as changed")
+	}
+}
+
+func TestAliasAddKeepsConfigFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(file, []byte("log_level: warn\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	viper.SetConfigFile(file)
+	if err := viper.ReadInConfig(); err != nil {
+		t.Fatal(err)
+	}
+	viper.SetDefault("cli.pager", "less")
+	viper.Set("log_level", "debug") // as TOOLBOX_LOG_LEVEL would
+	t.Cleanup(viper.Reset)
+
+	cmd := NewBaseCommand("alias", "")
+	cmd.SetOut(&bytes.Buffer{})
+	if err := runAliasAdd(cmd, &cobra.Command{Use: "toolbox"}, "up", []string{"system info"}); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var settings map[string]interface{}
+	if err := yaml.Unmarshal(data, &settings); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"log_level": "warn",
+		"aliases":   map[string]interface{}{"up": "system info"},
+	}
+	if !reflect.DeepEqual(settings, want) {
+		t.Errorf("config file = %v, want %v", settings, want)
+	}
+}
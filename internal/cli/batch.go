@@ -0,0 +1,442 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.yaml.in/yaml/v3"
+
+	"github.com/nate3d/go-toolbox/internal/theme"
+)
+
+// Batch job statuses
+const (
+	BatchOK      = "ok"
+	BatchFailed  = "failed"
+	BatchSkipped = "skipped"
+)
+
+// batchReportMode is the file mode of --report files
+const batchReportMode = 0o600
+
+// unbatchable are commands that cannot run inside a batch: they read stdin
+// interactively or would nest batches.
+var unbatchable = []string{"batch", "shell"}
+
+// BatchJob is one command of a batch file.
+type BatchJob struct {
+	Name    string `yaml:"name"`
+	Command string `yaml:"command"`
+
+	args []string
+}
+
+// UnmarshalYAML accepts a job as a plain command string or as a mapping
+// with name and command.
+func (j *BatchJob) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		j.Command = node.Value
+		return nil
+	}
+	type plain BatchJob
+	return node.Decode((*plain)(j))
+}
+
+// BatchResult is the outcome of one job.
+type BatchResult struct {
+	Name       string       `json:"name"             yaml:"name"`
+	Command    string       `json:"command"          yaml:"command"`
+	Status     string       `json:"status"           yaml:"status"`
+	ExitCode   int          `json:"exit_code"        yaml:"exit_code"`
+	DurationMS int64        `json:"duration_ms"      yaml:"duration_ms"`
+	Output     string       `json:"output,omitempty" yaml:"output,omitempty"`
+	Error      *errorDetail `json:"error,omitempty"  yaml:"error,omitempty"`
+
+	stderr string
+}
+
+// BatchReport is the combined result of a batch run.
+type BatchReport struct {
+	Jobs       []BatchResult `json:"jobs"        yaml:"jobs"`
+	Succeeded  int           `json:"succeeded"   yaml:"succeeded"`
+	Failed     int           `json:"failed"      yaml:"failed"`
+	Skipped    int           `json:"skipped"     yaml:"skipped"`
+	DurationMS int64         `json:"duration_ms" yaml:"duration_ms"`
+}
+
+// ParseBatch parses a batch file. It is either a YAML list whose items are
+// command strings or {name, command} mappings, or plain text with one
+// command per line, where blank lines and lines starting with # are skipped.
+func ParseBatch(data []byte) ([]BatchJob, error) {
+	var jobs []BatchJob
+	if isYAMLList(data) {
+		if err := yaml.Unmarshal(data, &jobs); err != nil {
+			return nil, fmt.Errorf("error parsing batch file: %w", err)
+		}
+	} else {
+		for _, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			if line != "" && !strings.HasPrefix(line, "#") {
+				jobs = append(jobs, BatchJob{Command: line})
+			}
+		}
+	}
+
+	for i := range jobs {
+		job := &jobs[i]
+		args, err := SplitArgs(job.Command)
+		if err != nil {
+			return nil, fmt.Errorf("job %d: %w", i+1, err)
+		}
+		if len(args) == 0 {
+			return nil, fmt.Errorf("job %d has no command", i+1)
+		}
+		job.args = args
+		if job.Name == "" {
+			job.Name = job.Command
+		}
+	}
+	return jobs, nil
+}
+
+// isYAMLList reports whether the first line with content starts a YAML list item.
+func isYAMLList(data []byte) bool {
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		return line == "-" || strings.HasPrefix(line, "- ")
+	}
+	return false
+}
+
+// batchOptions are the flags of the batch command.
+type batchOptions struct {
+	parallel  int
+	failFast  bool
+	keepGoing bool
+	report    string
+}
+
+// NewBatchCommand creates the "batch" command, which runs a list of commands
+// of root in-process. newRoot builds an independent command tree for each
+// extra worker of --parallel, since a cobra tree holds the parsed flags and
+// cannot run two commands at once.
+func NewBatchCommand(root *cobra.Command, newRoot func() *cobra.Command) *cobra.Command {
+	opts := &batchOptions{}
+	baseCmd := NewBaseCommand("batch <file|->", "Run a list of commands")
+	baseCmd.Long = `Run a list of toolbox commands from a file, or from stdin with "-", in a
+single process. The file holds one command per line, with # comments, or a
+YAML list of commands or {name, command} mappings:
+
+  - system info
+  - name: checksums
+    command: file hash go.sum
+
+Commands run in order and the batch stops at the first failure unless
+--keep-going is set. With --parallel N up to N commands run at once. Each
+command's output is shown once it finishes, followed by a summary of exit
+statuses and durations. With --output json or yaml, or --report, the
+combined report includes every command's output. Prompts are disabled.`
+	baseCmd.Example = `  toolbox batch jobs.txt
+  toolbox batch jobs.yaml --parallel 4 --keep-going --report report.json
+  printf 'system info\nutils string upper hi\n' | toolbox batch -`
+	baseCmd.Args = cobra.ExactArgs(1)
+	baseCmd.Flags().IntVarP(&opts.parallel, "parallel", "p", 1, "Number of commands to run at once")
+	baseCmd.Flags().BoolVar(&opts.failFast, "fail-fast", false, "Stop at the first failed command (default)")
+	baseCmd.Flags().BoolVarP(&opts.keepGoing, "keep-going", "k", false, "Run all commands even if some fail")
+	baseCmd.Flags().StringVar(&opts.report, "report", "", "Write the combined JSON report to a file")
+	baseCmd.MarkFlagsMutuallyExclusive("fail-fast", "keep-going")
+
+	baseCmd.RunE = func(_ *cobra.Command, args []string) error {
+		// Running jobs on root resets its flags, so take a copy first
+		options := *opts
+		return runBatch(baseCmd, root, newRoot, args[0], options)
+	}
+	return baseCmd.Command
+}
+
+func runBatch(cmd *BaseCommand, root *cobra.Command, newRoot func() *cobra.Command, source string, opts batchOptions) error {
+	if opts.parallel < 1 {
+		return UsageErrorf("--parallel must be at least 1")
+	}
+	jobs, err := readBatch(cmd, source)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	roots := []*cobra.Command{root}
+	for len(roots) < min(opts.parallel, len(jobs)) {
+		worker := newRoot()
+		prepareErrorHandling(worker)
+		roots = append(roots, worker)
+	}
+
+	format := cmd.Output
+	out, errOut := cmd.OutOrStdout(), cmd.ErrOrStderr()
+	runner := &batchRunner{roots: roots, failFast: !opts.keepGoing}
+	if format == OutputTable {
+		runner.done = func(_ int, result BatchResult) {
+			printBatchResult(out, errOut, result)
+		}
+	}
+
+	start := time.Now()
+	results := runner.run(ctx, jobs)
+	report := newBatchReport(results, time.Since(start))
+
+	if opts.report != "" {
+		if err := writeBatchReport(opts.report, report); err != nil {
+			return err
+		}
+	}
+	// Restore the flags of the batch command itself for printing
+	cmd.Output = format
+	if err := printBatchReport(cmd, report); err != nil {
+		return err
+	}
+	return batchExitStatus(ctx, results)
+}
+
+// readBatch reads and parses the batch file, or stdin for "-".
+func readBatch(cmd *BaseCommand, source string) ([]BatchJob, error) {
+	var data []byte
+	var err error
+	if source == "-" {
+		data, err = io.ReadAll(cmd.InOrStdin())
+	} else {
+		data, err = os.ReadFile(source) // #nosec G304 - the user names the batch file
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading batch file: %w", err)
+	}
+
+	jobs, err := ParseBatch(data)
+	if err != nil {
+		return nil, WrapError(KindUsage, err, "")
+	}
+	if len(jobs) == 0 {
+		return nil, UsageErrorf("no commands in %s", source)
+	}
+	return jobs, nil
+}
+
+// batchRunner runs jobs with one worker per root.
+type batchRunner struct {
+	roots    []*cobra.Command
+	failFast bool
+
+	// done, if set, is called as each job finishes, one call at a time
+	done func(i int, result BatchResult)
+	mu   sync.Mutex
+}
+
+// run executes the jobs and returns their results in input order. Jobs
+// that were not started because of a failure or an interrupt are skipped.
+func (r *batchRunner) run(ctx context.Context, jobs []BatchJob) []BatchResult {
+	results := make([]BatchResult, len(jobs))
+	for i, job := range jobs {
+		results[i] = BatchResult{Name: job.Name, Command: job.Command, Status: BatchSkipped}
+	}
+
+	var stopped atomic.Bool
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for _, root := range r.roots {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				if stopped.Load() || ctx.Err() != nil {
+					continue
+				}
+				result := runBatchJob(ctx, root, jobs[i])
+				if result.Status == BatchFailed && r.failFast {
+					stopped.Store(true)
+				}
+				r.finish(i, result, results)
+			}
+		}()
+	}
+	for i := range jobs {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	return results
+}
+
+func (r *batchRunner) finish(i int, result BatchResult, results []BatchResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	results[i] = result
+	if r.done != nil {
+		r.done(i, result)
+	}
+}
+
+// runBatchJob runs one job on root, capturing its output.
+func runBatchJob(ctx context.Context, root *cobra.Command, job BatchJob) BatchResult {
+	result := BatchResult{Name: job.Name, Command: job.Command, Status: BatchOK}
+
+	var stdout, stderr bytes.Buffer
+	root.SetOut(&stdout)
+	root.SetErr(&stderr)
+	defer func() {
+		root.SetOut(nil)
+		root.SetErr(nil)
+	}()
+	resetFlags(root)
+	root.SetContext(ctx)
+
+	start := time.Now()
+	err := checkBatchable(root, job.args)
+	var cmd *cobra.Command
+	step := job.args
+	if err == nil {
+		cmd, step, err = runSteps(root, job.args, batchPrepare(root))
+	}
+	result.DurationMS = time.Since(start).Milliseconds()
+
+	if err != nil {
+		result.Status = BatchFailed
+		result.ExitCode = reportExecuteError(&stderr, cmd, err, step)
+		// A child's exit status carries no error of its own
+		var status *ExitStatusError
+		if !errors.As(err, &status) {
+			detail := newErrorDetail(AsError(err))
+			result.Error = &detail
+		}
+	}
+	result.Output = stdout.String()
+	result.stderr = stderr.String()
+	return result
+}
+
+// checkBatchable rejects jobs that run commands which cannot be batched.
+func checkBatchable(root *cobra.Command, args []string) error {
+	steps, err := expandAliases(root, args)
+	if err != nil {
+		return err
+	}
+	for _, step := range steps {
+		target, _, err := root.Find(step)
+		if err != nil || target == root {
+			continue
+		}
+		for _, name := range unbatchable {
+			if target.Name() == name {
+				return UsageErrorf("%q cannot run inside a batch", name)
+			}
+		}
+	}
+	return nil
+}
+
+// batchPrepare disables prompts for commands that support --no-input,
+// since stdin may hold the batch itself and output is captured.
+func batchPrepare(root *cobra.Command) func([]string) []string {
+	return func(step []string) []string {
+		target, _, err := root.Find(step)
+		if err != nil {
+			return step
+		}
+		flag := target.Flag("no-input")
+		if flag == nil || hasFlag(step[:flagsEnd(step)], flag) {
+			return step
+		}
+		return insertFlags(step, []string{"--no-input"})
+	}
+}
+
+// printBatchResult shows a finished job's output under a header.
+func printBatchResult(out, errOut io.Writer, result BatchResult) {
+	palette := theme.Current()
+	_, _ = palette.Color(theme.Header).Fprintf(out, "==> %s\n", result.Name)
+	_, _ = io.WriteString(out, result.Output)
+	_, _ = io.WriteString(errOut, result.stderr)
+}
+
+func newBatchReport(results []BatchResult, elapsed time.Duration) BatchReport {
+	report := BatchReport{Jobs: results, DurationMS: elapsed.Milliseconds()}
+	for _, result := range results {
+		switch result.Status {
+		case BatchOK:
+			report.Succeeded++
+		case BatchFailed:
+			report.Failed++
+		default:
+			report.Skipped++
+		}
+	}
+	return report
+}
+
+func writeBatchReport(path string, report BatchReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding batch report: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), batchReportMode); err != nil {
+		return fmt.Errorf("error writing batch report: %w", err)
+	}
+	return nil
+}
+
+// printBatchReport prints the report as data, or as a summary table.
+func printBatchReport(cmd *BaseCommand, report BatchReport) error {
+	if printed, err := cmd.PrintData(report); printed {
+		return err
+	}
+
+	table := cmd.NewTable([]string{"#", "Name", "Status", "Exit", "Duration"})
+	for i, result := range report.Jobs {
+		exit, duration := "-", "-"
+		if result.Status != BatchSkipped {
+			exit = strconv.Itoa(result.ExitCode)
+			duration = FormatDuration(time.Duration(result.DurationMS) * time.Millisecond)
+		}
+		table.AddRow(strconv.Itoa(i+1), result.Name, result.Status, exit, duration)
+	}
+	table.Render()
+
+	summary := fmt.Sprintf("%d succeeded, %d failed, %d skipped in %s", report.Succeeded, report.Failed,
+		report.Skipped, FormatDuration(time.Duration(report.DurationMS)*time.Millisecond))
+	if report.Failed > 0 {
+		cmd.PrintErrorf("%s", summary)
+	} else {
+		cmd.PrintSuccessf("%s", summary)
+	}
+	return nil
+}
+
+// batchExitStatus returns the exit status of the first failed job. Errors
+// have already been reported with each job.
+func batchExitStatus(ctx context.Context, results []BatchResult) error {
+	for _, result := range results {
+		if result.Status == BatchFailed {
+			return &ExitStatusError{Code: result.ExitCode}
+		}
+	}
+	if ctx.Err() != nil {
+		return &ExitStatusError{Code: ExitCancelled}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestParseBatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    []BatchJob
+		wantErr bool
+	}{
+		{
+			name: "lines",
+			data: "# checks\nsystem info\n\n  utils string upper \"a b\"  \n",
+			want: []BatchJob{
+				{Name: "system info", Command: "system info", args: []string{"system", "info"}},
+				{Name: `utils string upper "a b"`, Command: `utils string upper "a b"`, args: []string{"utils", "string", "upper", "a b"}},
+			},
+		},
+		{
+			name: "yaml list",
+			data: "# checks\n- system info\n- name: hash\n  command: file hash go.sum\n",
+			want: []BatchJob{
+				{Name: "system info", Command: "system info", args: []string{"system", "info"}},
+				{Name: "hash", Command: "file hash go.sum", args: []string{"file", "hash", "go.sum"}},
+			},
+		},
+		{name: "missing command", data: "- name: empty\n", wantErr: true},
+		{name: "unterminated quote", data: "utils string upper \"a\n", wantErr: true},
+		{name: "invalid yaml", data: "- [a\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseBatch([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseBatch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseBatch() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// newBatchTestRoot builds a tree with "ok", which prints its arguments, and
+// "fail", which fails with a not found error.
+func newBatchTestRoot() *cobra.Command {
+	root := &cobra.Command{Use: "toolbox"}
+	ok := NewBaseCommand("ok", "")
+	ok.Run = func(_ *cobra.Command, args []string) {
+		ok.PrintInfof("%v no-input=%v", args, ok.NoInput)
+	}
+	fail := NewBaseCommand("fail", "")
+	fail.RunE = func(*cobra.Command, []string) error {
+		return NewError(KindNotFound, "nothing here")
+	}
+	root.AddCommand(ok.Command, fail.Command, NewBaseCommand("shell", "").Command)
+	prepareErrorHandling(root)
+	return root
+}
+
+func batchJobs(t *testing.T, lines string) []BatchJob {
+	t.Helper()
+	jobs, err := ParseBatch([]byte(lines))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return jobs
+}
+
+func statuses(results []BatchResult) []string {
+	var got []string
+	for _, result := range results {
+		got = append(got, result.Status)
+	}
+	return got
+}
+
+func TestBatchRunner(t *testing.T) {
+	jobs := batchJobs(t, "ok a\nfail\nok b\nshell\n")
+
+	runner := &batchRunner{roots: []*cobra.Command{newBatchTestRoot()}, failFast: true}
+	results := runner.run(context.Background(), jobs)
+	if want := []string{BatchOK, BatchFailed, BatchSkipped, BatchSkipped}; !reflect.DeepEqual(statuses(results), want) {
+		t.Errorf("fail-fast statuses = %q, want %q", statuses(results), want)
+	}
+	if results[0].Output != "[a] no-input=true\n" {
+		t.Errorf("output = %q", results[0].Output)
+	}
+	if results[1].ExitCode != ExitNotFound || results[1].Error == nil || results[1].Error.Message != "nothing here" {
+		t.Errorf("failed result = %+v", results[1])
+	}
+
+	runner = &batchRunner{roots: []*cobra.Command{newBatchTestRoot(), newBatchTestRoot()}}
+	results = runner.run(context.Background(), jobs)
+	if want := []string{BatchOK, BatchFailed, BatchOK, BatchFailed}; !reflect.DeepEqual(statuses(results), want) {
+		t.Errorf("keep-going statuses = %q, want %q", statuses(results), want)
+	}
+	if results[2].Output != "[b] no-input=true\n" || results[3].ExitCode != ExitUsage {
+		t.Errorf("parallel results = %+v", results)
+	}
+
+	report := newBatchReport(results, 0)
+	if report.Succeeded != 2 || report.Failed != 2 || report.Skipped != 0 {
+		t.Errorf("report = %+v", report)
+	}
+	var status *ExitStatusError
+	if err := batchExitStatus(context.Background(), results); !errors.As(err, &status) || status.Code != ExitNotFound {
+		t.Errorf("batchExitStatus() = %v, want exit status %d", err, ExitNotFound)
+	}
+}
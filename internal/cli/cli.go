@@ -2,18 +2,21 @@
 package cli
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
-	"strconv"
 	"strings"
 	"time"
 
-	"github.com/fatih/color"
 	"github.com/manifoldco/promptui"
 	"github.com/olekukonko/tablewriter"
 	"github.com/schollz/progressbar/v3"
 	"github.com/spf13/cobra"
+	"go.yaml.in/yaml/v3"
+
+	"github.com/nate3d/go-toolbox/internal/theme"
 )
 
 // OutputFormat represents different output formats.
@@ -29,33 +32,22 @@ const (
 	progressBarThrottleMs  = 65
 	progressBarSpinnerType = 14
 	spinnerSleepMs         = 100
-
-	// Size constants
-	bytesPerKB = 1024
-	bytesPerMB = bytesPerKB * 1024
-	bytesPerGB = bytesPerMB * 1024
-	bytesPerTB = bytesPerGB * 1024
-
-	// Time conversion constants
-	msPerSecond = 1000
-	hoursPerDay = 24
-)
-
-// Colors for different message types.
-var (
-	InfoColor    = color.New(color.FgCyan)
-	SuccessColor = color.New(color.FgGreen)
-	WarnColor    = color.New(color.FgYellow)
-	ErrorColor   = color.New(color.FgRed)
-	HeaderColor  = color.New(color.FgBlue, color.Bold)
 )
 
 // BaseCommand provides common functionality for CLI commands.
 type BaseCommand struct {
 	*cobra.Command
 
-	Verbose bool
-	Output  OutputFormat
+	Verbose     bool
+	Output      OutputFormat
+	AssumeYes   bool
+	NoInput     bool
+	AnswersFile string
+	NoPager     bool
+	Watch       time.Duration
+
+	prompter Prompter
+	watcher  *watcher
 }
 
 // NewBaseCommand creates a new base command with common flags.
@@ -72,52 +64,93 @@ func NewBaseCommand(use, short string) *BaseCommand {
 	// Add common flags
 	cmd.PersistentFlags().BoolVarP(&baseCmd.Verbose, "verbose", "v", false, "Enable verbose output")
 	cmd.PersistentFlags().StringVar((*string)(&baseCmd.Output), "output", "table", "Output format (table, json, yaml)")
+	cmd.PersistentFlags().BoolVarP(&baseCmd.AssumeYes, "yes", "y", false, "Assume yes for confirmations and accept defaults")
+	cmd.PersistentFlags().BoolVar(&baseCmd.NoInput, "no-input", false, "Never prompt; fail if input is required")
+	cmd.PersistentFlags().StringVar(&baseCmd.AnswersFile, "answers", "", "YAML or JSON file with scripted prompt answers")
+	cmd.PersistentFlags().Var(theme.NewColorFlag(), "color", "Colorize output: auto, always or never")
+	cmd.PersistentFlags().BoolVar(&baseCmd.NoPager, "no-pager", false, "Do not pipe long output into a pager")
+	_ = cmd.RegisterFlagCompletionFunc("output", CompleteOutputFormats)
+	_ = cmd.RegisterFlagCompletionFunc("color", CompleteValues(theme.ColorModes...))
 
 	return baseCmd
 }
 
+// Prompter returns the prompter commands should use for user input.
+// Scripted answers from --answers and TOOLBOX_ANSWER_* variables take
+// precedence. Remaining prompts go to the terminal unless --yes or
+// --no-input is set or stdin is not a terminal, in which case they resolve
+// to defaults or fail with ErrInputRequired instead of hanging.
+func (c *BaseCommand) Prompter() (Prompter, error) {
+	if c.prompter != nil {
+		return c.prompter, nil
+	}
+
+	var fallback Prompter = NewPrompt()
+	if c.AssumeYes || c.NoInput || !stdinIsTerminal() {
+		fallback = NewNonInteractivePrompter(c.AssumeYes)
+	}
+
+	answers := AnswersFromEnv()
+	if c.AnswersFile != "" {
+		fileAnswers, err := LoadAnswers(c.AnswersFile)
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range fileAnswers {
+			answers[key] = value
+		}
+	}
+
+	return NewScriptedPrompter(answers, fallback), nil
+}
+
+// SetPrompter overrides the prompter returned by Prompter, e.g. with a FakePrompter in tests.
+func (c *BaseCommand) SetPrompter(p Prompter) {
+	c.prompter = p
+}
+
 // PrintInfof prints an info message.
 func (c *BaseCommand) PrintInfof(format string, args ...interface{}) {
 	if c.Output == OutputTable {
-		_, _ = InfoColor.Printf(format+"\n", args...)
+		_, _ = theme.Current().Color(theme.Info).Fprintf(c.OutOrStdout(), format+"\n", args...)
 	} else {
-		_, _ = fmt.Printf(format+"\n", args...)
+		_, _ = fmt.Fprintf(c.OutOrStdout(), format+"\n", args...)
 	}
 }
 
 // PrintSuccessf prints a success message.
 func (c *BaseCommand) PrintSuccessf(format string, args ...interface{}) {
 	if c.Output == OutputTable {
-		_, _ = SuccessColor.Printf(format+"\n", args...)
+		_, _ = theme.Current().Color(theme.Success).Fprintf(c.OutOrStdout(), format+"\n", args...)
 	} else {
-		_, _ = fmt.Printf(format+"\n", args...)
+		_, _ = fmt.Fprintf(c.OutOrStdout(), format+"\n", args...)
 	}
 }
 
 // PrintWarnf prints a warning message.
 func (c *BaseCommand) PrintWarnf(format string, args ...interface{}) {
 	if c.Output == OutputTable {
-		_, _ = WarnColor.Printf(format+"\n", args...)
+		_, _ = theme.Current().Color(theme.Warn).Fprintf(c.OutOrStdout(), format+"\n", args...)
 	} else {
-		_, _ = fmt.Printf(format+"\n", args...)
+		_, _ = fmt.Fprintf(c.OutOrStdout(), format+"\n", args...)
 	}
 }
 
 // PrintErrorf prints an error message.
 func (c *BaseCommand) PrintErrorf(format string, args ...interface{}) {
 	if c.Output == OutputTable {
-		_, _ = ErrorColor.Printf(format+"\n", args...)
+		_, _ = theme.Current().Color(theme.Error).Fprintf(c.OutOrStdout(), format+"\n", args...)
 	} else {
-		_, _ = fmt.Printf(format+"\n", args...)
+		_, _ = fmt.Fprintf(c.OutOrStdout(), format+"\n", args...)
 	}
 }
 
 // PrintHeaderf prints a header message.
 func (c *BaseCommand) PrintHeaderf(format string, args ...interface{}) {
 	if c.Output == OutputTable {
-		_, _ = HeaderColor.Printf(format+"\n", args...)
+		_, _ = theme.Current().Color(theme.Header).Fprintf(c.OutOrStdout(), format+"\n", args...)
 	} else {
-		_, _ = fmt.Printf(format+"\n", args...)
+		_, _ = fmt.Fprintf(c.OutOrStdout(), format+"\n", args...)
 	}
 }
 
@@ -128,16 +161,56 @@ func (c *BaseCommand) PrintVerbosef(format string, args ...interface{}) {
 	}
 }
 
+// PrintData writes v as indented JSON or as YAML when --output selects one
+// of them. It reports false for table output so callers can render a table.
+func (c *BaseCommand) PrintData(v interface{}) (bool, error) {
+	if c.watcher != nil && c.Output == OutputJSON {
+		c.watcher.record(v)
+		return true, nil
+	}
+
+	switch c.Output {
+	case OutputJSON:
+		encoder := json.NewEncoder(c.OutOrStdout())
+		encoder.SetIndent("", "  ")
+		return true, encoder.Encode(v)
+	case OutputYAML:
+		encoder := yaml.NewEncoder(c.OutOrStdout())
+		defer func() { _ = encoder.Close() }()
+		return true, encoder.Encode(v)
+	default:
+		return false, nil
+	}
+}
+
 // Table provides utilities for creating tables.
 type Table struct {
 	writer  *tablewriter.Table
 	data    [][]string
 	headers []string
+
+	// highlight decorates rows before rendering, e.g. to mark changes in --watch mode
+	highlight func(headers []string, rows [][]string) [][]string
 }
 
-// NewTable creates a new table.
+// NewTable creates a new table that renders to stdout.
 func NewTable(headers []string) *Table {
-	table := tablewriter.NewWriter(os.Stdout)
+	return NewTableWriter(os.Stdout, headers)
+}
+
+// NewTable creates a new table that renders to the command's output, so it
+// is paged along with everything else the command prints.
+func (c *BaseCommand) NewTable(headers []string) *Table {
+	table := NewTableWriter(c.OutOrStdout(), headers)
+	if c.watcher != nil {
+		table.highlight = c.watcher.table
+	}
+	return table
+}
+
+// NewTableWriter creates a new table that renders to w.
+func NewTableWriter(w io.Writer, headers []string) *Table {
+	table := tablewriter.NewWriter(w)
 
 	return &Table{
 		writer:  table,
@@ -158,8 +231,13 @@ func (t *Table) Render() {
 		t.writer.Header(toInterfaceSlice(t.headers)...)
 	}
 
+	rows := t.data
+	if t.highlight != nil {
+		rows = t.highlight(t.headers, rows)
+	}
+
 	// Add all data rows
-	for _, row := range t.data {
+	for _, row := range rows {
 		_ = t.writer.Append(toInterfaceSlice(row)...)
 	}
 
@@ -184,19 +262,37 @@ type ProgressBar struct {
 // NewProgressBar creates a new progress bar.
 func NewProgressBar(maxValue int, description string) *ProgressBar {
 	bar := progressbar.NewOptions(maxValue,
+		append(progressOptions(description), progressbar.OptionShowCount())...,
+	)
+
+	return &ProgressBar{bar: bar}
+}
+
+// NewBytesProgressBar creates a progress bar for total bytes that renders to
+// stderr, so it does not mix with the command's output.
+func NewBytesProgressBar(total int64, description string) *ProgressBar {
+	bar := progressbar.NewOptions64(total,
+		append(progressOptions(description),
+			progressbar.OptionSetWriter(os.Stderr),
+			progressbar.OptionShowBytes(true),
+		)...,
+	)
+
+	return &ProgressBar{bar: bar}
+}
+
+func progressOptions(description string) []progressbar.Option {
+	return []progressbar.Option{
 		progressbar.OptionSetDescription(description),
 		progressbar.OptionSetWidth(progressBarWidth),
-		progressbar.OptionThrottle(progressBarThrottleMs*time.Millisecond),
-		progressbar.OptionShowCount(),
+		progressbar.OptionThrottle(progressBarThrottleMs * time.Millisecond),
 		progressbar.OptionOnCompletion(func() {
 			fmt.Fprint(os.Stderr, "\n")
 		}),
 		progressbar.OptionSpinnerType(progressBarSpinnerType),
 		progressbar.OptionFullWidth(),
 		progressbar.OptionSetRenderBlankState(true),
-	)
-
-	return &ProgressBar{bar: bar}
+	}
 }
 
 // Add increments the progress bar.
@@ -204,12 +300,18 @@ func (p *ProgressBar) Add(num int) {
 	_ = p.bar.Add(num)
 }
 
+// Add64 increments the progress bar by a 64-bit amount, e.g. bytes.
+func (p *ProgressBar) Add64(num int64) {
+	_ = p.bar.Add64(num)
+}
+
 // Finish completes the progress bar.
 func (p *ProgressBar) Finish() {
 	_ = p.bar.Finish()
 }
 
-// Prompt provides utilities for user input.
+// Prompt provides utilities for user input. It is the interactive terminal
+// implementation of Prompter.
 type Prompt struct{}
 
 // NewPrompt creates a new prompt.
@@ -317,63 +419,3 @@ func (s *Spinner) Stop() {
 	s.stop <- true
 	_, _ = fmt.Print("\r")
 }
-
-// ParseSize parses a size string (e.g., "1KB", "2MB") into bytes.
-func ParseSize(sizeStr string) (int64, error) {
-	sizeStr = strings.ToUpper(strings.TrimSpace(sizeStr))
-
-	multipliers := map[string]int64{
-		"B":  1,
-		"KB": bytesPerKB,
-		"MB": bytesPerMB,
-		"GB": bytesPerGB,
-		"TB": bytesPerTB,
-	}
-
-	for suffix, multiplier := range multipliers {
-		if strings.HasSuffix(sizeStr, suffix) {
-			numStr := strings.TrimSuffix(sizeStr, suffix)
-			num, err := strconv.ParseFloat(numStr, 64)
-			if err != nil {
-				return 0, fmt.Errorf("invalid size format: %s", sizeStr)
-			}
-			return int64(num * float64(multiplier)), nil
-		}
-	}
-
-	// If no suffix, assume bytes
-	num, err := strconv.ParseInt(sizeStr, 10, 64)
-	if err != nil {
-		return 0, fmt.Errorf("invalid size format: %s", sizeStr)
-	}
-
-	return num, nil
-}
-
-// FormatSize formats bytes into a human-readable string.
-func FormatSize(bytes int64) string {
-	const unit = 1024
-	if bytes < unit {
-		return fmt.Sprintf("%d B", bytes)
-	}
-	div, exp := int64(unit), 0
-	for n := bytes / unit; n >= unit; n /= unit {
-		div *= unit
-		exp++
-	}
-	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
-}
-
-// FormatDuration formats a duration into a human-readable string.
-func FormatDuration(d time.Duration) string {
-	if d < time.Second {
-		return fmt.Sprintf("%.0fms", d.Seconds()*msPerSecond)
-	}
-	if d < time.Minute {
-		return fmt.Sprintf("%.1fs", d.Seconds())
-	}
-	if d < time.Hour {
-		return fmt.Sprintf("%.1fm", d.Minutes())
-	}
-	return fmt.Sprintf("%.1fd", d.Hours()/hoursPerDay)
-}
@@ -0,0 +1,178 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nate3d/go-toolbox/internal/config"
+)
+
+// Shells supported by the completion command.
+const (
+	shellBash       = "bash"
+	shellZsh        = "zsh"
+	shellFish       = "fish"
+	shellPowerShell = "powershell"
+)
+
+// OutputFormats lists the values accepted by --output.
+var OutputFormats = []string{string(OutputTable), string(OutputJSON), string(OutputYAML)}
+
+// NewCompletionCommand creates the "completion" command that prints shell
+// completion scripts for root. It replaces cobra's default completion command.
+func NewCompletionCommand(root *cobra.Command) *cobra.Command {
+	root.CompletionOptions.DisableDefaultCmd = true
+	name := root.Name()
+
+	return &cobra.Command{
+		Use:   "completion [bash|zsh|fish|powershell]",
+		Short: "Generate shell completion scripts",
+		Long: `Generate a completion script for your shell.
+
+Besides commands and flags, completions cover argument values such as
+string operations, template names, output formats, config keys and
+hosts from ~/.ssh/known_hosts.`,
+		Example: fmt.Sprintf(`  # Bash (current shell)
+  source <(%[1]s completion bash)
+
+  # Zsh (add to ~/.zshrc)
+  source <(%[1]s completion zsh)
+
+  # Fish
+  %[1]s completion fish > ~/.config/fish/completions/%[1]s.fish
+
+  # PowerShell (add to $PROFILE)
+  %[1]s completion powershell | Out-String | Invoke-Expression`, name),
+		ValidArgs:             []string{shellBash, shellZsh, shellFish, shellPowerShell},
+		Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+		DisableFlagsInUseLine: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			out := cmd.OutOrStdout()
+			switch args[0] {
+			case shellBash:
+				return root.GenBashCompletionV2(out, true)
+			case shellZsh:
+				return root.GenZshCompletion(out)
+			case shellFish:
+				return root.GenFishCompletion(out, true)
+			default:
+				return root.GenPowerShellCompletionWithDesc(out)
+			}
+		},
+	}
+}
+
+// CompleteValues completes from a fixed list of values without falling back to files.
+func CompleteValues(values ...string) cobra.CompletionFunc {
+	return func(_ *cobra.Command, _ []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		return filterPrefix(values, toComplete), cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// CompletePositional completes the i-th positional argument with fns[i].
+// Arguments beyond the list get no completions.
+func CompletePositional(fns ...cobra.CompletionFunc) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		if len(args) >= len(fns) || fns[len(args)] == nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return fns[len(args)](cmd, args, toComplete)
+	}
+}
+
+// CompleteOutputFormats completes values for --output.
+func CompleteOutputFormats(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	return CompleteValues(OutputFormats...)(cmd, args, toComplete)
+}
+
+// CompleteConfigKeys completes dotted configuration keys such as "network.timeout".
+func CompleteConfigKeys(_ *cobra.Command, _ []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	return filterPrefix(config.Keys(), toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// CompleteKnownHosts completes hostnames from ~/.ssh/known_hosts.
+func CompleteKnownHosts(_ *cobra.Command, _ []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	hosts, err := KnownHosts(filepath.Join(home, ".ssh", "known_hosts"))
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return filterPrefix(hosts, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// KnownHosts returns the sorted, unique hostnames in an OpenSSH known_hosts
+// file. Hashed entries, wildcard patterns and negations are skipped and
+// "[host]:port" entries are reduced to the host.
+func KnownHosts(path string) ([]string, error) {
+	file, err := os.Open(path) // #nosec G304 - path is the user's known_hosts file
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	seen := make(map[string]struct{})
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		// Skip markers such as @cert-authority and @revoked
+		if strings.HasPrefix(fields[0], "@") {
+			if len(fields) < 2 {
+				continue
+			}
+			fields = fields[1:]
+		}
+		for _, host := range strings.Split(fields[0], ",") {
+			if host = knownHostName(host); host != "" {
+				seen[host] = struct{}{}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	hosts := make([]string, 0, len(seen))
+	for host := range seen {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	return hosts, nil
+}
+
+// knownHostName extracts a completable hostname from a known_hosts pattern.
+func knownHostName(pattern string) string {
+	if pattern == "" || strings.HasPrefix(pattern, "|") || strings.HasPrefix(pattern, "!") ||
+		strings.ContainsAny(pattern, "*?") {
+		return ""
+	}
+	if strings.HasPrefix(pattern, "[") {
+		if end := strings.Index(pattern, "]"); end > 0 {
+			return pattern[1:end]
+		}
+		return ""
+	}
+	return pattern
+}
+
+// filterPrefix returns the values starting with prefix.
+func filterPrefix(values []string, prefix string) []cobra.Completion {
+	matches := make([]cobra.Completion, 0, len(values))
+	for _, value := range values {
+		if strings.HasPrefix(value, prefix) {
+			matches = append(matches, value)
+		}
+	}
+	return matches
+}
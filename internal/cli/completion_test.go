@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestKnownHosts(t *testing.T) {
+	content := strings.Join([]string{
+		"# comment",
+		"github.com,140.82.121.4 ssh-ed25519 AAAA",
+		"[git.example.com]:2222 ssh-rsa AAAA",
+		"|1|hashed=|salt= ssh-rsa AAAA",
+		"@cert-authority *.example.org ssh-rsa AAAA",
+		"@revoked bastion.example.org ssh-rsa AAAA",
+		"!blocked.example.com,github.com ssh-rsa AAAA",
+		"",
+	}, "\n")
+	path := filepath.Join(t.TempDir(), "known_hosts")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := KnownHosts(path)
+	if err != nil {
+		t.Fatalf("KnownHosts() error = %v", err)
+	}
+	want := []string{"140.82.121.4", "bastion.example.org", "git.example.com", "github.com"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("KnownHosts() = %v, want %v", got, want)
+	}
+}
+
+func TestCompletePositional(t *testing.T) {
+	complete := CompletePositional(CompleteValues("reverse", "upper", "lower"))
+
+	got, directive := complete(nil, nil, "u")
+	if !reflect.DeepEqual(got, []string{"upper"}) || directive != cobra.ShellCompDirectiveNoFileComp {
+		t.Errorf("first argument = %v, %v", got, directive)
+	}
+	if got, _ = complete(nil, []string{"upper"}, ""); len(got) != 0 {
+		t.Errorf("second argument = %v, want none", got)
+	}
+}
+
+func TestCompletionCommand(t *testing.T) {
+	root := &cobra.Command{Use: "toolbox"}
+	root.AddCommand(NewBaseCommand("utils", "General utilities").Command)
+	root.AddCommand(NewCompletionCommand(root))
+
+	for _, shell := range []string{"bash", "zsh", "fish", "powershell"} {
+		var out bytes.Buffer
+		root.SetOut(&out)
+		root.SetArgs([]string{"completion", shell})
+		if err := root.Execute(); err != nil {
+			t.Fatalf("completion %s error = %v", shell, err)
+		}
+		if !strings.Contains(out.String(), "toolbox") {
+			t.Errorf("completion %s script does not mention the program name", shell)
+		}
+	}
+
+	root.SetArgs([]string{"completion", "tcsh"})
+	if err := root.Execute(); err == nil {
+		t.Error("completion tcsh error = nil, want error")
+	}
+}
+
+func TestOutputFlagCompletion(t *testing.T) {
+	root := &cobra.Command{Use: "toolbox"}
+	utils := NewBaseCommand("utils", "General utilities")
+	utils.AddCommand(&cobra.Command{Use: "random", Run: func(*cobra.Command, []string) {}})
+	root.AddCommand(utils.Command)
+
+	var out bytes.Buffer
+	root.SetOut(&out)
+	root.SetArgs([]string{cobra.ShellCompRequestCmd, "utils", "random", "--output", "j"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("__complete error = %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "json\n") {
+		t.Errorf("completions = %q, want json first", out.String())
+	}
+}
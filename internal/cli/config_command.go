@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nate3d/go-toolbox/internal/config"
+)
+
+// configEntry is a configuration key and its value in structured output.
+type configEntry struct {
+	Key   string      `json:"key"   yaml:"key"`
+	Value interface{} `json:"value" yaml:"value"`
+}
+
+// NewConfigCommand creates the "config" command for inspecting configuration.
+func NewConfigCommand() *cobra.Command {
+	baseCmd := NewBaseCommand("config", "Inspect configuration")
+
+	getCmd := &cobra.Command{
+		Use:               "get [key]",
+		Short:             "Print a configuration value",
+		Example:           "  toolbox config get network.timeout",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: CompletePositional(CompleteConfigKeys),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return runConfigGet(baseCmd, args[0])
+		},
+	}
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List all configuration values",
+		Args:  cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return runConfigList(baseCmd)
+		},
+	}
+
+	baseCmd.AddWatchFlag(getCmd)
+	baseCmd.AddWatchFlag(listCmd)
+	baseCmd.AddCommand(getCmd)
+	baseCmd.AddCommand(listCmd)
+
+	return baseCmd.Command
+}
+
+func runConfigGet(cmd *BaseCommand, key string) error {
+	if !config.IsSet(key) {
+		return NewError(KindNotFound, "unknown configuration key %q", key).
+			WithSuggestions(key, config.Keys())
+	}
+
+	value := config.Value(key)
+	if printed, err := cmd.PrintData(configEntry{Key: key, Value: value}); printed {
+		return err
+	}
+	_, _ = fmt.Fprintln(cmd.OutOrStdout(), formatConfigValue(value))
+	return nil
+}
+
+func runConfigList(cmd *BaseCommand) error {
+	keys := config.Keys()
+	entries := make([]configEntry, 0, len(keys))
+	for _, key := range keys {
+		entries = append(entries, configEntry{Key: key, Value: config.Value(key)})
+	}
+	if printed, err := cmd.PrintData(entries); printed {
+		return err
+	}
+
+	table := cmd.NewTable([]string{"Key", "Value"})
+	for _, entry := range entries {
+		table.AddRow(entry.Key, formatConfigValue(entry.Value))
+	}
+	table.Render()
+	return nil
+}
+
+// formatConfigValue renders a configuration value for plain-text output.
+func formatConfigValue(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,196 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cpuguy83/go-md2man/v2/md2man"
+	"github.com/spf13/cobra"
+	"github.com/spf13/cobra/doc"
+
+	"github.com/nate3d/go-toolbox/internal/config"
+)
+
+// Reference documentation formats accepted by "docs generate".
+const (
+	DocsMarkdown = "markdown"
+	DocsMan      = "man"
+	DocsReST     = "rst"
+)
+
+// DocsFormats lists the values accepted by "docs generate --format".
+var DocsFormats = []string{DocsMarkdown, DocsMan, DocsReST}
+
+// NewDocsCommand creates the hidden "docs" command that writes reference
+// documentation for every command of root.
+func NewDocsCommand(root *cobra.Command) *cobra.Command {
+	var format, dir string
+
+	docsCmd := &cobra.Command{
+		Use:    "docs",
+		Short:  "Generate reference documentation",
+		Hidden: true,
+	}
+
+	generateCmd := &cobra.Command{
+		Use:   "generate",
+		Short: "Generate man pages, Markdown or reStructuredText for all commands",
+		Long: `Generate reference documentation from the command tree. Pages include
+usage, flags and examples for every command, plus a page listing the
+configuration keys with their defaults.`,
+		Example: fmt.Sprintf(`  %[1]s docs generate --format markdown --dir docs/reference
+  %[1]s docs generate --format man --dir out/man`, root.Name()),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if err := GenerateDocs(root, format, dir); err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Wrote %s documentation to %s\n", format, dir)
+			return nil
+		},
+	}
+	generateCmd.Flags().StringVar(&format, "format", DocsMarkdown, "Output format (markdown, man, rst)")
+	generateCmd.Flags().StringVar(&dir, "dir", "docs/reference", "Output directory")
+	_ = generateCmd.RegisterFlagCompletionFunc("format", CompleteValues(DocsFormats...))
+	_ = generateCmd.MarkFlagDirname("dir")
+
+	docsCmd.AddCommand(generateCmd)
+	return docsCmd
+}
+
+// GenerateDocs writes reference documentation for root and its visible
+// subcommands to dir in the given format. Output is reproducible: it has no
+// generation timestamps, and man page dates honour SOURCE_DATE_EPOCH.
+func GenerateDocs(root *cobra.Command, format, dir string) error {
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return fmt.Errorf("error creating %s: %w", dir, err)
+	}
+	disableAutoGenTag(root)
+
+	var err error
+	switch format {
+	case DocsMarkdown:
+		err = doc.GenMarkdownTree(root, dir)
+	case DocsReST:
+		err = doc.GenReSTTree(root, dir)
+	case DocsMan:
+		date, dateErr := manDate()
+		if dateErr != nil {
+			return dateErr
+		}
+		err = doc.GenManTree(root, &doc.GenManHeader{
+			Date:   &date,
+			Source: strings.TrimSpace(root.Name() + " " + root.Version),
+			Manual: root.Name() + " manual",
+		}, dir)
+	default:
+		return UsageErrorf("unknown documentation format %q", format).
+			WithSuggestions(format, DocsFormats)
+	}
+	if err != nil {
+		return fmt.Errorf("error generating %s documentation: %w", format, err)
+	}
+
+	return writeConfigReference(root, format, dir)
+}
+
+// manDate returns the date for man page headers: SOURCE_DATE_EPOCH when set,
+// for reproducible builds, otherwise the current time.
+func manDate() (time.Time, error) {
+	epoch := os.Getenv("SOURCE_DATE_EPOCH")
+	if epoch == "" {
+		return time.Now(), nil
+	}
+	seconds, err := strconv.ParseInt(epoch, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid SOURCE_DATE_EPOCH: %w", err)
+	}
+	return time.Unix(seconds, 0).UTC(), nil
+}
+
+// disableAutoGenTag drops cobra's dated footer from every page.
+func disableAutoGenTag(cmd *cobra.Command) {
+	cmd.DisableAutoGenTag = true
+	for _, sub := range cmd.Commands() {
+		disableAutoGenTag(sub)
+	}
+}
+
+// writeConfigReference writes the page documenting configuration keys.
+func writeConfigReference(root *cobra.Command, format, dir string) error {
+	name := root.Name()
+	var path string
+	var content []byte
+
+	switch format {
+	case DocsMan:
+		date, err := manDate()
+		if err != nil {
+			return err
+		}
+		path = filepath.Join(dir, name+"-configuration.5")
+		header := fmt.Sprintf("%% %q %q %q %q %q\n", strings.ToUpper(name)+"-CONFIGURATION", "5",
+			date.Format("Jan 2006"), strings.TrimSpace(name+" "+root.Version), name+" manual")
+		content = md2man.Render([]byte(header + configReferenceMarkdown(name)))
+	case DocsReST:
+		path = filepath.Join(dir, name+"_configuration.rst")
+		content = []byte(configReferenceReST(name))
+	default:
+		path = filepath.Join(dir, name+"_configuration.md")
+		content = []byte(configReferenceMarkdown(name))
+	}
+
+	if err := os.WriteFile(path, content, 0600); err != nil {
+		return fmt.Errorf("error writing %s: %w", path, err)
+	}
+	return nil
+}
+
+// configReferenceIntro describes where configuration is read from.
+func configReferenceIntro(name string) string {
+	return fmt.Sprintf(`Configuration is read from config.yaml in ./configs, $HOME/.config/%[1]s,
+/etc/%[1]s or the working directory, in that order. Every key can also be
+set with an environment variable: %[2]s_ followed by the key in upper case
+with dots replaced by underscores, e.g. %[2]s_NETWORK_TIMEOUT.
+`, name, strings.ToUpper(name))
+}
+
+// configReferenceMarkdown renders the configuration keys as a Markdown table.
+func configReferenceMarkdown(name string) string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# %s configuration\n\n", name)
+	buf.WriteString(configReferenceIntro(name))
+	buf.WriteString("\n| Key | Default | Description |\n| --- | --- | --- |\n")
+	for _, info := range config.KnownKeys() {
+		fmt.Fprintf(&buf, "| `%s` | `%s` | %s |\n", info.Key, formatDefault(info.Default), info.Description)
+	}
+	return buf.String()
+}
+
+// configReferenceReST renders the configuration keys as a reStructuredText list table.
+func configReferenceReST(name string) string {
+	var buf bytes.Buffer
+	title := name + " configuration"
+	fmt.Fprintf(&buf, "%s\n%s\n\n", title, strings.Repeat("=", len(title)))
+	buf.WriteString(configReferenceIntro(name))
+	buf.WriteString("\n.. list-table::\n   :header-rows: 1\n\n   * - Key\n     - Default\n     - Description\n")
+	for _, info := range config.KnownKeys() {
+		fmt.Fprintf(&buf, "   * - ``%s``\n     - ``%s``\n     - %s\n", info.Key, formatDefault(info.Default), info.Description)
+	}
+	return buf.String()
+}
+
+// formatDefault renders a default value as it would appear in JSON.
+func formatDefault(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func newDocsTestRoot() *cobra.Command {
+	root := &cobra.Command{Use: "toolbox", Version: "0.1.0"}
+	utils := NewBaseCommand("utils", "General utilities")
+	utils.AddCommand(&cobra.Command{
+		Use:     "random",
+		Short:   "Generate random strings",
+		Example: "  toolbox utils random",
+		Run:     func(*cobra.Command, []string) {},
+	})
+	root.AddCommand(utils.Command)
+	root.AddCommand(NewDocsCommand(root))
+	return root
+}
+
+func TestGenerateDocsFormats(t *testing.T) {
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+	tests := []struct {
+		format string
+		page   string
+		config string
+	}{
+		{DocsMarkdown, "toolbox_utils_random.md", "toolbox_configuration.md"},
+		{DocsReST, "toolbox_utils_random.rst", "toolbox_configuration.rst"},
+		{DocsMan, "toolbox-utils-random.1", "toolbox-configuration.5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := GenerateDocs(newDocsTestRoot(), tt.format, dir); err != nil {
+				t.Fatalf("GenerateDocs() error = %v", err)
+			}
+
+			page, err := os.ReadFile(filepath.Join(dir, tt.page)) // #nosec G304 - test temp dir
+			if err != nil {
+				t.Fatalf("missing command page: %v", err)
+			}
+			for _, want := range []string{"toolbox utils random", "--output"} {
+				if !strings.Contains(strings.ReplaceAll(string(page), `\-`, "-"), want) {
+					t.Errorf("%s does not mention %q", tt.page, want)
+				}
+			}
+
+			reference, err := os.ReadFile(filepath.Join(dir, tt.config)) // #nosec G304 - test temp dir
+			if err != nil {
+				t.Fatalf("missing configuration page: %v", err)
+			}
+			if !strings.Contains(string(reference), "network.timeout") {
+				t.Errorf("%s does not list network.timeout", tt.config)
+			}
+
+			// Hidden commands such as docs itself are not documented
+			if matches, _ := filepath.Glob(filepath.Join(dir, "*docs*")); len(matches) != 0 {
+				t.Errorf("hidden command documented: %v", matches)
+			}
+		})
+	}
+}
+
+func TestGenerateDocsUnknownFormat(t *testing.T) {
+	err := GenerateDocs(newDocsTestRoot(), "mann", t.TempDir())
+	if AsError(err).Kind != KindUsage {
+		t.Errorf("GenerateDocs() error = %v, want usage error", err)
+	}
+}
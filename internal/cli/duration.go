@@ -0,0 +1,290 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"github.com/nate3d/go-toolbox/internal/config"
+)
+
+const (
+	// Day is 24 hours. Durations in days and weeks ignore daylight saving changes.
+	Day = 24 * time.Hour
+	// Week is 7 days.
+	Week = 7 * Day
+
+	// Approximate lengths used only for relative phrases.
+	relativeMonth = 30 * Day
+	relativeYear  = 365 * Day
+)
+
+// ErrInvalidDuration is returned when a duration string cannot be parsed.
+var ErrInvalidDuration = errors.New("invalid duration")
+
+// durationUnit pairs a unit suffix with its length.
+type durationUnit struct {
+	suffix string
+	length time.Duration
+}
+
+// formatUnits are the units used by FormatDuration, largest first.
+var formatUnits = []durationUnit{
+	{"d", Day},
+	{"h", time.Hour},
+	{"m", time.Minute},
+	{"s", time.Second},
+	{"ms", time.Millisecond},
+}
+
+// parseUnits are the suffixes accepted by ParseDuration.
+var parseUnits = map[string]time.Duration{
+	"ns": time.Nanosecond,
+	"us": time.Microsecond,
+	"µs": time.Microsecond,
+	"μs": time.Microsecond,
+	"ms": time.Millisecond,
+	"s":  time.Second,
+	"m":  time.Minute,
+	"h":  time.Hour,
+	"d":  Day,
+	"w":  Week,
+}
+
+// relativeUnits are the units used by FormatRelative, largest first.
+var relativeUnits = []struct {
+	name   string
+	length time.Duration
+}{
+	{"year", relativeYear},
+	{"month", relativeMonth},
+	{"week", Week},
+	{"day", Day},
+	{"hour", time.Hour},
+	{"minute", time.Minute},
+	{"second", time.Second},
+}
+
+// Register the duration rule for configuration keys holding durations.
+func init() {
+	config.RegisterRule("network.timeout", DurationRule())
+	config.RegisterRule("system.refresh_interval", DurationRule())
+}
+
+// durationFormat holds the settings applied by DurationOption values.
+type durationFormat struct {
+	units int
+}
+
+// DurationOption customizes FormatDuration.
+type DurationOption func(*durationFormat)
+
+// WithDurationUnits limits output to the n largest non-zero units, rounding
+// the rest: 2h 3m 40s becomes "2h 4m" with n=2. Zero means no limit.
+func WithDurationUnits(n int) DurationOption {
+	return func(f *durationFormat) {
+		f.units = max(n, 0)
+	}
+}
+
+// FormatDuration formats a duration as compound units, e.g. "2h 3m 10s".
+// Durations under a minute keep millisecond precision, e.g. "1s 500ms";
+// longer durations are rounded to the second.
+func FormatDuration(d time.Duration, opts ...DurationOption) string {
+	format := durationFormat{}
+	for _, opt := range opts {
+		opt(&format)
+	}
+
+	sign := ""
+	if d < 0 {
+		sign = "-"
+		d = -d
+	}
+	if d == 0 {
+		return "0s"
+	}
+	if d < time.Millisecond {
+		return sign + "0ms"
+	}
+
+	// Durations under a minute keep millisecond precision; others stop at seconds
+	smallest := len(formatUnits) - 1
+	if d >= time.Minute {
+		smallest--
+	}
+	d = d.Round(formatUnits[smallest].length)
+
+	// Round to the last unit that will be shown when the unit count is limited
+	if format.units > 0 {
+		first := 0
+		for first < smallest && d < formatUnits[first].length {
+			first++
+		}
+		last := min(first+format.units-1, smallest)
+		d = d.Round(formatUnits[last].length)
+	}
+
+	parts := make([]string, 0, len(formatUnits))
+	for _, unit := range formatUnits[:smallest+1] {
+		count := d / unit.length
+		d -= count * unit.length
+		if count == 0 {
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%d%s", count, unit.suffix))
+		if format.units > 0 && len(parts) == format.units {
+			break
+		}
+	}
+	return sign + strings.Join(parts, " ")
+}
+
+// FormatRelative describes t relative to now, e.g. "3 minutes ago" or "in 2 days".
+// Months and years are approximated as 30 and 365 days.
+func FormatRelative(t, now time.Time) string {
+	d := now.Sub(t)
+	past := d >= 0
+	if !past {
+		d = -d
+	}
+	if d < time.Second {
+		return "just now"
+	}
+
+	phrase := ""
+	for _, unit := range relativeUnits {
+		if d >= unit.length {
+			count := int64(d / unit.length)
+			phrase = fmt.Sprintf("%d %s", count, unit.name)
+			if count != 1 {
+				phrase += "s"
+			}
+			break
+		}
+	}
+
+	if past {
+		return phrase + " ago"
+	}
+	return "in " + phrase
+}
+
+// ParseDuration parses a duration such as "90s", "1d12h", "2w" or "1h 30m".
+// It accepts everything time.ParseDuration does plus "d" (24h) and "w" (7d)
+// units and spaces between components.
+func ParseDuration(s string) (time.Duration, error) {
+	input := strings.ReplaceAll(strings.TrimSpace(s), " ", "")
+	if input == "" {
+		return 0, fmt.Errorf("%w: empty value", ErrInvalidDuration)
+	}
+
+	negative := false
+	switch input[0] {
+	case '-':
+		negative = true
+		input = input[1:]
+	case '+':
+		input = input[1:]
+	}
+	if input == "" {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidDuration, s)
+	}
+	if input == "0" {
+		return 0, nil
+	}
+
+	var total float64
+	for input != "" {
+		numEnd := strings.IndexFunc(input, func(r rune) bool {
+			return (r < '0' || r > '9') && r != '.'
+		})
+		if numEnd <= 0 {
+			return 0, fmt.Errorf("%w: %q", ErrInvalidDuration, s)
+		}
+		value, err := strconv.ParseFloat(input[:numEnd], 64)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %q", ErrInvalidDuration, s)
+		}
+		input = input[numEnd:]
+
+		unitEnd := strings.IndexFunc(input, func(r rune) bool {
+			return (r >= '0' && r <= '9') || r == '.'
+		})
+		if unitEnd < 0 {
+			unitEnd = len(input)
+		}
+		unit, ok := parseUnits[input[:unitEnd]]
+		if !ok {
+			return 0, fmt.Errorf("%w: missing or unknown unit in %q", ErrInvalidDuration, s)
+		}
+		input = input[unitEnd:]
+
+		total += value * float64(unit)
+	}
+
+	if total > math.MaxInt64 {
+		return 0, fmt.Errorf("%w: %q is out of range", ErrInvalidDuration, s)
+	}
+	d := time.Duration(math.Round(total))
+	if negative {
+		d = -d
+	}
+	return d, nil
+}
+
+// DurationRule validates configuration values with ParseDuration.
+func DurationRule() config.Rule {
+	return func(value string) error {
+		if _, err := ParseDuration(value); err != nil {
+			return fmt.Errorf("%w: %w", config.ErrInvalidValue, err)
+		}
+		return nil
+	}
+}
+
+// ConfigDuration returns the configuration value at key parsed with ParseDuration.
+func ConfigDuration(key string) (time.Duration, error) {
+	d, err := ParseDuration(config.GetString(key))
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", key, err)
+	}
+	return d, nil
+}
+
+// DurationValue is a pflag.Value accepting ParseDuration syntax.
+type DurationValue time.Duration
+
+var _ pflag.Value = (*DurationValue)(nil)
+
+// DurationVar defines a duration flag that accepts "1d12h" and "2w" as well
+// as standard Go durations.
+func DurationVar(flags *pflag.FlagSet, p *time.Duration, name string, value time.Duration, usage string) {
+	*p = value
+	flags.Var((*DurationValue)(p), name, usage)
+}
+
+// Set implements pflag.Value.
+func (d *DurationValue) Set(s string) error {
+	parsed, err := ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = DurationValue(parsed)
+	return nil
+}
+
+// String implements pflag.Value.
+func (d *DurationValue) String() string {
+	return FormatDuration(time.Duration(*d))
+}
+
+// Type implements pflag.Value.
+func (d *DurationValue) Type() string {
+	return "duration"
+}
@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		opts []DurationOption
+		want string
+	}{
+		{0, nil, "0s"},
+		{250 * time.Millisecond, nil, "250ms"},
+		{1500 * time.Millisecond, nil, "1s 500ms"},
+		{2 * time.Second, nil, "2s"},
+		{59*time.Second + 999600*time.Microsecond, nil, "1m"},
+		{90*time.Second + 400*time.Millisecond, nil, "1m 30s"},
+		{1500 * time.Millisecond, []DurationOption{WithDurationUnits(1)}, "2s"},
+		{90 * time.Second, nil, "1m 30s"},
+		{2 * time.Hour, nil, "2h"},
+		{2*time.Hour + 3*time.Minute + 10*time.Second, nil, "2h 3m 10s"},
+		{-(2*time.Hour + 3*time.Minute), nil, "-2h 3m"},
+		{36 * time.Hour, nil, "1d 12h"},
+		{2*time.Hour + 3*time.Minute + 40*time.Second, []DurationOption{WithDurationUnits(2)}, "2h 4m"},
+		{59*time.Minute + 40*time.Second, []DurationOption{WithDurationUnits(1)}, "1h"},
+		{Day + time.Second, []DurationOption{WithDurationUnits(2)}, "1d"},
+	}
+	for _, tt := range tests {
+		if got := FormatDuration(tt.d, tt.opts...); got != tt.want {
+			t.Errorf("FormatDuration(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestFormatRelative(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		t    time.Time
+		want string
+	}{
+		{now, "just now"},
+		{now.Add(-3 * time.Minute), "3 minutes ago"},
+		{now.Add(-1 * time.Hour), "1 hour ago"},
+		{now.Add(2 * Day), "in 2 days"},
+		{now.Add(-400 * Day), "1 year ago"},
+	}
+	for _, tt := range tests {
+		if got := FormatRelative(tt.t, now); got != tt.want {
+			t.Errorf("FormatRelative(%v) = %q, want %q", tt.t, got, tt.want)
+		}
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		input string
+		want  time.Duration
+	}{
+		{"0", 0},
+		{"90s", 90 * time.Second},
+		{"1d12h", 36 * time.Hour},
+		{"2w", 14 * Day},
+		{"1h 30m", 90 * time.Minute},
+		{"1.5h", 90 * time.Minute},
+		{"-1d", -Day},
+		{"500ms", 500 * time.Millisecond},
+		{"10us", 10 * time.Microsecond},
+	}
+	for _, tt := range tests {
+		got, err := ParseDuration(tt.input)
+		if err != nil {
+			t.Errorf("ParseDuration(%q) error = %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseDuration(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+
+	for _, input := range []string{"", "-", "+", "10", "5x", "d", "1.2.3s", "999999999w"} {
+		if _, err := ParseDuration(input); !errors.Is(err, ErrInvalidDuration) {
+			t.Errorf("ParseDuration(%q) error = %v, want ErrInvalidDuration", input, err)
+		}
+	}
+}
+
+func TestDurationVar(t *testing.T) {
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	var timeout time.Duration
+	DurationVar(flags, &timeout, "timeout", 5*time.Second, "timeout")
+
+	if err := flags.Parse([]string{"--timeout", "1d12h"}); err != nil {
+		t.Fatalf("Parse error = %v", err)
+	}
+	if timeout != 36*time.Hour {
+		t.Errorf("timeout = %v, want 36h", timeout)
+	}
+}
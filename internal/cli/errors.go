@@ -0,0 +1,462 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"go.yaml.in/yaml/v3"
+
+	"github.com/nate3d/go-toolbox/internal/config"
+	"github.com/nate3d/go-toolbox/internal/theme"
+)
+
+// ErrorKind classifies failures so scripts can react to them. Each kind maps
+// to a stable process exit code.
+type ErrorKind string
+
+const (
+	KindGeneral       ErrorKind = "error"
+	KindUsage         ErrorKind = "usage"
+	KindNotFound      ErrorKind = "not_found"
+	KindPermission    ErrorKind = "permission"
+	KindTimeout       ErrorKind = "timeout"
+	KindNetwork       ErrorKind = "network"
+	KindConfig        ErrorKind = "config"
+	KindInputRequired ErrorKind = "input_required"
+	KindCancelled     ErrorKind = "cancelled"
+)
+
+// Exit codes returned by the toolbox binaries. These values are stable.
+const (
+	ExitOK            = 0
+	ExitGeneral       = 1
+	ExitUsage         = 2
+	ExitNotFound      = 3
+	ExitPermission    = 4
+	ExitTimeout       = 5
+	ExitNetwork       = 6
+	ExitConfig        = 7
+	ExitInputRequired = 8
+	ExitCancelled     = 130
+)
+
+// maxSuggestionDistance is the largest edit distance offered as "did you mean".
+const maxSuggestionDistance = 2
+
+// exitCodes maps each error kind to its exit code.
+var exitCodes = map[ErrorKind]int{
+	KindGeneral:       ExitGeneral,
+	KindUsage:         ExitUsage,
+	KindNotFound:      ExitNotFound,
+	KindPermission:    ExitPermission,
+	KindTimeout:       ExitTimeout,
+	KindNetwork:       ExitNetwork,
+	KindConfig:        ExitConfig,
+	KindInputRequired: ExitInputRequired,
+	KindCancelled:     ExitCancelled,
+}
+
+// cobraUsagePrefixes identify argument and flag errors raised by cobra and pflag.
+var cobraUsagePrefixes = []string{
+	"unknown command",
+	"unknown flag",
+	"unknown shorthand flag",
+	"flag needs an argument",
+	"invalid argument",
+	"required flag",
+	"accepts ",
+	"requires at least",
+	"requires at most",
+	"if any flags in the group",
+	"none of the others can be",
+}
+
+// Error is a failure with a kind, an optional remediation hint and an optional cause.
+type Error struct {
+	Kind    ErrorKind
+	Message string
+	Hint    string
+	Err     error
+}
+
+// ExitStatusError carries the exit code of a child process, such as a
+// plugin, that has already reported its own failure. Execute exits with the
+// code without printing anything.
+type ExitStatusError struct {
+	Code int
+}
+
+// Error implements error.
+func (e *ExitStatusError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// NewError creates an error of the given kind.
+func NewError(kind ErrorKind, format string, args ...interface{}) *Error {
+	return &Error{
+		Kind:    kind,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// WrapError creates an error of the given kind caused by err. The message
+// defaults to err's message when format is empty.
+func WrapError(kind ErrorKind, err error, format string, args ...interface{}) *Error {
+	message := err.Error()
+	if format != "" {
+		message = fmt.Sprintf(format, args...) + ": " + message
+	}
+	return &Error{
+		Kind:    kind,
+		Message: message,
+		Err:     err,
+	}
+}
+
+// UsageErrorf creates a KindUsage error.
+func UsageErrorf(format string, args ...interface{}) *Error {
+	return NewError(KindUsage, format, args...)
+}
+
+// WithHint sets the remediation hint shown below the message.
+func (e *Error) WithHint(format string, args ...interface{}) *Error {
+	e.Hint = fmt.Sprintf(format, args...)
+	return e
+}
+
+// WithSuggestions sets a "did you mean" hint when input is close to one of candidates.
+func (e *Error) WithSuggestions(input string, candidates []string) *Error {
+	if suggestions := Suggest(input, candidates); len(suggestions) > 0 {
+		e.Hint = fmt.Sprintf("Did you mean %s?", quoteList(suggestions))
+	} else if len(candidates) > 0 {
+		e.Hint = "Valid values: " + strings.Join(candidates, ", ")
+	}
+	return e
+}
+
+// Error implements error.
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Unwrap returns the underlying cause.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns the process exit code for the error's kind.
+func (e *Error) ExitCode() int {
+	if code, ok := exitCodes[e.Kind]; ok {
+		return code
+	}
+	return ExitGeneral
+}
+
+// AsError returns err as an *Error, classifying well-known causes such as
+// missing files, permission problems, timeouts and cobra usage errors.
+func AsError(err error) *Error {
+	var typed *Error
+	if errors.As(err, &typed) {
+		return typed
+	}
+
+	kind := KindGeneral
+	var netErr net.Error
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		kind = KindNotFound
+	case errors.Is(err, os.ErrPermission):
+		kind = KindPermission
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, os.ErrDeadlineExceeded):
+		kind = KindTimeout
+	case errors.Is(err, context.Canceled):
+		kind = KindCancelled
+	case errors.As(err, &netErr):
+		kind = KindNetwork
+		if netErr.Timeout() {
+			kind = KindTimeout
+		}
+	case errors.Is(err, ErrInputRequired):
+		kind = KindInputRequired
+	case errors.Is(err, config.ErrInvalidValue):
+		kind = KindConfig
+	case errors.Is(err, ErrInvalidSize), errors.Is(err, ErrInvalidDuration), isCobraUsageError(err):
+		kind = KindUsage
+	}
+
+	return &Error{Kind: kind, Message: err.Error(), Err: err}
+}
+
+// ExitCode returns the process exit code for err, or ExitOK when err is nil.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	var status *ExitStatusError
+	if errors.As(err, &status) {
+		return status.Code
+	}
+	return AsError(err).ExitCode()
+}
+
+// errorReport is the structured form of an error for JSON and YAML output.
+type errorReport struct {
+	Error errorDetail `json:"error" yaml:"error"`
+}
+
+type errorDetail struct {
+	Kind    ErrorKind `json:"kind"           yaml:"kind"`
+	Code    int       `json:"code"           yaml:"code"`
+	Message string    `json:"message"        yaml:"message"`
+	Hint    string    `json:"hint,omitempty" yaml:"hint,omitempty"`
+}
+
+func newErrorDetail(err *Error) errorDetail {
+	return errorDetail{
+		Kind:    err.Kind,
+		Code:    err.ExitCode(),
+		Message: err.Message,
+		Hint:    err.Hint,
+	}
+}
+
+// ReportError writes err to w in the given output format and returns the exit
+// code. Table output is a coloured "Error:" line with an optional "Hint:";
+// JSON and YAML output is an object under an "error" key.
+func ReportError(w io.Writer, err error, format OutputFormat) int {
+	if err == nil {
+		return ExitOK
+	}
+	typed := AsError(err)
+	report := errorReport{Error: newErrorDetail(typed)}
+
+	switch format {
+	case OutputJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		_ = encoder.Encode(report)
+	case OutputYAML:
+		_ = yaml.NewEncoder(w).Encode(report)
+	default:
+		palette := theme.Current()
+		_, _ = palette.Color(theme.Error).Fprintf(w, "Error: %s\n", typed.Message)
+		if typed.Hint != "" {
+			_, _ = palette.Color(theme.Warn).Fprintf(w, "Hint: %s\n", typed.Hint)
+		}
+	}
+	return typed.ExitCode()
+}
+
+// Fatal reports err as text on stderr and exits. It is meant for failures
+// that happen before a command runs, such as configuration errors.
+func Fatal(err error) {
+	os.Exit(ReportError(os.Stderr, err, OutputTable))
+}
+
+// Execute runs root with the process arguments, expanding user-defined
+// aliases first. It reports any error in the format selected by the
+// executed command's --output flag and returns the process exit code.
+func Execute(root *cobra.Command) int {
+	prepareErrorHandling(root)
+	return executeArgs(root, os.Args[1:], nil)
+}
+
+// executeArgs runs args on root after expanding aliases. The steps of a
+// macro run in order and the first failure stops it. prepare, if set, may
+// rewrite each step before it runs.
+func executeArgs(root *cobra.Command, args []string, prepare func([]string) []string) int {
+	cmd, step, err := runSteps(root, args, prepare)
+	if err != nil {
+		return reportExecuteError(os.Stderr, cmd, err, step)
+	}
+	return ExitOK
+}
+
+// runSteps expands aliases in args and executes the steps on root, stopping
+// at the first failure. It returns the failed command, which is nil when
+// alias expansion fails, and the arguments of the failed step.
+func runSteps(root *cobra.Command, args []string, prepare func([]string) []string) (*cobra.Command, []string, error) {
+	steps, err := expandAliases(root, args)
+	if err != nil {
+		return nil, args, err
+	}
+
+	for i, step := range steps {
+		if i > 0 {
+			resetFlags(root)
+		}
+		if prepare != nil {
+			step = prepare(step)
+		}
+		root.SetArgs(step)
+		if cmd, err := root.ExecuteC(); err != nil {
+			return cmd, step, err
+		}
+	}
+	return nil, nil, nil
+}
+
+// reportExecuteError reports a failed command in the output format it asked for.
+func reportExecuteError(w io.Writer, cmd *cobra.Command, err error, args []string) int {
+	var status *ExitStatusError
+	if errors.As(err, &status) {
+		return status.Code
+	}
+
+	format := outputFromArgs(args)
+	if cmd != nil {
+		if flag := cmd.Flag("output"); flag != nil && flag.Changed {
+			format = OutputFormat(flag.Value.String())
+		}
+	}
+
+	typed := AsError(err)
+	if name, ok := unknownCommandName(typed.Message); ok && cmd != nil && typed.Hint == "" {
+		typed = UsageErrorf("unknown command %q for %q", name, cmd.CommandPath()).
+			WithSuggestions(name, commandNames(cmd))
+	}
+	if typed.Kind == KindUsage && typed.Hint == "" && cmd != nil {
+		typed.Hint = fmt.Sprintf("Run '%s --help' for usage.", cmd.CommandPath())
+	}
+	return ReportError(w, typed, format)
+}
+
+// prepareErrorHandling makes cobra return typed usage errors instead of
+// printing its own messages.
+func prepareErrorHandling(root *cobra.Command) {
+	root.SilenceErrors = true
+	root.SilenceUsage = true
+
+	root.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return WrapError(KindUsage, err, "").
+			WithHint("Run '%s --help' for usage.", cmd.CommandPath())
+	})
+
+	// A root with subcommands rejects unknown ones with suggestions. Cobra
+	// only checks the arguments of runnable commands; other roots keep its
+	// own check, whose error reportExecuteError rewrites
+	if root.Args == nil && root.HasSubCommands() && root.Runnable() {
+		root.Args = func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return nil
+			}
+			return UsageErrorf("unknown command %q for %q", args[0], cmd.CommandPath()).
+				WithSuggestions(args[0], commandNames(cmd))
+		}
+	}
+}
+
+// unknownCommandName returns the name in cobra's unknown command error,
+// which it raises for commands without Run.
+func unknownCommandName(message string) (string, bool) {
+	rest, ok := strings.CutPrefix(message, "unknown command ")
+	if !ok {
+		return "", false
+	}
+	quoted, _, _ := strings.Cut(rest, " for ")
+	name, err := strconv.Unquote(quoted)
+	if err != nil {
+		return "", false
+	}
+	return name, true
+}
+
+// outputFromArgs finds --output in raw arguments. It covers errors raised
+// before cobra has parsed the flags of the executed command.
+func outputFromArgs(args []string) OutputFormat {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if value, ok := strings.CutPrefix(arg, "--output="); ok {
+			return OutputFormat(value)
+		}
+		if arg == "--output" && i+1 < len(args) {
+			return OutputFormat(args[i+1])
+		}
+	}
+	return OutputTable
+}
+
+// commandNames lists the available subcommand names and aliases of cmd.
+func commandNames(cmd *cobra.Command) []string {
+	names := make([]string, 0, len(cmd.Commands()))
+	for _, sub := range cmd.Commands() {
+		if !sub.IsAvailableCommand() {
+			continue
+		}
+		names = append(names, sub.Name())
+		names = append(names, sub.Aliases...)
+	}
+	return names
+}
+
+// isCobraUsageError reports whether err is an argument or flag error from cobra.
+func isCobraUsageError(err error) bool {
+	message := err.Error()
+	for _, prefix := range cobraUsagePrefixes {
+		if strings.HasPrefix(message, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Suggest returns the candidates within a small edit distance of input, or
+// that start with it, in candidate order.
+func Suggest(input string, candidates []string) []string {
+	input = strings.ToLower(input)
+	suggestions := make([]string, 0)
+	for _, candidate := range candidates {
+		lower := strings.ToLower(candidate)
+		if lower == input {
+			continue
+		}
+		if levenshtein(input, lower) <= maxSuggestionDistance || (input != "" && strings.HasPrefix(lower, input)) {
+			suggestions = append(suggestions, candidate)
+		}
+	}
+	return suggestions
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// quoteList formats values as 'a', 'b' or 'c'.
+func quoteList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = "'" + value + "'"
+	}
+	if len(quoted) == 1 {
+		return quoted[0]
+	}
+	return strings.Join(quoted[:len(quoted)-1], ", ") + " or " + quoted[len(quoted)-1]
+}
@@ -0,0 +1,159 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nate3d/go-toolbox/internal/config"
+)
+
+func TestAsErrorClassifies(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		kind ErrorKind
+		code int
+	}{
+		{"typed", NewError(KindNetwork, "unreachable"), KindNetwork, ExitNetwork},
+		{"wrapped typed", fmt.Errorf("ping: %w", NewError(KindTimeout, "slow")), KindTimeout, ExitTimeout},
+		{"not found", fmt.Errorf("open: %w", os.ErrNotExist), KindNotFound, ExitNotFound},
+		{"permission", os.ErrPermission, KindPermission, ExitPermission},
+		{"input required", inputRequiredError("Name"), KindInputRequired, ExitInputRequired},
+		{"config", fmt.Errorf("%w: bad", config.ErrInvalidValue), KindConfig, ExitConfig},
+		{"size", fmt.Errorf("%w: x", ErrInvalidSize), KindUsage, ExitUsage},
+		{"cobra args", errors.New("accepts 1 arg(s), received 0"), KindUsage, ExitUsage},
+		{"plain", errors.New("boom"), KindGeneral, ExitGeneral},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := AsError(tt.err)
+			if got.Kind != tt.kind || got.ExitCode() != tt.code {
+				t.Errorf("AsError() = %s/%d, want %s/%d", got.Kind, got.ExitCode(), tt.kind, tt.code)
+			}
+			if ExitCode(tt.err) != tt.code {
+				t.Errorf("ExitCode() = %d, want %d", ExitCode(tt.err), tt.code)
+			}
+		})
+	}
+	if ExitCode(nil) != ExitOK {
+		t.Errorf("ExitCode(nil) = %d, want %d", ExitCode(nil), ExitOK)
+	}
+	if code := ExitCode(fmt.Errorf("plugin: %w", &ExitStatusError{Code: 42})); code != 42 {
+		t.Errorf("ExitCode(ExitStatusError) = %d, want 42", code)
+	}
+}
+
+func TestWrapErrorUnwraps(t *testing.T) {
+	err := WrapError(KindConfig, os.ErrNotExist, "loading %s", "config.yaml")
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Error("errors.Is(err, os.ErrNotExist) = false, want true")
+	}
+	if want := "loading config.yaml: file does not exist"; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	candidates := []string{"reverse", "upper", "lower", "camel", "snake", "kebab"}
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"revrse", []string{"reverse"}},
+		{"lowr", []string{"lower"}},
+		{"ke", []string{"kebab"}},
+		{"xyzzy", []string{}},
+		{"upper", []string{}},
+	}
+	for _, tt := range tests {
+		if got := Suggest(tt.input, candidates); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Suggest(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestReportErrorFormats(t *testing.T) {
+	err := UsageErrorf("unknown operation %q", "revrse").
+		WithSuggestions("revrse", []string{"reverse", "upper"})
+
+	var text bytes.Buffer
+	if code := ReportError(&text, err, OutputTable); code != ExitUsage {
+		t.Errorf("ReportError() code = %d, want %d", code, ExitUsage)
+	}
+	if !strings.Contains(text.String(), "Error: unknown operation") ||
+		!strings.Contains(text.String(), "Hint: Did you mean 'reverse'?") {
+		t.Errorf("text output = %q", text.String())
+	}
+
+	var out bytes.Buffer
+	ReportError(&out, err, OutputJSON)
+	var report errorReport
+	if decodeErr := json.Unmarshal(out.Bytes(), &report); decodeErr != nil {
+		t.Fatalf("invalid JSON %q: %v", out.String(), decodeErr)
+	}
+	want := errorDetail{
+		Kind:    KindUsage,
+		Code:    ExitUsage,
+		Message: `unknown operation "revrse"`,
+		Hint:    "Did you mean 'reverse'?",
+	}
+	if report.Error != want {
+		t.Errorf("JSON report = %+v, want %+v", report.Error, want)
+	}
+}
+
+func TestExecuteUnknownCommand(t *testing.T) {
+	root := &cobra.Command{Use: "toolbox", Run: func(*cobra.Command, []string) {}}
+	root.AddCommand(&cobra.Command{Use: "utils", Run: func(*cobra.Command, []string) {}})
+	root.SetArgs([]string{"utls"})
+
+	prepareErrorHandling(root)
+	_, err := root.ExecuteC()
+	typed := AsError(err)
+	if typed.Kind != KindUsage || typed.Hint != "Did you mean 'utils'?" {
+		t.Errorf("Execute() error = %s %q hint %q", typed.Kind, typed.Message, typed.Hint)
+	}
+}
+
+func TestExecuteUnknownCommandWithoutRun(t *testing.T) {
+	root := &cobra.Command{Use: "toolbox"}
+	root.AddCommand(&cobra.Command{Use: "utils", Run: func(*cobra.Command, []string) {}})
+	prepareErrorHandling(root)
+
+	cmd, step, err := runSteps(root, []string{"utls"}, nil)
+	if err == nil {
+		t.Fatal("unknown command succeeded")
+	}
+	var out bytes.Buffer
+	if code := reportExecuteError(&out, cmd, err, step); code != ExitUsage {
+		t.Errorf("exit code = %d, want %d", code, ExitUsage)
+	}
+	if !strings.Contains(out.String(), `unknown command "utls"`) || !strings.Contains(out.String(), "Did you mean 'utils'?") {
+		t.Errorf("report = %q", out.String())
+	}
+}
+
+func TestOutputFromArgs(t *testing.T) {
+	tests := []struct {
+		args []string
+		want OutputFormat
+	}{
+		{[]string{"utils", "--output", "json"}, OutputJSON},
+		{[]string{"--output=yaml", "file"}, OutputYAML},
+		{[]string{"file", "--", "--output", "json"}, OutputTable},
+		{nil, OutputTable},
+	}
+	for _, tt := range tests {
+		if got := outputFromArgs(tt.args); got != tt.want {
+			t.Errorf("outputFromArgs(%v) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
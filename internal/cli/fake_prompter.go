@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/nate3d/go-toolbox/internal/config"
+)
+
+// FakePrompter is a Prompter for tests. It answers from Answers keyed by
+// AnswerKey(label) and records every label it was asked in Asked.
+type FakePrompter struct {
+	Answers map[string]string
+	Asked   []string
+}
+
+// NewFakePrompter creates a FakePrompter with the given answers.
+func NewFakePrompter(answers map[string]string) *FakePrompter {
+	normalized := make(map[string]string, len(answers))
+	for key, value := range answers {
+		normalized[AnswerKey(key)] = value
+	}
+	return &FakePrompter{Answers: normalized}
+}
+
+// String returns the answer for label, falling back to defaultValue.
+func (p *FakePrompter) String(label string, defaultValue string) (string, error) {
+	answer, ok := p.answer(label)
+	if !ok {
+		if defaultValue != "" {
+			return defaultValue, nil
+		}
+		return "", inputRequiredError(label)
+	}
+	return answer, nil
+}
+
+// Password returns the answer for label.
+func (p *FakePrompter) Password(label string) (string, error) {
+	answer, ok := p.answer(label)
+	if !ok {
+		return "", inputRequiredError(label)
+	}
+	return answer, nil
+}
+
+// Confirm returns the answer for label parsed as yes/no.
+func (p *FakePrompter) Confirm(label string) (bool, error) {
+	answer, ok := p.answer(label)
+	if !ok {
+		return false, inputRequiredError(label)
+	}
+	return parseYesNo(answer)
+}
+
+// Select returns the item whose text or index matches the answer for label.
+func (p *FakePrompter) Select(label string, items []string) (int, string, error) {
+	answer, ok := p.answer(label)
+	if !ok {
+		return -1, "", inputRequiredError(label)
+	}
+	return selectAnswer(label, answer, items)
+}
+
+// MultiSelect returns the items in the comma-separated answer, or defaults.
+func (p *FakePrompter) MultiSelect(label string, items []string, defaults []string) ([]string, error) {
+	answer, ok := p.answer(label)
+	if !ok {
+		return defaults, nil
+	}
+	return multiSelectAnswer(label, answer, items)
+}
+
+// FuzzySelect behaves like Select.
+func (p *FakePrompter) FuzzySelect(label string, items []string) (int, string, error) {
+	return p.Select(label, items)
+}
+
+// Int returns the answer for label, or defaultValue, after the range check.
+func (p *FakePrompter) Int(label string, defaultValue, minValue, maxValue int) (int, error) {
+	answer, ok := p.answer(label)
+	if !ok {
+		answer = strconv.Itoa(defaultValue)
+	}
+	return intAnswer(label, answer, minValue, maxValue)
+}
+
+// Path returns the answer for label, or defaultValue, after checking rules.
+func (p *FakePrompter) Path(label string, defaultValue string, rules ...config.Rule) (string, error) {
+	answer, ok := p.answer(label)
+	if !ok {
+		answer = defaultValue
+	}
+	return checkedAnswer(label, answer, rules)
+}
+
+// Editor returns the answer for label, or initial, after checking rules.
+func (p *FakePrompter) Editor(label string, initial string, rules ...config.Rule) (string, error) {
+	answer, ok := p.answer(label)
+	if !ok {
+		answer = initial
+	}
+	return checkedAnswer(label, answer, rules)
+}
+
+// DateTime parses the answer for label, or returns defaultValue.
+func (p *FakePrompter) DateTime(label string, defaultValue time.Time) (time.Time, error) {
+	answer, ok := p.answer(label)
+	if !ok {
+		return defaultValue, nil
+	}
+	return dateTimeAnswer(label, answer)
+}
+
+func (p *FakePrompter) answer(label string) (string, bool) {
+	p.Asked = append(p.Asked, label)
+	answer, ok := p.Answers[AnswerKey(label)]
+	return answer, ok
+}
@@ -0,0 +1,221 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/nate3d/go-toolbox/internal/config"
+)
+
+// DefaultPager is used when neither cli.pager nor $PAGER is set.
+const DefaultPager = "less -FRX"
+
+// PagerCommand returns the pager command line, or nil when paging is
+// disabled. A configured pager wins over $PAGER; "false", "off", "no",
+// "never" and "cat" turn paging off.
+func PagerCommand(configured string, lookupEnv func(string) (string, bool)) []string {
+	value := strings.TrimSpace(configured)
+	if value == "" {
+		if env, ok := lookupEnv("PAGER"); ok {
+			value = strings.TrimSpace(env)
+		}
+	}
+	switch strings.ToLower(value) {
+	case "false", "off", "no", "never", "cat":
+		return nil
+	case "":
+		value = DefaultPager
+	}
+	return strings.Fields(value)
+}
+
+// AddCommand adds subcommands. Their output goes through the pager when it
+// is taller than the terminal, and those with AddWatchFlag honor --watch.
+func (c *BaseCommand) AddCommand(cmds ...*cobra.Command) {
+	for _, cmd := range cmds {
+		c.wrapRun(cmd)
+	}
+	c.Command.AddCommand(cmds...)
+}
+
+// wrapRun makes cmd and its existing subcommands run under runPaged, or
+// runWatch when --watch is set.
+func (c *BaseCommand) wrapRun(cmd *cobra.Command) {
+	for _, child := range cmd.Commands() {
+		c.wrapRun(child)
+	}
+
+	run := cmd.RunE
+	if run == nil && cmd.Run != nil {
+		plain := cmd.Run
+		run = func(cmd *cobra.Command, args []string) error {
+			plain(cmd, args)
+			return nil
+		}
+		cmd.Run = nil
+	}
+	if run == nil {
+		return
+	}
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		fn := func() error { return run(cmd, args) }
+		if c.Watch > 0 {
+			return c.runWatch(cmd, fn)
+		}
+		return c.runPaged(fn)
+	}
+}
+
+// runPaged runs fn with the command's output sent through a pager when
+// stdout is a terminal, paging is enabled and the output is not redirected,
+// e.g. by batch.
+func (c *BaseCommand) runPaged(fn func() error) error {
+	command := PagerCommand(config.GetString("cli.pager"), os.LookupEnv)
+	fd := int(os.Stdout.Fd())
+	if c.NoPager || command == nil || !term.IsTerminal(fd) || c.OutOrStdout() != io.Writer(os.Stdout) {
+		return fn()
+	}
+	width, height, err := term.GetSize(fd)
+	if err != nil {
+		return fn()
+	}
+
+	pager := newPagerWriter(os.Stdout, command, width, height)
+	c.SetOut(pager)
+	defer c.SetOut(nil)
+
+	runErr := fn()
+	if err := pager.Close(); err != nil && runErr == nil {
+		return err
+	}
+	return runErr
+}
+
+// pagerWriter buffers output until it fills the terminal, then starts the
+// pager and streams the rest into it. Output that fits is written as is.
+type pagerWriter struct {
+	out     io.Writer
+	command []string
+	width   int
+	height  int
+
+	buf     bytes.Buffer
+	scanned int
+	rows    int
+
+	cmd      *exec.Cmd
+	stdin    io.WriteCloser
+	startErr error
+}
+
+func newPagerWriter(out io.Writer, command []string, width, height int) *pagerWriter {
+	return &pagerWriter{out: out, command: command, width: width, height: height}
+}
+
+// Write implements io.Writer.
+func (w *pagerWriter) Write(p []byte) (int, error) {
+	switch {
+	case w.stdin != nil:
+		// Once the pager quits the rest of the output is dropped
+		_, _ = w.stdin.Write(p)
+		return len(p), nil
+	case w.startErr != nil:
+		return w.out.Write(p)
+	}
+
+	w.buf.Write(p)
+	w.countRows()
+	if w.rows >= w.height {
+		w.start()
+	}
+	return len(p), nil
+}
+
+// countRows adds the terminal rows taken by newly completed lines, counting
+// wrapped lines and ignoring color escapes.
+func (w *pagerWriter) countRows() {
+	for {
+		pending := w.buf.Bytes()[w.scanned:]
+		end := bytes.IndexByte(pending, '\n')
+		if end < 0 {
+			return
+		}
+		rows := 1
+		if width := lipgloss.Width(string(pending[:end])); w.width > 0 && width > w.width {
+			rows = (width + w.width - 1) / w.width
+		}
+		w.rows += rows
+		w.scanned += end + 1
+	}
+}
+
+// start launches the pager and hands it the buffered output. If the pager
+// cannot be started, output goes straight to the terminal instead.
+func (w *pagerWriter) start() {
+	cmd := exec.Command(w.command[0], w.command[1:]...) // #nosec G204 - the pager is chosen by the user
+	cmd.Stdout = w.out
+	cmd.Stderr = os.Stderr
+	cmd.Env = pagerEnv(os.Environ())
+
+	stdin, err := cmd.StdinPipe()
+	if err == nil {
+		err = cmd.Start()
+	}
+	if err != nil {
+		w.startErr = err
+		_, _ = w.out.Write(w.buf.Bytes())
+		w.buf.Reset()
+		return
+	}
+
+	// Ctrl-C belongs to the pager while it runs
+	signal.Ignore(os.Interrupt)
+	w.cmd = cmd
+	w.stdin = stdin
+	_, _ = stdin.Write(w.buf.Bytes())
+	w.buf.Reset()
+}
+
+// Close flushes output that never filled the screen, or waits for the pager
+// to exit.
+func (w *pagerWriter) Close() error {
+	if w.stdin == nil {
+		_, err := w.out.Write(w.buf.Bytes())
+		w.buf.Reset()
+		return err
+	}
+
+	_ = w.stdin.Close()
+	err := w.cmd.Wait()
+	signal.Reset(os.Interrupt)
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		// The pager reports its own problems; quitting early is not a failure
+		return nil
+	}
+	return err
+}
+
+// pagerEnv makes less and lv pass color escapes through unless the user
+// configured them otherwise.
+func pagerEnv(env []string) []string {
+	defaults := map[string]string{"LESS": "FRX", "LV": "-c"}
+	for _, entry := range env {
+		name, _, _ := strings.Cut(entry, "=")
+		delete(defaults, name)
+	}
+	for name, value := range defaults {
+		env = append(env, name+"="+value)
+	}
+	return env
+}
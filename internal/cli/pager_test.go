@@ -0,0 +1,125 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestPagerCommand(t *testing.T) {
+	tests := []struct {
+		name       string
+		configured string
+		env        map[string]string
+		want       []string
+	}{
+		{"default", "", nil, []string{"less", "-FRX"}},
+		{"from PAGER", "", map[string]string{"PAGER": "more -d"}, []string{"more", "-d"}},
+		{"empty PAGER", "", map[string]string{"PAGER": ""}, []string{"less", "-FRX"}},
+		{"config wins", "most", map[string]string{"PAGER": "more"}, []string{"most"}},
+		{"config disables", "false", map[string]string{"PAGER": "more"}, nil},
+		{"PAGER cat disables", "", map[string]string{"PAGER": "cat"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lookup := func(key string) (string, bool) {
+				value, ok := tt.env[key]
+				return value, ok
+			}
+			if got := PagerCommand(tt.configured, lookup); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PagerCommand() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPagerWriterShortOutput(t *testing.T) {
+	var out bytes.Buffer
+	w := newPagerWriter(&out, []string{"false"}, 80, 5)
+
+	_, _ = w.Write([]byte("\x1b[36mone\x1b[0m\ntwo\n"))
+	if out.Len() != 0 {
+		t.Fatalf("output written before Close: %q", out.String())
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if got, want := out.String(), "\x1b[36mone\x1b[0m\ntwo\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestPagerWriterLongOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "paged")
+	var out bytes.Buffer
+	w := newPagerWriter(&out, []string{"sh", "-c", "cat > " + path}, 80, 3)
+
+	for _, line := range []string{"1\n", "2\n", "3\n", "4\n"} {
+		_, _ = w.Write([]byte(line))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	paged, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(paged), "1\n2\n3\n4\n"; got != want {
+		t.Errorf("paged = %q, want %q", got, want)
+	}
+	if out.Len() != 0 {
+		t.Errorf("output bypassed the pager: %q", out.String())
+	}
+}
+
+func TestPagerWriterCountsWrappedLines(t *testing.T) {
+	w := newPagerWriter(&bytes.Buffer{}, []string{"false"}, 10, 100)
+	_, _ = w.Write([]byte(strings.Repeat("x", 25) + "\nshort"))
+	if w.rows != 3 {
+		t.Errorf("rows = %d, want 3", w.rows)
+	}
+	_, _ = w.Write([]byte("\n"))
+	if w.rows != 4 {
+		t.Errorf("rows = %d, want 4", w.rows)
+	}
+}
+
+func TestAddCommandKeepsRun(t *testing.T) {
+	base := NewBaseCommand("base", "")
+	ran := false
+	child := &cobra.Command{Use: "child", Run: func(*cobra.Command, []string) { ran = true }}
+	base.AddCommand(child)
+
+	if child.Run != nil || child.RunE == nil {
+		t.Fatal("Run was not wrapped")
+	}
+	base.SetArgs([]string{"child"})
+	if err := base.Execute(); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if !ran {
+		t.Error("wrapped Run did not run")
+	}
+}
+
+func TestPagerEnv(t *testing.T) {
+	env := pagerEnv([]string{"LESS=R", "HOME=/root"})
+	var lv string
+	for _, entry := range env {
+		if entry == "LESS=FRX" {
+			t.Error("existing LESS was overridden")
+		}
+		if strings.HasPrefix(entry, "LV=") {
+			lv = entry
+		}
+	}
+	if lv != "LV=-c" {
+		t.Errorf("LV = %q, want LV=-c", lv)
+	}
+}
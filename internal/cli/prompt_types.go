@@ -0,0 +1,308 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/chzyer/readline"
+	"github.com/manifoldco/promptui"
+
+	"github.com/nate3d/go-toolbox/internal/config"
+	"github.com/nate3d/go-toolbox/internal/theme"
+)
+
+const (
+	// fuzzySelectSize is the number of visible rows in fuzzy-search selects.
+	fuzzySelectSize = 10
+
+	// multiSelectDone is the entry that finishes a multi-select.
+	multiSelectDone = "Done"
+
+	// dateTimePromptLayout is used to show default date/time values.
+	dateTimePromptLayout = "2006-01-02 15:04"
+)
+
+// MultiSelect prompts for any number of items using checkboxes. Selecting an
+// item toggles it; selecting "Done" returns the checked items in list order.
+func (p *Prompt) MultiSelect(label string, items []string, defaults []string) ([]string, error) {
+	checked := make(map[string]bool, len(defaults))
+	for _, item := range defaults {
+		checked[item] = true
+	}
+
+	cursor := 0
+	for {
+		rows := make([]string, 0, len(items)+1)
+		for _, item := range items {
+			box := "[ ]"
+			if checked[item] {
+				box = "[x]"
+			}
+			rows = append(rows, box+" "+item)
+		}
+		rows = append(rows, multiSelectDone)
+
+		prompt := promptui.Select{
+			Label:        label,
+			Items:        rows,
+			Size:         len(rows),
+			CursorPos:    cursor,
+			HideSelected: true,
+		}
+		index, _, err := prompt.Run()
+		if err != nil {
+			return nil, err
+		}
+		if index == len(items) {
+			break
+		}
+		checked[items[index]] = !checked[items[index]]
+		cursor = index
+	}
+
+	selected := make([]string, 0, len(checked))
+	for _, item := range items {
+		if checked[item] {
+			selected = append(selected, item)
+		}
+	}
+	return selected, nil
+}
+
+// FuzzySelect prompts for selection from a long list, filtering as the user types.
+func (p *Prompt) FuzzySelect(label string, items []string) (int, string, error) {
+	prompt := promptui.Select{
+		Label: label,
+		Items: items,
+		Size:  fuzzySelectSize,
+		Searcher: func(input string, index int) bool {
+			return fuzzyMatch(input, items[index])
+		},
+		StartInSearchMode: true,
+	}
+
+	index, result, err := prompt.Run()
+	if err != nil {
+		return -1, "", err
+	}
+	return index, result, nil
+}
+
+// Int prompts for a whole number between minValue and maxValue inclusive.
+func (p *Prompt) Int(label string, defaultValue, minValue, maxValue int) (int, error) {
+	prompt := promptui.Prompt{
+		Label:    fmt.Sprintf("%s (%d-%d)", label, minValue, maxValue),
+		Default:  strconv.Itoa(defaultValue),
+		Validate: promptui.ValidateFunc(config.IntRange(minValue, maxValue)),
+	}
+
+	result, err := prompt.Run()
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(result))
+}
+
+// Path prompts for a file path with tab completion, re-prompting until the
+// value passes rules.
+func (p *Prompt) Path(label string, defaultValue string, rules ...config.Rule) (string, error) {
+	promptText := label + ": "
+	if defaultValue != "" {
+		promptText = fmt.Sprintf("%s [%s]: ", label, defaultValue)
+	}
+
+	rl, err := readline.NewEx(&readline.Config{
+		Prompt:       promptText,
+		AutoComplete: pathCompleter{},
+	})
+	if err != nil {
+		return "", err
+	}
+	defer rl.Close()
+
+	for {
+		line, readErr := rl.Readline()
+		if readErr != nil {
+			return "", readErr
+		}
+		value := strings.TrimSpace(line)
+		if value == "" {
+			value = defaultValue
+		}
+		value = expandHome(value)
+		if ruleErr := config.All(rules...)(value); ruleErr != nil {
+			_, _ = theme.Current().Color(theme.Error).Fprintf(os.Stderr, "%v\n", ruleErr)
+			continue
+		}
+		return value, nil
+	}
+}
+
+// Editor opens $VISUAL or $EDITOR on initial and returns the edited text once
+// it passes rules.
+func (p *Prompt) Editor(label string, initial string, rules ...config.Rule) (string, error) {
+	_, _ = theme.Current().Color(theme.Info).Fprintf(os.Stderr, "%s (opening %s)\n", label, editorCommand())
+
+	result, err := editText(initial)
+	if err != nil {
+		return "", err
+	}
+	if ruleErr := config.All(rules...)(result); ruleErr != nil {
+		return "", ruleErr
+	}
+	return result, nil
+}
+
+// DateTime prompts for a date and optional time in one of config.DateTimeLayouts.
+func (p *Prompt) DateTime(label string, defaultValue time.Time) (time.Time, error) {
+	prompt := promptui.Prompt{
+		Label:    label + " (YYYY-MM-DD HH:MM)",
+		Validate: promptui.ValidateFunc(config.DateTime()),
+	}
+	if !defaultValue.IsZero() {
+		prompt.Default = defaultValue.Format(dateTimePromptLayout)
+	}
+
+	result, err := prompt.Run()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return config.ParseDateTime(result)
+}
+
+// fuzzyMatch reports whether the characters of pattern appear in candidate in
+// order, ignoring case and spaces. "gtb" matches "go-toolbox".
+func fuzzyMatch(pattern, candidate string) bool {
+	candidateRunes := []rune(strings.ToLower(candidate))
+	pos := 0
+	for _, r := range strings.ToLower(pattern) {
+		if unicode.IsSpace(r) {
+			continue
+		}
+		found := false
+		for pos < len(candidateRunes) {
+			pos++
+			if candidateRunes[pos-1] == r {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// pathCompleter completes file and directory names for readline.
+type pathCompleter struct{}
+
+// Do implements readline.AutoCompleter.
+func (pathCompleter) Do(line []rune, pos int) ([][]rune, int) {
+	input := expandHome(string(line[:pos]))
+	dir, prefix := filepath.Split(input)
+
+	searchDir := dir
+	if searchDir == "" {
+		searchDir = "."
+	}
+	entries, err := os.ReadDir(searchDir)
+	if err != nil {
+		return nil, 0
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		// Hide dotfiles unless the user started typing one
+		if strings.HasPrefix(name, ".") && !strings.HasPrefix(prefix, ".") {
+			continue
+		}
+		if entry.IsDir() {
+			name += string(filepath.Separator)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	candidates := make([][]rune, 0, len(names))
+	for _, name := range names {
+		candidates = append(candidates, []rune(name[len(prefix):]))
+	}
+	return candidates, len([]rune(prefix))
+}
+
+// expandHome replaces a leading "~/" with the user's home directory.
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
+
+// editorCommand returns the user's preferred editor command line.
+func editorCommand() string {
+	for _, key := range []string{"VISUAL", "EDITOR"} {
+		if editor := strings.TrimSpace(os.Getenv(key)); editor != "" {
+			return editor
+		}
+	}
+	if runtime.GOOS == "windows" {
+		return "notepad"
+	}
+	return "vi"
+}
+
+// editText writes initial to a temporary file, opens it in the user's editor
+// and returns the saved contents.
+func editText(initial string) (string, error) {
+	file, err := os.CreateTemp("", "toolbox-edit-*.txt")
+	if err != nil {
+		return "", fmt.Errorf("error creating temporary file: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	if _, writeErr := file.WriteString(initial); writeErr != nil {
+		_ = file.Close()
+		return "", fmt.Errorf("error writing temporary file: %w", writeErr)
+	}
+	if closeErr := file.Close(); closeErr != nil {
+		return "", closeErr
+	}
+
+	parts := strings.Fields(editorCommand())
+	if len(parts) == 0 {
+		return "", errors.New("no editor configured")
+	}
+	// #nosec G204 - The editor command comes from the user's own environment
+	cmd := exec.Command(parts[0], append(parts[1:], file.Name())...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if runErr := cmd.Run(); runErr != nil {
+		return "", fmt.Errorf("editor %s failed: %w", parts[0], runErr)
+	}
+
+	// #nosec G304 - Reading back the temporary file created above
+	content, err := os.ReadFile(file.Name())
+	if err != nil {
+		return "", fmt.Errorf("error reading edited file: %w", err)
+	}
+	return string(content), nil
+}
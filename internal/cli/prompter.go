@@ -0,0 +1,364 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.yaml.in/yaml/v3"
+	"golang.org/x/term"
+
+	"github.com/nate3d/go-toolbox/internal/config"
+)
+
+// answerEnvPrefix is the environment variable prefix used for scripted answers.
+// A prompt labelled "Length" is answered by TOOLBOX_ANSWER_LENGTH.
+const answerEnvPrefix = "TOOLBOX_ANSWER_"
+
+// ErrInputRequired is returned when a prompt needs an answer but input is unavailable.
+var ErrInputRequired = errors.New("input required but not available")
+
+// Prompter asks the user for input. Commands should prompt through a Prompter
+// instead of calling promptui directly so they can run headless and be tested.
+type Prompter interface {
+	String(label string, defaultValue string) (string, error)
+	Password(label string) (string, error)
+	Confirm(label string) (bool, error)
+	Select(label string, items []string) (int, string, error)
+	MultiSelect(label string, items []string, defaults []string) ([]string, error)
+	FuzzySelect(label string, items []string) (int, string, error)
+	Int(label string, defaultValue, minValue, maxValue int) (int, error)
+	Path(label string, defaultValue string, rules ...config.Rule) (string, error)
+	Editor(label string, initial string, rules ...config.Rule) (string, error)
+	DateTime(label string, defaultValue time.Time) (time.Time, error)
+}
+
+// Compile-time checks that all prompters satisfy the interface.
+var (
+	_ Prompter = (*Prompt)(nil)
+	_ Prompter = (*ScriptedPrompter)(nil)
+	_ Prompter = (*NonInteractivePrompter)(nil)
+	_ Prompter = (*FakePrompter)(nil)
+)
+
+// ScriptedPrompter answers prompts from a fixed set of answers keyed by label.
+// Prompts without a scripted answer are delegated to the fallback prompter.
+type ScriptedPrompter struct {
+	answers  map[string]string
+	fallback Prompter
+}
+
+// NewScriptedPrompter creates a prompter that answers from the given map.
+// Keys are normalized with AnswerKey, so "Length" and "length" are equivalent.
+func NewScriptedPrompter(answers map[string]string, fallback Prompter) *ScriptedPrompter {
+	normalized := make(map[string]string, len(answers))
+	for key, value := range answers {
+		normalized[AnswerKey(key)] = value
+	}
+	return &ScriptedPrompter{
+		answers:  normalized,
+		fallback: fallback,
+	}
+}
+
+// String returns the scripted answer for label.
+func (p *ScriptedPrompter) String(label string, defaultValue string) (string, error) {
+	if answer, ok := p.lookup(label); ok {
+		return answer, nil
+	}
+	return p.fallback.String(label, defaultValue)
+}
+
+// Password returns the scripted answer for label.
+func (p *ScriptedPrompter) Password(label string) (string, error) {
+	if answer, ok := p.lookup(label); ok {
+		return answer, nil
+	}
+	return p.fallback.Password(label)
+}
+
+// Confirm returns the scripted answer for label parsed as a yes/no value.
+func (p *ScriptedPrompter) Confirm(label string) (bool, error) {
+	if answer, ok := p.lookup(label); ok {
+		return parseYesNo(answer)
+	}
+	return p.fallback.Confirm(label)
+}
+
+// Select returns the item matching the scripted answer for label.
+// The answer may be the item text or its zero-based index.
+func (p *ScriptedPrompter) Select(label string, items []string) (int, string, error) {
+	answer, ok := p.lookup(label)
+	if !ok {
+		return p.fallback.Select(label, items)
+	}
+	return selectAnswer(label, answer, items)
+}
+
+// MultiSelect returns the items listed in the comma-separated scripted answer.
+func (p *ScriptedPrompter) MultiSelect(label string, items []string, defaults []string) ([]string, error) {
+	answer, ok := p.lookup(label)
+	if !ok {
+		return p.fallback.MultiSelect(label, items, defaults)
+	}
+	return multiSelectAnswer(label, answer, items)
+}
+
+// FuzzySelect behaves like Select.
+func (p *ScriptedPrompter) FuzzySelect(label string, items []string) (int, string, error) {
+	answer, ok := p.lookup(label)
+	if !ok {
+		return p.fallback.FuzzySelect(label, items)
+	}
+	return selectAnswer(label, answer, items)
+}
+
+// Int returns the scripted answer once it passes the range check.
+func (p *ScriptedPrompter) Int(label string, defaultValue, minValue, maxValue int) (int, error) {
+	answer, ok := p.lookup(label)
+	if !ok {
+		return p.fallback.Int(label, defaultValue, minValue, maxValue)
+	}
+	return intAnswer(label, answer, minValue, maxValue)
+}
+
+// Path returns the scripted answer once it passes rules.
+func (p *ScriptedPrompter) Path(label string, defaultValue string, rules ...config.Rule) (string, error) {
+	answer, ok := p.lookup(label)
+	if !ok {
+		return p.fallback.Path(label, defaultValue, rules...)
+	}
+	return checkedAnswer(label, expandHome(answer), rules)
+}
+
+// Editor returns the scripted answer once it passes rules.
+func (p *ScriptedPrompter) Editor(label string, initial string, rules ...config.Rule) (string, error) {
+	answer, ok := p.lookup(label)
+	if !ok {
+		return p.fallback.Editor(label, initial, rules...)
+	}
+	return checkedAnswer(label, answer, rules)
+}
+
+// DateTime parses the scripted answer with config.ParseDateTime.
+func (p *ScriptedPrompter) DateTime(label string, defaultValue time.Time) (time.Time, error) {
+	answer, ok := p.lookup(label)
+	if !ok {
+		return p.fallback.DateTime(label, defaultValue)
+	}
+	return dateTimeAnswer(label, answer)
+}
+
+func (p *ScriptedPrompter) lookup(label string) (string, bool) {
+	answer, ok := p.answers[AnswerKey(label)]
+	return answer, ok
+}
+
+// NonInteractivePrompter never reads from the terminal. Prompts with a default
+// resolve to it, confirmations resolve to AssumeYes when set, and everything
+// else fails with ErrInputRequired.
+type NonInteractivePrompter struct {
+	AssumeYes bool
+}
+
+// NewNonInteractivePrompter creates a prompter for --yes and --no-input modes.
+func NewNonInteractivePrompter(assumeYes bool) *NonInteractivePrompter {
+	return &NonInteractivePrompter{AssumeYes: assumeYes}
+}
+
+// String returns the default value, or an error when there is none.
+func (p *NonInteractivePrompter) String(label string, defaultValue string) (string, error) {
+	if defaultValue != "" {
+		return defaultValue, nil
+	}
+	return "", inputRequiredError(label)
+}
+
+// Password always fails since passwords have no default.
+func (p *NonInteractivePrompter) Password(label string) (string, error) {
+	return "", inputRequiredError(label)
+}
+
+// Confirm returns true when AssumeYes is set, otherwise it fails.
+func (p *NonInteractivePrompter) Confirm(label string) (bool, error) {
+	if p.AssumeYes {
+		return true, nil
+	}
+	return false, fmt.Errorf("%w (pass --yes to confirm)", inputRequiredError(label))
+}
+
+// Select always fails since there is no sensible default choice.
+func (p *NonInteractivePrompter) Select(label string, _ []string) (int, string, error) {
+	return -1, "", inputRequiredError(label)
+}
+
+// MultiSelect returns the default selection.
+func (p *NonInteractivePrompter) MultiSelect(_ string, _ []string, defaults []string) ([]string, error) {
+	return defaults, nil
+}
+
+// FuzzySelect always fails since there is no sensible default choice.
+func (p *NonInteractivePrompter) FuzzySelect(label string, _ []string) (int, string, error) {
+	return -1, "", inputRequiredError(label)
+}
+
+// Int returns the default value once it passes the range check.
+func (p *NonInteractivePrompter) Int(label string, defaultValue, minValue, maxValue int) (int, error) {
+	return intAnswer(label, strconv.Itoa(defaultValue), minValue, maxValue)
+}
+
+// Path returns the default value, or an error when there is none.
+func (p *NonInteractivePrompter) Path(label string, defaultValue string, rules ...config.Rule) (string, error) {
+	if defaultValue == "" {
+		return "", inputRequiredError(label)
+	}
+	return checkedAnswer(label, defaultValue, rules)
+}
+
+// Editor returns the initial text, or an error when there is none.
+func (p *NonInteractivePrompter) Editor(label string, initial string, rules ...config.Rule) (string, error) {
+	if initial == "" {
+		return "", inputRequiredError(label)
+	}
+	return checkedAnswer(label, initial, rules)
+}
+
+// DateTime returns the default value, or an error when there is none.
+func (p *NonInteractivePrompter) DateTime(label string, defaultValue time.Time) (time.Time, error) {
+	if defaultValue.IsZero() {
+		return time.Time{}, inputRequiredError(label)
+	}
+	return defaultValue, nil
+}
+
+// inputRequiredError builds an ErrInputRequired error that tells the user how
+// to supply the missing answer.
+func inputRequiredError(label string) error {
+	return fmt.Errorf("%w: %q (provide it with --answers or %s%s)",
+		ErrInputRequired, label, answerEnvPrefix, strings.ToUpper(AnswerKey(label)))
+}
+
+// selectAnswer resolves an answer to an item by its text or zero-based index.
+func selectAnswer(label, answer string, items []string) (int, string, error) {
+	answer = strings.TrimSpace(answer)
+	for i, item := range items {
+		if item == answer || strconv.Itoa(i) == answer {
+			return i, item, nil
+		}
+	}
+	return -1, "", fmt.Errorf("answer for %q: %w", label, config.OneOf(items...)(answer))
+}
+
+// multiSelectAnswer resolves a comma-separated answer to items.
+func multiSelectAnswer(label, answer string, items []string) ([]string, error) {
+	selected := make([]string, 0)
+	for _, part := range strings.Split(answer, ",") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		_, item, err := selectAnswer(label, part, items)
+		if err != nil {
+			return nil, err
+		}
+		selected = append(selected, item)
+	}
+	return selected, nil
+}
+
+// intAnswer validates answer with config.IntRange and converts it.
+func intAnswer(label, answer string, minValue, maxValue int) (int, error) {
+	if err := config.IntRange(minValue, maxValue)(answer); err != nil {
+		return 0, fmt.Errorf("answer for %q: %w", label, err)
+	}
+	return strconv.Atoi(strings.TrimSpace(answer))
+}
+
+// checkedAnswer returns answer once it passes rules.
+func checkedAnswer(label, answer string, rules []config.Rule) (string, error) {
+	if err := config.All(rules...)(answer); err != nil {
+		return "", fmt.Errorf("answer for %q: %w", label, err)
+	}
+	return answer, nil
+}
+
+// dateTimeAnswer parses answer with config.ParseDateTime.
+func dateTimeAnswer(label, answer string) (time.Time, error) {
+	t, err := config.ParseDateTime(answer)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("answer for %q: %w", label, err)
+	}
+	return t, nil
+}
+
+// AnswerKey normalizes a prompt label into the key used by answers files and
+// environment variables: lowercase, with runs of other characters collapsed to "_".
+func AnswerKey(label string) string {
+	var b strings.Builder
+	pendingSep := false
+	for _, r := range strings.ToLower(label) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if pendingSep && b.Len() > 0 {
+				b.WriteByte('_')
+			}
+			b.WriteRune(r)
+			pendingSep = false
+			continue
+		}
+		pendingSep = true
+	}
+	return b.String()
+}
+
+// LoadAnswers reads scripted answers from a YAML or JSON file of key/value pairs.
+func LoadAnswers(path string) (map[string]string, error) {
+	// #nosec G304 - The answers file path is provided by the user
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading answers file: %w", err)
+	}
+
+	raw := make(map[string]interface{})
+	if unmarshalErr := yaml.Unmarshal(content, &raw); unmarshalErr != nil {
+		return nil, fmt.Errorf("error parsing answers file: %w", unmarshalErr)
+	}
+
+	answers := make(map[string]string, len(raw))
+	for key, value := range raw {
+		answers[key] = fmt.Sprint(value)
+	}
+	return answers, nil
+}
+
+// AnswersFromEnv collects scripted answers from TOOLBOX_ANSWER_* variables.
+func AnswersFromEnv() map[string]string {
+	answers := make(map[string]string)
+	for _, entry := range os.Environ() {
+		key, value, ok := strings.Cut(entry, "=")
+		if !ok || !strings.HasPrefix(key, answerEnvPrefix) {
+			continue
+		}
+		answers[strings.TrimPrefix(key, answerEnvPrefix)] = value
+	}
+	return answers
+}
+
+// parseYesNo interprets common yes/no spellings.
+func parseYesNo(answer string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes", "true", "1":
+		return true, nil
+	case "n", "no", "false", "0", "":
+		return false, nil
+	default:
+		return false, fmt.Errorf("invalid yes/no answer: %q", answer)
+	}
+}
+
+// stdinIsTerminal reports whether prompts can be shown to a user.
+func stdinIsTerminal() bool {
+	return term.IsTerminal(int(os.Stdin.Fd())) // #nosec G115 - file descriptors fit in int
+}
@@ -0,0 +1,180 @@
+package cli
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nate3d/go-toolbox/internal/config"
+)
+
+func TestAnswerKey(t *testing.T) {
+	tests := []struct {
+		label string
+		want  string
+	}{
+		{"Length", "length"},
+		{"Enter length (default: 16)", "enter_length_default_16"},
+		{"  Tool name?  ", "tool_name"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := AnswerKey(tt.label); got != tt.want {
+			t.Errorf("AnswerKey(%q) = %q, want %q", tt.label, got, tt.want)
+		}
+	}
+}
+
+func TestScriptedPrompter(t *testing.T) {
+	p := NewScriptedPrompter(map[string]string{
+		"LENGTH":    "32",
+		"overwrite": "yes",
+		"color":     "1",
+	}, NewNonInteractivePrompter(false))
+
+	if got, err := p.String("Length", "16"); err != nil || got != "32" {
+		t.Errorf("String() = %q, %v, want %q", got, err, "32")
+	}
+	if got, err := p.String("Missing", "fallback"); err != nil || got != "fallback" {
+		t.Errorf("String() fallback = %q, %v, want %q", got, err, "fallback")
+	}
+	if got, err := p.Confirm("Overwrite?"); err != nil || !got {
+		t.Errorf("Confirm() = %v, %v, want true", got, err)
+	}
+	if i, item, err := p.Select("Color", []string{"red", "green"}); err != nil || i != 1 || item != "green" {
+		t.Errorf("Select() = %d, %q, %v, want 1, %q", i, item, err, "green")
+	}
+	if _, err := p.Password("Secret"); !errors.Is(err, ErrInputRequired) {
+		t.Errorf("Password() error = %v, want ErrInputRequired", err)
+	}
+}
+
+func TestNonInteractivePrompter(t *testing.T) {
+	if _, err := NewNonInteractivePrompter(false).Confirm("Delete?"); !errors.Is(err, ErrInputRequired) {
+		t.Errorf("Confirm() without --yes error = %v, want ErrInputRequired", err)
+	}
+	if got, err := NewNonInteractivePrompter(true).Confirm("Delete?"); err != nil || !got {
+		t.Errorf("Confirm() with --yes = %v, %v, want true", got, err)
+	}
+	if _, err := NewNonInteractivePrompter(true).String("Name", ""); !errors.Is(err, ErrInputRequired) {
+		t.Errorf("String() without default error = %v, want ErrInputRequired", err)
+	}
+}
+
+func TestLoadAnswersAndEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "answers.yaml")
+	if err := os.WriteFile(path, []byte("length: 24\nconfirm: true\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TOOLBOX_ANSWER_NAME", "toolbox")
+
+	base := NewBaseCommand("test", "test command")
+	base.NoInput = true
+	base.AnswersFile = path
+
+	p, err := base.Prompter()
+	if err != nil {
+		t.Fatalf("Prompter() error = %v", err)
+	}
+	if got, _ := p.String("Length", "16"); got != "24" {
+		t.Errorf("String(Length) = %q, want %q", got, "24")
+	}
+	if got, _ := p.String("Name", ""); got != "toolbox" {
+		t.Errorf("String(Name) = %q, want %q", got, "toolbox")
+	}
+	if got, _ := p.Confirm("Confirm"); !got {
+		t.Error("Confirm(Confirm) = false, want true")
+	}
+}
+
+func TestFakePrompterRecordsLabels(t *testing.T) {
+	fake := NewFakePrompter(map[string]string{"Length": "8"})
+	base := NewBaseCommand("test", "test command")
+	base.SetPrompter(fake)
+
+	p, err := base.Prompter()
+	if err != nil {
+		t.Fatalf("Prompter() error = %v", err)
+	}
+	if got, _ := p.String("Length", "16"); got != "8" {
+		t.Errorf("String(Length) = %q, want %q", got, "8")
+	}
+	if len(fake.Asked) != 1 || fake.Asked[0] != "Length" {
+		t.Errorf("Asked = %v, want [Length]", fake.Asked)
+	}
+}
+
+func TestScriptedPrompterRichTypes(t *testing.T) {
+	p := NewScriptedPrompter(map[string]string{
+		"features": "tests, 0",
+		"workers":  "64",
+		"port":     "8080",
+		"when":     "2025-03-01 09:30",
+	}, NewNonInteractivePrompter(false))
+
+	got, err := p.MultiSelect("Features", []string{"lint", "tests", "docs"}, nil)
+	if err != nil || !reflect.DeepEqual(got, []string{"tests", "lint"}) {
+		t.Errorf("MultiSelect() = %v, %v, want [tests lint]", got, err)
+	}
+	if _, err = p.Int("Workers", 4, 1, 32); !errors.Is(err, config.ErrInvalidValue) {
+		t.Errorf("Int() out of range error = %v, want ErrInvalidValue", err)
+	}
+	if n, intErr := p.Int("Port", 80, 1, 65535); intErr != nil || n != 8080 {
+		t.Errorf("Int() = %d, %v, want 8080", n, intErr)
+	}
+	when, err := p.DateTime("When", time.Time{})
+	if err != nil || when.Hour() != 9 || when.Minute() != 30 {
+		t.Errorf("DateTime() = %v, %v", when, err)
+	}
+	if _, err = p.DateTime("Missing", time.Time{}); !errors.Is(err, ErrInputRequired) {
+		t.Errorf("DateTime() without answer error = %v, want ErrInputRequired", err)
+	}
+}
+
+func TestFuzzyMatch(t *testing.T) {
+	tests := []struct {
+		pattern   string
+		candidate string
+		want      bool
+	}{
+		{"gtb", "go-toolbox", true},
+		{"GTB", "go-toolbox", true},
+		{"", "anything", true},
+		{"btg", "go-toolbox", false},
+		{"go tb", "go-toolbox", true},
+	}
+	for _, tt := range tests {
+		if got := fuzzyMatch(tt.pattern, tt.candidate); got != tt.want {
+			t.Errorf("fuzzyMatch(%q, %q) = %v, want %v", tt.pattern, tt.candidate, got, tt.want)
+		}
+	}
+}
+
+func TestPathCompleter(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"alpha.txt", "alpine.txt", ".hidden"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "alps"), 0750); err != nil {
+		t.Fatal(err)
+	}
+
+	line := []rune(dir + string(filepath.Separator) + "alp")
+	candidates, length := pathCompleter{}.Do(line, len(line))
+	if length != 3 {
+		t.Errorf("length = %d, want 3", length)
+	}
+	got := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		got = append(got, string(c))
+	}
+	want := []string{"ha.txt", "ine.txt", "s" + string(filepath.Separator)}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("candidates = %v, want %v", got, want)
+	}
+}
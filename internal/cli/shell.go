@@ -0,0 +1,381 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/chzyer/readline"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/nate3d/go-toolbox/internal/config"
+	"github.com/nate3d/go-toolbox/internal/theme"
+)
+
+// shellBuiltins are the commands handled by the shell itself
+var shellBuiltins = []string{"exit", "quit", "set", "unset"}
+
+// NewShellCommand creates the "shell" command, an interactive prompt that
+// runs commands of root in a single process.
+func NewShellCommand(root *cobra.Command) *cobra.Command {
+	baseCmd := NewBaseCommand("shell", "Start an interactive shell")
+	baseCmd.Long = `Start an interactive shell that runs toolbox commands without starting a new
+process for each one. It offers line editing, tab completion and history,
+which is kept in the XDG state directory.
+
+Besides toolbox commands and aliases the shell understands:
+  set                 list session settings
+  set <flag> <value>  pass --<flag>=<value> to every command that has the flag
+  set <key> <value>   override a configuration key, e.g. set cli.pager false
+  unset <name>        remove a session setting
+  exit, quit          leave the shell (or press Ctrl-D)`
+	baseCmd.Example = `  toolbox shell
+  echo "utils string upper hello" | toolbox shell`
+	baseCmd.Args = cobra.NoArgs
+	baseCmd.RunE = func(_ *cobra.Command, _ []string) error {
+		return runShell(baseCmd, root)
+	}
+	return baseCmd.Command
+}
+
+func runShell(cmd *BaseCommand, root *cobra.Command) error {
+	rlConfig := &readline.Config{
+		Prompt:            theme.Current().Sprint(theme.Accent, root.Name()+"> "),
+		AutoComplete:      &shellCompleter{root: root},
+		InterruptPrompt:   "^C",
+		EOFPrompt:         "exit",
+		HistorySearchFold: true,
+	}
+	if dir, err := config.GetStateDir(root.Name()); err == nil {
+		rlConfig.HistoryFile = filepath.Join(dir, "history")
+	} else {
+		cmd.PrintVerbosef("History is disabled: %v", err)
+	}
+
+	rl, err := readline.NewEx(rlConfig)
+	if err != nil {
+		return fmt.Errorf("error starting shell: %w", err)
+	}
+	defer func() { _ = rl.Close() }()
+
+	session := newShellSession(cmd, root)
+	for {
+		line, err := rl.Readline()
+		switch {
+		case errors.Is(err, readline.ErrInterrupt):
+			continue
+		case errors.Is(err, io.EOF):
+			return nil
+		case err != nil:
+			return err
+		}
+		if session.handle(line) {
+			return nil
+		}
+	}
+}
+
+// shellSession holds the settings of one shell.
+type shellSession struct {
+	cmd  *BaseCommand
+	root *cobra.Command
+
+	// flags are passed to every command that has them
+	flags map[string]string
+	// config holds overridden configuration keys and their original values
+	config map[string]interface{}
+}
+
+func newShellSession(cmd *BaseCommand, root *cobra.Command) *shellSession {
+	return &shellSession{
+		cmd:    cmd,
+		root:   root,
+		flags:  make(map[string]string),
+		config: make(map[string]interface{}),
+	}
+}
+
+// handle runs one input line and reports whether the shell should exit.
+func (s *shellSession) handle(line string) bool {
+	words, err := SplitArgs(line)
+	if err != nil {
+		ReportError(os.Stderr, WrapError(KindUsage, err, ""), OutputTable)
+		return false
+	}
+	if len(words) == 0 {
+		return false
+	}
+
+	switch words[0] {
+	case "exit", "quit":
+		return true
+	case "set":
+		s.reportError(s.set(words[1:]))
+	case "unset":
+		s.reportError(s.unset(words[1:]))
+	case s.cmd.Name():
+		s.cmd.PrintWarnf("Already in a shell")
+	default:
+		s.run(words)
+	}
+	return false
+}
+
+// run executes a toolbox command line. Ctrl-C cancels the command's
+// context instead of ending the shell.
+func (s *shellSession) run(args []string) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	resetFlags(s.root)
+	s.root.SetContext(ctx)
+	executeArgs(s.root, args, s.apply)
+}
+
+// apply adds the session flags the command supports and does not set itself.
+func (s *shellSession) apply(step []string) []string {
+	target, _, err := s.root.Find(step)
+	if err != nil || len(s.flags) == 0 {
+		return step
+	}
+
+	var extra []string
+	for _, name := range sortedKeys(s.flags) {
+		flag := target.Flag(name)
+		if flag == nil || hasFlag(step[:flagsEnd(step)], flag) {
+			continue
+		}
+		extra = append(extra, "--"+name+"="+s.flags[name])
+	}
+	return insertFlags(step, extra)
+}
+
+// flagsEnd returns the index of the "--" that ends the flags in args, or len(args).
+func flagsEnd(args []string) int {
+	for i, arg := range args {
+		if arg == "--" {
+			return i
+		}
+	}
+	return len(args)
+}
+
+// insertFlags adds flags to args before any "--".
+func insertFlags(args, flags []string) []string {
+	end := flagsEnd(args)
+	return append(append(append([]string{}, args[:end]...), flags...), args[end:]...)
+}
+
+// hasFlag reports whether args set flag.
+func hasFlag(args []string, flag *pflag.Flag) bool {
+	for _, arg := range args {
+		name, _, _ := strings.Cut(arg, "=")
+		if name == "--"+flag.Name || (flag.Shorthand != "" && name == "-"+flag.Shorthand) {
+			return true
+		}
+	}
+	return false
+}
+
+// set changes a session setting or lists them all.
+func (s *shellSession) set(args []string) error {
+	if len(args) == 1 {
+		if key, value, ok := strings.Cut(args[0], "="); ok {
+			args = []string{key, value}
+		}
+	}
+	switch len(args) {
+	case 0:
+		s.list()
+		return nil
+	case 2:
+	default:
+		return UsageErrorf("usage: set <flag|config key> <value>")
+	}
+
+	name, value := args[0], args[1]
+	if strings.Contains(name, ".") {
+		return s.setConfig(name, value)
+	}
+
+	flag := findFlag(s.root, name)
+	if flag == nil {
+		return UsageErrorf("unknown setting %q", name).WithSuggestions(name, flagNames(s.root))
+	}
+	// Check the value with the flag's own parser, then restore the default
+	if err := flag.Value.Set(value); err != nil {
+		return WrapError(KindUsage, err, "invalid value for %s", name)
+	}
+	resetFlag(flag)
+	s.flags[name] = value
+	return nil
+}
+
+// setConfig overrides a configuration key for the rest of the session.
+func (s *shellSession) setConfig(key, value string) error {
+	if !config.IsSet(key) {
+		return NewError(KindNotFound, "unknown configuration key %q", key).WithSuggestions(key, config.Keys())
+	}
+	previous := config.Value(key)
+	config.Set(key, value)
+	if err := config.Validate(); err != nil {
+		config.Set(key, previous)
+		return err
+	}
+	if _, ok := s.config[key]; !ok {
+		s.config[key] = previous
+	}
+	return nil
+}
+
+// unset removes session settings.
+func (s *shellSession) unset(names []string) error {
+	if len(names) == 0 {
+		return UsageErrorf("usage: unset <name>...")
+	}
+	for _, name := range names {
+		if original, ok := s.config[name]; ok {
+			config.Set(name, original)
+			delete(s.config, name)
+			continue
+		}
+		if _, ok := s.flags[name]; !ok {
+			return NewError(KindNotFound, "%q is not set", name)
+		}
+		delete(s.flags, name)
+	}
+	return nil
+}
+
+// list prints the session settings.
+func (s *shellSession) list() {
+	if len(s.flags) == 0 && len(s.config) == 0 {
+		s.cmd.PrintInfof("No session settings")
+		return
+	}
+	for _, name := range sortedKeys(s.flags) {
+		s.cmd.PrintInfof("%s = %s", name, s.flags[name])
+	}
+	for _, key := range sortedKeys(s.config) {
+		s.cmd.PrintInfof("%s = %v", key, config.Value(key))
+	}
+}
+
+func (s *shellSession) reportError(err error) {
+	if err != nil {
+		ReportError(os.Stderr, err, OutputTable)
+	}
+}
+
+// findFlag returns the first flag called name anywhere in the command tree.
+func findFlag(root *cobra.Command, name string) *pflag.Flag {
+	if flag := root.Flags().Lookup(name); flag != nil {
+		return flag
+	}
+	if flag := root.PersistentFlags().Lookup(name); flag != nil {
+		return flag
+	}
+	for _, cmd := range root.Commands() {
+		if flag := findFlag(cmd, name); flag != nil {
+			return flag
+		}
+	}
+	return nil
+}
+
+// flagNames lists the distinct flag names in the command tree, sorted.
+func flagNames(root *cobra.Command) []string {
+	seen := make(map[string]bool)
+	var walk func(cmd *cobra.Command)
+	walk = func(cmd *cobra.Command) {
+		visit := func(flag *pflag.Flag) { seen[flag.Name] = true }
+		cmd.Flags().VisitAll(visit)
+		cmd.PersistentFlags().VisitAll(visit)
+		for _, sub := range cmd.Commands() {
+			walk(sub)
+		}
+	}
+	walk(root)
+	delete(seen, "help")
+	return sortedKeys(seen)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// shellCompleter completes shell input from the cobra command tree.
+type shellCompleter struct {
+	root *cobra.Command
+}
+
+// Do implements readline.AutoCompleter.
+func (c *shellCompleter) Do(line []rune, pos int) ([][]rune, int) {
+	text := string(line[:pos])
+	words, err := SplitArgs(text)
+	if err != nil {
+		return nil, 0
+	}
+	toComplete := ""
+	if len(words) > 0 && !strings.HasSuffix(text, " ") {
+		toComplete = words[len(words)-1]
+		words = words[:len(words)-1]
+	}
+
+	var candidates []string
+	switch {
+	case len(words) == 0:
+		candidates = append(c.complete(words, toComplete), shellBuiltins...)
+	case len(words) == 1 && (words[0] == "set" || words[0] == "unset"):
+		candidates = append(flagNames(c.root), config.Keys()...)
+	default:
+		candidates = c.complete(words, toComplete)
+	}
+
+	var suffixes [][]rune
+	for _, candidate := range candidates {
+		if rest, ok := strings.CutPrefix(candidate, toComplete); ok {
+			suffixes = append(suffixes, []rune(rest+" "))
+		}
+	}
+	return suffixes, len([]rune(toComplete))
+}
+
+// complete asks cobra's hidden completion command for candidates.
+func (c *shellCompleter) complete(args []string, toComplete string) []string {
+	var out bytes.Buffer
+	c.root.SetOut(&out)
+	c.root.SetErr(io.Discard)
+	defer func() {
+		c.root.SetOut(nil)
+		c.root.SetErr(nil)
+		resetFlags(c.root)
+	}()
+
+	c.root.SetArgs(append(append([]string{cobra.ShellCompNoDescRequestCmd}, args...), toComplete))
+	if err := c.root.Execute(); err != nil {
+		return nil
+	}
+
+	var candidates []string
+	for _, line := range strings.Split(out.String(), "\n") {
+		if line == "" || strings.HasPrefix(line, ":") {
+			continue
+		}
+		candidates = append(candidates, line)
+	}
+	return candidates
+}
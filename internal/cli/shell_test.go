@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func newShellTestRoot() (*cobra.Command, *BaseCommand) {
+	root := &cobra.Command{Use: "toolbox"}
+	utils := NewBaseCommand("utils", "")
+	utils.AddCommand(&cobra.Command{Use: "string", RunE: func(*cobra.Command, []string) error { return nil }})
+	utils.AddCommand(&cobra.Command{Use: "random", RunE: func(*cobra.Command, []string) error { return nil }})
+	root.AddCommand(utils.Command, &cobra.Command{Use: "plain", Run: func(*cobra.Command, []string) {}})
+	shell := NewBaseCommand("shell", "")
+	return root, shell
+}
+
+func TestShellSessionSettings(t *testing.T) {
+	root, shell := newShellTestRoot()
+	session := newShellSession(shell, root)
+
+	if err := session.set([]string{"output", "json"}); err != nil {
+		t.Fatalf("set output: %v", err)
+	}
+	if err := session.set([]string{"watch=nope"}); ExitCode(err) != ExitUsage {
+		t.Errorf("set watch=nope error = %v, want usage error", err)
+	}
+	if err := session.set([]string{"outptu", "json"}); ExitCode(err) != ExitUsage {
+		t.Errorf("set unknown error = %v, want usage error", err)
+	}
+
+	tests := []struct {
+		step []string
+		want []string
+	}{
+		{[]string{"utils", "string", "upper"}, []string{"utils", "string", "upper", "--output=json"}},
+		{[]string{"utils", "string", "--output", "yaml"}, []string{"utils", "string", "--output", "yaml"}},
+		{[]string{"utils", "string", "--", "-x"}, []string{"utils", "string", "--output=json", "--", "-x"}},
+		{[]string{"plain"}, []string{"plain"}},
+	}
+	for _, tt := range tests {
+		if got := session.apply(tt.step); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("apply(%q) = %q, want %q", tt.step, got, tt.want)
+		}
+	}
+
+	if err := session.unset([]string{"output"}); err != nil {
+		t.Fatalf("unset output: %v", err)
+	}
+	if got := session.apply([]string{"utils", "string"}); len(got) != 2 {
+		t.Errorf("apply after unset = %q", got)
+	}
+	if err := session.unset([]string{"output"}); ExitCode(err) != ExitNotFound {
+		t.Errorf("unset twice error = %v, want not found", err)
+	}
+}
+
+func TestShellHandle(t *testing.T) {
+	root, shell := newShellTestRoot()
+	ran := false
+	root.AddCommand(&cobra.Command{Use: "mark", Run: func(*cobra.Command, []string) { ran = true }})
+	session := newShellSession(shell, root)
+
+	if session.handle("   ") || session.handle(`broken "quote`) {
+		t.Error("blank or invalid lines ended the shell")
+	}
+	if session.handle("mark") || !ran {
+		t.Error("command was not run")
+	}
+	if !session.handle("exit") {
+		t.Error("exit did not end the shell")
+	}
+}
+
+func TestShellCompleter(t *testing.T) {
+	root, _ := newShellTestRoot()
+	completer := &shellCompleter{root: root}
+
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"ut", []string{"ils "}},
+		{"utils ", []string{"random ", "string "}},
+		{"utils s", []string{"tring "}},
+		{"ex", []string{"it "}},
+	}
+	for _, tt := range tests {
+		suffixes, _ := completer.Do([]rune(tt.line), len([]rune(tt.line)))
+		got := make([]string, 0, len(suffixes))
+		for _, suffix := range suffixes {
+			got = append(got, string(suffix))
+		}
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Do(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,269 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strings"
+
+	"github.com/nate3d/go-toolbox/internal/config"
+)
+
+// SizeBase selects decimal (SI) or binary (IEC) multiples when formatting sizes.
+type SizeBase int
+
+const (
+	// SizeBaseIEC uses powers of 1024: KiB, MiB, GiB.
+	SizeBaseIEC SizeBase = 1024
+	// SizeBaseSI uses powers of 1000: kB, MB, GB.
+	SizeBaseSI SizeBase = 1000
+)
+
+// SizeUnitStyle selects how units are written when formatting sizes.
+type SizeUnitStyle int
+
+const (
+	// SizeUnitShort writes unit symbols: "1.5 MiB", "1.5 MB".
+	SizeUnitShort SizeUnitStyle = iota
+	// SizeUnitLong writes unit names: "1.5 mebibytes", "1.5 megabytes".
+	SizeUnitLong
+	// SizeUnitBits writes the value in bits: "12.0 Mibit", "12.0 Mbit".
+	SizeUnitBits
+)
+
+const (
+	bitsPerByte          = 8
+	defaultSizePrecision = 1
+)
+
+var (
+	// ErrInvalidSize is returned when a size string cannot be parsed.
+	ErrInvalidSize = errors.New("invalid size")
+	// ErrSizeOverflow is returned when a size does not fit in an int64.
+	ErrSizeOverflow = errors.New("size out of range")
+
+	sizePattern = regexp.MustCompile(`^([+-]?(?:\d+\.?\d*|\.\d+)(?:[eE][+-]?\d+)?)\s*([A-Za-z]*)$`)
+
+	// sizePrefixes maps unit prefixes to their exponent. SI and IEC prefixes
+	// share exponents and differ only in base.
+	sizePrefixes = map[string]int{
+		"": 0, "k": 1, "m": 2, "g": 3, "t": 4, "p": 5, "e": 6,
+	}
+
+	siSymbols     = []string{"B", "kB", "MB", "GB", "TB", "PB", "EB"}
+	iecSymbols    = []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+	siBitSymbols  = []string{"bit", "kbit", "Mbit", "Gbit", "Tbit", "Pbit", "Ebit"}
+	iecBitSymbols = []string{"bit", "Kibit", "Mibit", "Gibit", "Tibit", "Pibit", "Eibit"}
+	siNames       = []string{"byte", "kilobyte", "megabyte", "gigabyte", "terabyte", "petabyte", "exabyte"}
+	iecNames      = []string{"byte", "kibibyte", "mebibyte", "gibibyte", "tebibyte", "pebibyte", "exbibyte"}
+)
+
+// Register the size rule for configuration keys holding sizes.
+func init() {
+	config.RegisterRule("file.max_file_size", SizeRule())
+}
+
+// ParseSize parses a size string into bytes. It accepts:
+//
+//   - plain byte counts: "512", "512B", "-1"
+//   - SI multiples of 1000: "10kB", "10MB", "1.5G"
+//   - IEC multiples of 1024: "10KiB", "10Mi", "2GiB"
+//   - bits, written "bit" or as a lowercase "b" after an uppercase prefix:
+//     "100Mb", "1Gbit", "8Kibit"
+//
+// Prefixes are case-insensitive. An all-lowercase unit such as "100mb"
+// means bytes, as it always has. Fractional results are rounded to the
+// nearest byte and values that do not fit in an int64 return
+// ErrSizeOverflow.
+func ParseSize(sizeStr string) (int64, error) {
+	match := sizePattern.FindStringSubmatch(strings.TrimSpace(sizeStr))
+	if match == nil {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidSize, sizeStr)
+	}
+
+	multiplier, isBits, ok := parseSizeUnit(match[2])
+	if !ok {
+		return 0, fmt.Errorf("%w: unknown unit %q in %q", ErrInvalidSize, match[2], sizeStr)
+	}
+
+	value, ok := new(big.Rat).SetString(match[1])
+	if !ok {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidSize, sizeStr)
+	}
+	value.Mul(value, new(big.Rat).SetInt(multiplier))
+	if isBits {
+		value.Quo(value, big.NewRat(bitsPerByte, 1))
+	}
+
+	bytes := roundRat(value)
+	if !bytes.IsInt64() {
+		return 0, fmt.Errorf("%w: %q", ErrSizeOverflow, sizeStr)
+	}
+	return bytes.Int64(), nil
+}
+
+// parseSizeUnit returns the byte multiplier of unit and whether it counts bits.
+func parseSizeUnit(unit string) (*big.Int, bool, bool) {
+	isBits := false
+	lower := strings.ToLower(unit)
+	switch {
+	case lower == "bit" || lower == "bits":
+		return big.NewInt(1), true, true
+	case lower == "byte" || lower == "bytes":
+		return big.NewInt(1), false, true
+	case strings.HasSuffix(lower, "bit"):
+		isBits = true
+		lower = strings.TrimSuffix(lower, "bit")
+	case strings.HasSuffix(lower, "bits"):
+		isBits = true
+		lower = strings.TrimSuffix(lower, "bits")
+	case strings.HasSuffix(unit, "b") && unit != lower:
+		// "Mb" is megabits, but "mb" has long meant megabytes
+		isBits = true
+		lower = strings.TrimSuffix(lower, "b")
+	case strings.HasSuffix(lower, "b"):
+		lower = strings.TrimSuffix(lower, "b")
+	}
+
+	base := int64(SizeBaseSI)
+	if strings.HasSuffix(lower, "i") && lower != "i" {
+		base = int64(SizeBaseIEC)
+		lower = strings.TrimSuffix(lower, "i")
+	}
+
+	exp, ok := sizePrefixes[lower]
+	if !ok {
+		return nil, false, false
+	}
+	return new(big.Int).Exp(big.NewInt(base), big.NewInt(int64(exp)), nil), isBits, true
+}
+
+// roundRat rounds r to the nearest integer, halves away from zero.
+func roundRat(r *big.Rat) *big.Int {
+	quo, rem := new(big.Int).QuoRem(r.Num(), r.Denom(), new(big.Int))
+	if new(big.Int).Mul(new(big.Int).Abs(rem), big.NewInt(2)).Cmp(r.Denom()) >= 0 {
+		if r.Sign() < 0 {
+			quo.Sub(quo, big.NewInt(1))
+		} else {
+			quo.Add(quo, big.NewInt(1))
+		}
+	}
+	return quo
+}
+
+// SizeRule validates configuration values with ParseSize.
+func SizeRule() config.Rule {
+	return func(value string) error {
+		if _, err := ParseSize(value); err != nil {
+			return fmt.Errorf("%w: %w", config.ErrInvalidValue, err)
+		}
+		return nil
+	}
+}
+
+// ConfigSize returns the configuration value at key parsed with ParseSize.
+func ConfigSize(key string) (int64, error) {
+	size, err := ParseSize(config.GetString(key))
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", key, err)
+	}
+	return size, nil
+}
+
+// sizeFormat holds the settings applied by SizeOption values.
+type sizeFormat struct {
+	base      SizeBase
+	precision int
+	style     SizeUnitStyle
+}
+
+// SizeOption customizes FormatSize.
+type SizeOption func(*sizeFormat)
+
+// WithSizeBase selects SI or IEC multiples. The default is SizeBaseIEC.
+func WithSizeBase(base SizeBase) SizeOption {
+	return func(f *sizeFormat) {
+		f.base = base
+	}
+}
+
+// WithSizePrecision sets the number of decimal places. The default is 1.
+func WithSizePrecision(precision int) SizeOption {
+	return func(f *sizeFormat) {
+		f.precision = max(precision, 0)
+	}
+}
+
+// WithSizeUnitStyle selects unit symbols, unit names or bits. The default is SizeUnitShort.
+func WithSizeUnitStyle(style SizeUnitStyle) SizeOption {
+	return func(f *sizeFormat) {
+		f.style = style
+	}
+}
+
+// FormatSize formats bytes into a human-readable string such as "1.5 MiB".
+// Values below one multiple are written exactly: "512 B".
+func FormatSize(bytes int64, opts ...SizeOption) string {
+	format := sizeFormat{
+		base:      SizeBaseIEC,
+		precision: defaultSizePrecision,
+		style:     SizeUnitShort,
+	}
+	for _, opt := range opts {
+		opt(&format)
+	}
+	if format.base != SizeBaseSI {
+		format.base = SizeBaseIEC
+	}
+
+	value := math.Abs(float64(bytes))
+	if format.style == SizeUnitBits {
+		value *= bitsPerByte
+	}
+
+	sign := ""
+	if bytes < 0 {
+		sign = "-"
+	}
+
+	base := float64(format.base)
+	exp := 0
+	for value >= base && exp < len(siSymbols)-1 {
+		value /= base
+		exp++
+	}
+
+	unit := sizeUnitLabel(format, exp, value)
+	if exp == 0 {
+		return fmt.Sprintf("%s%.0f %s", sign, value, unit)
+	}
+	return fmt.Sprintf("%s%.*f %s", sign, format.precision, value, unit)
+}
+
+// sizeUnitLabel returns the unit text for a scaled value.
+func sizeUnitLabel(format sizeFormat, exp int, value float64) string {
+	iec := format.base == SizeBaseIEC
+	switch format.style {
+	case SizeUnitLong:
+		names := siNames
+		if iec {
+			names = iecNames
+		}
+		if value == 1 {
+			return names[exp]
+		}
+		return names[exp] + "s"
+	case SizeUnitBits:
+		if iec {
+			return iecBitSymbols[exp]
+		}
+		return siBitSymbols[exp]
+	default:
+		if iec {
+			return iecSymbols[exp]
+		}
+		return siSymbols[exp]
+	}
+}
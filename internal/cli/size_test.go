@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/nate3d/go-toolbox/internal/config"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int64
+	}{
+		{"0", 0},
+		{"512", 512},
+		{"512B", 512},
+		{"10 bytes", 10},
+		{"10kB", 10_000},
+		{"10KB", 10_000},
+		{"10MB", 10_000_000},
+		{"1.5G", 1_500_000_000},
+		{"10KiB", 10_240},
+		{"10kib", 10_240},
+		{"100mb", 100_000_000},
+		{"16b", 16},
+		{"10Kib", 10 * 1024 / 8},
+		{"1MiB", 1 << 20},
+		{"2Gi", 2 << 30},
+		{"100Mb", 12_500_000},
+		{"1Gbit", 125_000_000},
+		{"8Kibit", 1024},
+		{"16bit", 2},
+		{"-10MB", -10_000_000},
+		{"0.5KiB", 512},
+		{"1e3", 1000},
+		{"7EiB", 7 << 60},
+	}
+	for _, tt := range tests {
+		got, err := ParseSize(tt.input)
+		if err != nil {
+			t.Errorf("ParseSize(%q) error = %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseSize(%q) = %d, want %d", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseSizeErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		want  error
+	}{
+		{"", ErrInvalidSize},
+		{"MB", ErrInvalidSize},
+		{"10XB", ErrInvalidSize},
+		{"ten MB", ErrInvalidSize},
+		{"8EiB", ErrSizeOverflow},
+		{"10000PB", ErrSizeOverflow},
+	}
+	for _, tt := range tests {
+		if _, err := ParseSize(tt.input); !errors.Is(err, tt.want) {
+			t.Errorf("ParseSize(%q) error = %v, want %v", tt.input, err, tt.want)
+		}
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := []struct {
+		bytes int64
+		opts  []SizeOption
+		want  string
+	}{
+		{512, nil, "512 B"},
+		{1536, nil, "1.5 KiB"},
+		{-1536, nil, "-1.5 KiB"},
+		{1_500_000, []SizeOption{WithSizeBase(SizeBaseSI)}, "1.5 MB"},
+		{1 << 30, []SizeOption{WithSizePrecision(0)}, "1 GiB"},
+		{1 << 20, []SizeOption{WithSizePrecision(3)}, "1.000 MiB"},
+		{1 << 20, []SizeOption{WithSizeUnitStyle(SizeUnitLong), WithSizePrecision(0)}, "1 mebibyte"},
+		{2_000, []SizeOption{WithSizeBase(SizeBaseSI), WithSizeUnitStyle(SizeUnitLong), WithSizePrecision(0)}, "2 kilobytes"},
+		{12_500_000, []SizeOption{WithSizeBase(SizeBaseSI), WithSizeUnitStyle(SizeUnitBits), WithSizePrecision(0)}, "100 Mbit"},
+		{1, []SizeOption{WithSizeUnitStyle(SizeUnitBits)}, "8 bit"},
+	}
+	for _, tt := range tests {
+		if got := FormatSize(tt.bytes, tt.opts...); got != tt.want {
+			t.Errorf("FormatSize(%d) = %q, want %q", tt.bytes, got, tt.want)
+		}
+	}
+}
+
+func TestSizeRuleRegistered(t *testing.T) {
+	rule, ok := config.RuleFor("file.max_file_size")
+	if !ok {
+		t.Fatal("no rule registered for file.max_file_size")
+	}
+	if err := rule("100MB"); err != nil {
+		t.Errorf("rule(100MB) error = %v", err)
+	}
+	if err := rule("lots"); !errors.Is(err, config.ErrInvalidValue) {
+		t.Errorf("rule(lots) error = %v, want ErrInvalidValue", err)
+	}
+}
@@ -0,0 +1,212 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/nate3d/go-toolbox/internal/theme"
+)
+
+// clearScreen moves the cursor home and clears the terminal
+const clearScreen = "\x1b[H\x1b[2J"
+
+// WatchSnapshot is one NDJSON line written by --watch in JSON mode.
+type WatchSnapshot struct {
+	Time  time.Time   `json:"time"`
+	Run   int         `json:"run"`
+	Data  interface{} `json:"data"`
+	Error string      `json:"error,omitempty"`
+}
+
+// AddWatchFlag adds --watch to cmd, a subcommand of c. Only commands that
+// do not change anything should have it, since each run repeats the last.
+func (c *BaseCommand) AddWatchFlag(cmd *cobra.Command) {
+	DurationVar(cmd.Flags(), &c.Watch, "watch", 0, "Re-run the command every interval, e.g. 2s, until interrupted")
+}
+
+// runWatch re-runs fn every --watch interval until Ctrl-C or SIGTERM. An
+// interrupt cancels cmd.Context() and waits for the current run to end, so
+// commands should watch it to stop early; a second interrupt ends the
+// process.
+func (c *BaseCommand) runWatch(cmd *cobra.Command, fn func() error) error {
+	parent := cmd.Context()
+	ctx, stop := signal.NotifyContext(parent, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	context.AfterFunc(ctx, stop)
+	cmd.SetContext(ctx)
+	defer cmd.SetContext(parent)
+
+	redraw := c.Output != OutputJSON && term.IsTerminal(int(os.Stdout.Fd()))
+	w := newWatcher(c, c.OutOrStdout(), redraw)
+	c.watcher = w
+	defer func() { c.watcher = nil }()
+
+	ticker := time.NewTicker(c.Watch)
+	defer ticker.Stop()
+	for {
+		if err := w.frame(ctx, fn); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// watcher renders the frames of a --watch session and remembers the tables
+// of the previous run to highlight what changed.
+type watcher struct {
+	cmd    *BaseCommand
+	out    io.Writer
+	redraw bool
+	title  string
+	now    func() time.Time
+
+	run    int
+	prev   [][][]string
+	tables [][][]string
+	data   []interface{}
+}
+
+func newWatcher(cmd *BaseCommand, out io.Writer, redraw bool) *watcher {
+	return &watcher{cmd: cmd, out: out, redraw: redraw, title: commandLine(cmd), now: time.Now}
+}
+
+// commandLine is the command as typed, shown in the frame header.
+func commandLine(cmd *BaseCommand) string {
+	if len(os.Args) == 0 {
+		return cmd.CommandPath()
+	}
+	return strings.Join(os.Args, " ")
+}
+
+// frame runs fn once and writes its output as a screen or NDJSON line,
+// unless ctx was cancelled meanwhile and the output is incomplete.
+func (w *watcher) frame(ctx context.Context, fn func() error) error {
+	w.run++
+	w.prev = w.tables
+	w.tables = nil
+	w.data = nil
+
+	var buf bytes.Buffer
+	w.cmd.SetOut(&buf)
+	runErr := fn()
+	w.cmd.SetOut(w.out)
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	if w.cmd.Output == OutputJSON {
+		return w.writeSnapshot(runErr)
+	}
+
+	var frame bytes.Buffer
+	if w.redraw {
+		frame.WriteString(clearScreen)
+	}
+	t := theme.Current()
+	header := fmt.Sprintf("Every %s: %s    %s", FormatDuration(w.cmd.Watch), w.title,
+		w.now().Format(time.DateTime))
+	frame.WriteString(t.Sprint(theme.Muted, header) + "\n\n")
+	frame.Write(buf.Bytes())
+	if runErr != nil {
+		frame.WriteString(t.Sprint(theme.Error, "Error: "+runErr.Error()) + "\n")
+	}
+	_, err := w.out.Write(frame.Bytes())
+	return err
+}
+
+// writeSnapshot writes the data of one run as a single JSON line. Values
+// passed to PrintData are used; otherwise tables become lists of objects.
+func (w *watcher) writeSnapshot(runErr error) error {
+	snapshot := WatchSnapshot{Time: w.now(), Run: w.run}
+	switch {
+	case len(w.data) == 1:
+		snapshot.Data = w.data[0]
+	case len(w.data) > 1:
+		snapshot.Data = w.data
+	case len(w.tables) > 0:
+		snapshot.Data = w.tableData()
+	}
+	if runErr != nil {
+		snapshot.Error = runErr.Error()
+	}
+	return json.NewEncoder(w.out).Encode(snapshot)
+}
+
+// tableData converts the recorded tables to header-keyed rows.
+func (w *watcher) tableData() interface{} {
+	tables := make([][]map[string]string, 0, len(w.tables))
+	for _, table := range w.tables {
+		headers, rows := table[0], table[1:]
+		objects := make([]map[string]string, 0, len(rows))
+		for _, row := range rows {
+			object := make(map[string]string, len(row))
+			for i, cell := range row {
+				if i < len(headers) {
+					object[headers[i]] = cell
+				}
+			}
+			objects = append(objects, object)
+		}
+		tables = append(tables, objects)
+	}
+	if len(tables) == 1 {
+		return tables[0]
+	}
+	return tables
+}
+
+// record keeps a PrintData value for the snapshot.
+func (w *watcher) record(v interface{}) {
+	w.data = append(w.data, v)
+}
+
+// table records a table and highlights the cells that changed since the
+// previous run. Rows are matched by their first cell, so reordered rows
+// such as processes sorted by CPU are compared with themselves.
+func (w *watcher) table(headers []string, rows [][]string) [][]string {
+	index := len(w.tables)
+	recorded := append([][]string{headers}, rows...)
+	w.tables = append(w.tables, recorded)
+	if index >= len(w.prev) {
+		return rows
+	}
+
+	previous := make(map[string][]string)
+	for _, row := range w.prev[index][1:] {
+		if len(row) > 0 {
+			previous[row[0]] = row
+		}
+	}
+
+	t := theme.Current()
+	highlighted := make([][]string, len(rows))
+	for i, row := range rows {
+		var old []string
+		if len(row) > 0 {
+			old = previous[row[0]]
+		}
+		highlighted[i] = make([]string, len(row))
+		for j, cell := range row {
+			if old == nil || j >= len(old) || old[j] != cell {
+				cell = t.Sprint(theme.Highlight, cell)
+			}
+			highlighted[i][j] = cell
+		}
+	}
+	return highlighted
+}
@@ -0,0 +1,174 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nate3d/go-toolbox/internal/theme"
+)
+
+func newTestWatcher(t *testing.T, output OutputFormat) (*BaseCommand, *watcher, *bytes.Buffer) {
+	t.Helper()
+	previous := theme.Current()
+	theme.Set(theme.New("test", theme.Palette{Highlight: "1"}, true))
+	t.Cleanup(func() { theme.Set(previous) })
+
+	cmd := NewBaseCommand("ps", "")
+	cmd.Output = output
+	cmd.Watch = 2 * time.Second
+
+	var out bytes.Buffer
+	w := newWatcher(cmd, &out, true)
+	w.title = "toolbox system ps"
+	w.now = func() time.Time { return time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC) }
+	cmd.watcher = w
+	return cmd, w, &out
+}
+
+func TestWatchHighlightsChangedCells(t *testing.T) {
+	cmd, w, out := newTestWatcher(t, OutputTable)
+
+	cpu := "1.0%"
+	run := func() error {
+		table := cmd.NewTable([]string{"PID", "CPU%"})
+		table.AddRow("42", cpu)
+		table.AddRow("7", "0.1%")
+		table.Render()
+		return nil
+	}
+
+	if err := w.frame(context.Background(), run); err != nil {
+		t.Fatal(err)
+	}
+	first := out.String()
+	if !strings.HasPrefix(first, clearScreen) {
+		t.Errorf("frame does not clear the screen: %q", first)
+	}
+	if !strings.Contains(first, "Every 2s: toolbox system ps    2024-05-01 12:00:00") {
+		t.Errorf("frame header missing: %q", first)
+	}
+	if strings.Contains(first, "\x1b[31m") {
+		t.Errorf("first frame has highlights: %q", first)
+	}
+
+	out.Reset()
+	cpu = "9.5%"
+	if err := w.frame(context.Background(), run); err != nil {
+		t.Fatal(err)
+	}
+	second := out.String()
+	if !strings.Contains(second, "\x1b[31m9.5%") {
+		t.Errorf("changed cell not highlighted: %q", second)
+	}
+	if strings.Contains(second, "\x1b[31m0.1%") || strings.Contains(second, "\x1b[31m42") {
+		t.Errorf("unchanged cells highlighted: %q", second)
+	}
+}
+
+func TestWatchSnapshots(t *testing.T) {
+	cmd, w, out := newTestWatcher(t, OutputJSON)
+
+	runs := 0
+	run := func() error {
+		runs++
+		table := cmd.NewTable([]string{"Port", "State"})
+		table.AddRow("22", "open")
+		table.Render()
+		if runs == 2 {
+			return errors.New("host unreachable")
+		}
+		return nil
+	}
+	for i := 0; i < 2; i++ {
+		if err := w.frame(context.Background(), run); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2: %q", len(lines), out.String())
+	}
+	var snapshot struct {
+		Run   int                 `json:"run"`
+		Data  []map[string]string `json:"data"`
+		Error string              `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.Run != 2 || snapshot.Error != "host unreachable" {
+		t.Errorf("snapshot = %+v", snapshot)
+	}
+	if len(snapshot.Data) != 1 || snapshot.Data[0]["State"] != "open" {
+		t.Errorf("snapshot data = %v", snapshot.Data)
+	}
+}
+
+func TestWatchSnapshotUsesPrintData(t *testing.T) {
+	cmd, w, out := newTestWatcher(t, OutputJSON)
+
+	err := w.frame(context.Background(), func() error {
+		_, err := cmd.PrintData(map[string]int{"count": 3})
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), `"data":{"count":3}`) {
+		t.Errorf("snapshot = %q", out.String())
+	}
+}
+
+func TestWatchIsOptIn(t *testing.T) {
+	base := NewBaseCommand("system", "")
+	started := make(chan struct{})
+	finished := false
+	ps := &cobra.Command{Use: "ps", RunE: func(cmd *cobra.Command, _ []string) error {
+		close(started)
+		// A slow run that stops some time after being cancelled
+		<-cmd.Context().Done()
+		time.Sleep(50 * time.Millisecond)
+		_, err := base.PrintData(map[string]int{"count": 1})
+		finished = true
+		return err
+	}}
+	kill := &cobra.Command{Use: "kill", RunE: func(*cobra.Command, []string) error { return nil }}
+	base.AddWatchFlag(ps)
+	base.AddCommand(ps, kill)
+	base.SetOut(&bytes.Buffer{})
+	base.SetErr(&bytes.Buffer{})
+
+	base.SetArgs([]string{"kill", "--watch", "1s"})
+	if err := base.Execute(); err == nil || !strings.Contains(err.Error(), "unknown flag: --watch") {
+		t.Errorf("kill --watch: error = %v", err)
+	}
+
+	// Cancelling, as Ctrl-C does, stops the current run and waits for it
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+	base.SetArgs([]string{"ps", "--watch", "1s"})
+	done := make(chan error, 1)
+	go func() { done <- base.ExecuteContext(ctx) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("ps --watch: error = %v", err)
+		}
+		if !finished {
+			t.Error("ps --watch returned before the run ended")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("ps --watch did not stop when cancelled")
+	}
+}
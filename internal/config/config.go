@@ -2,13 +2,17 @@
 package config
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/spf13/viper"
+	"go.yaml.in/yaml/v3"
 )
 
 // Config represents the global configuration structure
@@ -18,8 +22,11 @@ type Config struct {
 	LogFile  string `mapstructure:"log_file"`
 
 	// Application-specific settings
-	CLI CLIConfig `mapstructure:"cli"`
-	TUI TUIConfig `mapstructure:"tui"`
+	CLI     CLIConfig     `mapstructure:"cli"`
+	TUI     TUIConfig     `mapstructure:"tui"`
+	File    FileConfig    `mapstructure:"file"`
+	Network NetworkConfig `mapstructure:"network"`
+	System  SystemConfig  `mapstructure:"system"`
 }
 
 // CLIConfig holds CLI-specific configuration
@@ -35,6 +42,30 @@ type TUIConfig struct {
 	MouseEvents bool   `mapstructure:"mouse_events"`
 }
 
+// FileConfig holds file operation settings
+type FileConfig struct {
+	// MaxFileSize is a size string such as "100MB"; parse it with cli.ParseSize.
+	MaxFileSize     string `mapstructure:"max_file_size"`
+	RecursiveSearch bool   `mapstructure:"recursive_search"`
+	ShowHidden      bool   `mapstructure:"show_hidden"`
+}
+
+// NetworkConfig holds network tool settings
+type NetworkConfig struct {
+	// Timeout is a duration string such as "5s"; parse it with cli.ParseDuration.
+	Timeout         string `mapstructure:"timeout"`
+	ConcurrentScans int    `mapstructure:"concurrent_scans"`
+	DefaultPorts    []int  `mapstructure:"default_ports"`
+}
+
+// SystemConfig holds system information settings
+type SystemConfig struct {
+	// RefreshInterval is a duration string such as "1s"; parse it with cli.ParseDuration.
+	RefreshInterval string `mapstructure:"refresh_interval"`
+	ShowProcesses   bool   `mapstructure:"show_processes"`
+	MaxProcesses    int    `mapstructure:"max_processes"`
+}
+
 var globalConfig *Config
 
 // Init initializes the configuration system
@@ -54,7 +85,7 @@ func Init(appName string) error {
 	viper.AutomaticEnv()
 
 	// Set defaults
-	setDefaults()
+	setDefaults(viper.GetViper())
 
 	// Read configuration file
 	if err := viper.ReadInConfig(); err != nil {
@@ -64,30 +95,67 @@ func Init(appName string) error {
 		}
 		// Config file not found, use defaults
 	}
+	return load()
+}
 
-	// Unmarshal into struct
+// LoadFile reads the configuration from file instead of the searched
+// locations, as for a --config flag. Init must have been called first.
+func LoadFile(file string) error {
+	viper.SetConfigFile(file)
+	if err := viper.ReadInConfig(); err != nil {
+		return fmt.Errorf("error reading config file: %w", err)
+	}
+	return load()
+}
+
+// load unmarshals and validates the configuration that was read.
+func load() error {
 	globalConfig = &Config{}
 	if err := viper.Unmarshal(globalConfig); err != nil {
 		return fmt.Errorf("error unmarshaling config: %w", err)
 	}
 
+	// Validate keys with registered rules
+	if err := Validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
 	return nil
 }
 
 // setDefaults sets default configuration values
-func setDefaults() {
+func setDefaults(v *viper.Viper) {
 	// Global defaults
-	viper.SetDefault("log_level", "info")
-	viper.SetDefault("log_file", "")
+	v.SetDefault("log_level", "info")
+	v.SetDefault("log_file", "")
 
 	// CLI defaults
-	viper.SetDefault("cli.default_output", "table")
-	viper.SetDefault("cli.color_output", true)
-	viper.SetDefault("cli.verbose", false)
+	v.SetDefault("cli.default_output", "table")
+	v.SetDefault("cli.color_output", true)
+	v.SetDefault("cli.verbose", false)
+	v.SetDefault("cli.pager", "")
 
 	// TUI defaults
-	viper.SetDefault("tui.theme", "default")
-	viper.SetDefault("tui.mouse_events", true)
+	v.SetDefault("tui.theme", "default")
+	v.SetDefault("tui.mouse_events", true)
+
+	// File defaults
+	v.SetDefault("file.max_file_size", "100MB")
+	v.SetDefault("file.recursive_search", true)
+	v.SetDefault("file.show_hidden", false)
+
+	// Network defaults
+	v.SetDefault("network.timeout", "5s")
+	v.SetDefault("network.concurrent_scans", 100)
+	v.SetDefault("network.default_ports", []int{22, 23, 53, 80, 110, 443, 993, 995})
+
+	// System defaults
+	v.SetDefault("system.refresh_interval", "1s")
+	v.SetDefault("system.show_processes", true)
+	v.SetDefault("system.max_processes", 50)
+
+	// User-defined command aliases
+	v.SetDefault("aliases", map[string]interface{}{})
 }
 
 // Get returns the global configuration
@@ -95,7 +163,7 @@ func Get() *Config {
 	if globalConfig == nil {
 		// Initialize with default values if not initialized
 		globalConfig = &Config{}
-		setDefaults()
+		setDefaults(viper.GetViper())
 		_ = viper.Unmarshal(globalConfig)
 	}
 	return globalConfig
@@ -116,16 +184,118 @@ func GetInt(key string) int {
 	return viper.GetInt(key)
 }
 
+// Value returns the raw configuration value at key
+func Value(key string) interface{} {
+	return viper.Get(key)
+}
+
+// IsSet reports whether key has a value from a default, file, flag or environment variable
+func IsSet(key string) bool {
+	return viper.IsSet(key)
+}
+
+// Keys returns all known configuration keys in dotted form, sorted
+func Keys() []string {
+	keys := viper.AllKeys()
+	sort.Strings(keys)
+	return keys
+}
+
+// UnmarshalKey decodes the configuration subtree at key into out
+func UnmarshalKey(key string, out interface{}) error {
+	return viper.UnmarshalKey(key, out)
+}
+
 // Set sets a configuration value
 func Set(key string, value interface{}) {
 	viper.Set(key, value)
 }
 
+// Unset removes key from the values read from the configuration file, so the
+// next WriteConfig drops it. Viper itself can only add or override values.
+func Unset(key string) error {
+	file := viper.ConfigFileUsed()
+	if file == "" {
+		return nil
+	}
+
+	v := viper.New()
+	v.SetConfigFile(file)
+	if err := v.ReadInConfig(); err != nil {
+		return fmt.Errorf("error reading config file: %w", err)
+	}
+	settings := v.AllSettings()
+	deleteKey(settings, strings.Split(strings.ToLower(key), "."))
+
+	data, err := yaml.Marshal(settings)
+	if err != nil {
+		return fmt.Errorf("error encoding config: %w", err)
+	}
+	viper.SetConfigType("yaml")
+	return viper.ReadConfig(bytes.NewReader(data))
+}
+
+// SetInFile sets key to value in the configuration file at path, creating
+// it if needed, and in the running configuration. Only the values read from
+// the file are written back, not defaults or environment overrides as with
+// WriteConfig.
+func SetInFile(path, key string, value interface{}) error {
+	settings := map[string]interface{}{}
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err == nil {
+		settings = v.AllSettings()
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("error reading config file: %w", err)
+	}
+	setKey(settings, strings.Split(strings.ToLower(key), "."), value)
+
+	out := viper.New()
+	if err := out.MergeConfigMap(settings); err != nil {
+		return fmt.Errorf("error encoding config: %w", err)
+	}
+	if err := out.WriteConfigAs(path); err != nil {
+		return fmt.Errorf("error writing config file: %w", err)
+	}
+	viper.Set(key, value)
+	return nil
+}
+
+// setKey sets the dotted path in a nested settings map, replacing any
+// value there
+func setKey(settings map[string]interface{}, path []string, value interface{}) {
+	for _, name := range path[:len(path)-1] {
+		child, ok := settings[name].(map[string]interface{})
+		if !ok {
+			child = map[string]interface{}{}
+			settings[name] = child
+		}
+		settings = child
+	}
+	settings[path[len(path)-1]] = value
+}
+
+// deleteKey removes the dotted path from a nested settings map
+func deleteKey(settings map[string]interface{}, path []string) {
+	if len(path) == 1 {
+		delete(settings, path[0])
+		return
+	}
+	if child, ok := settings[path[0]].(map[string]interface{}); ok {
+		deleteKey(child, path[1:])
+	}
+}
+
 // WriteConfig writes the current configuration to file
 func WriteConfig() error {
 	return viper.WriteConfig()
 }
 
+// FileUsed returns the path of the configuration file that was read, or "" if none was found
+func FileUsed() string {
+	return viper.ConfigFileUsed()
+}
+
 // WriteConfigAs writes the current configuration to a specific file
 func WriteConfigAs(filename string) error {
 	return viper.WriteConfigAs(filename)
@@ -145,3 +315,24 @@ func GetConfigDir(appName string) (string, error) {
 
 	return configDir, nil
 }
+
+// GetStateDir returns the directory for state such as history files,
+// following the XDG base directory spec: $XDG_STATE_HOME/<app> or
+// ~/.local/state/<app>
+func GetStateDir(appName string) (string, error) {
+	base := os.Getenv("XDG_STATE_HOME")
+	if base == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		base = filepath.Join(homeDir, ".local", "state")
+	}
+
+	stateDir := filepath.Join(base, appName)
+	if mkdirErr := os.MkdirAll(stateDir, 0750); mkdirErr != nil {
+		return "", mkdirErr
+	}
+
+	return stateDir, nil
+}
@@ -4,6 +4,8 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+
+	"github.com/spf13/viper"
 )
 
 func TestDefaultConfigValues(t *testing.T) {
@@ -54,3 +56,117 @@ func TestGetConfigDirCreatesDirectory(t *testing.T) {
 		t.Fatalf("Expected %s to be a directory", dir)
 	}
 }
+
+func TestUnset(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	content := "aliases:\n  a: system info\n  b: system ps\nlog_level: debug\n"
+	if err := os.WriteFile(file, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	viper.SetConfigFile(file)
+	if err := viper.ReadInConfig(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		viper.Reset()
+		setDefaults(viper.GetViper())
+	})
+
+	if err := Unset("aliases.a"); err != nil {
+		t.Fatalf("Unset() error = %v", err)
+	}
+	if viper.IsSet("aliases.a") {
+		t.Error("aliases.a is still set")
+	}
+	if got := viper.GetString("aliases.b"); got != "system ps" {
+		t.Errorf("aliases.b = %q, want %q", got, "system ps")
+	}
+	if got := viper.GetString("log_level"); got != "debug" {
+		t.Errorf("log_level = %q, want %q", got, "debug")
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "custom.yaml")
+	if err := os.WriteFile(file, []byte("log_level: warn\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := Init("testapp"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		viper.Reset()
+		setDefaults(viper.GetViper())
+	})
+
+	if err := LoadFile(file); err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if got := Get().LogLevel; got != "warn" {
+		t.Errorf("LogLevel = %q, want warn", got)
+	}
+	if got := FileUsed(); got != file {
+		t.Errorf("FileUsed() = %q, want %q", got, file)
+	}
+	if err := LoadFile(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("LoadFile() of a missing file succeeded")
+	}
+}
+
+func TestGetStateDir(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("XDG_STATE_HOME", "")
+	t.Setenv("HOME", tempDir)
+
+	dir, err := GetStateDir("testapp")
+	if err != nil {
+		t.Fatalf("GetStateDir failed: %v", err)
+	}
+	if want := filepath.Join(tempDir, ".local", "state", "testapp"); dir != want {
+		t.Errorf("GetStateDir() = %q, want %q", dir, want)
+	}
+
+	t.Setenv("XDG_STATE_HOME", filepath.Join(tempDir, "state"))
+	dir, err = GetStateDir("testapp")
+	if err != nil {
+		t.Fatalf("GetStateDir failed: %v", err)
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		t.Errorf("state directory %s was not created: %v", dir, err)
+	}
+}
+
+func TestSetInFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(file, []byte("log_level: warn\naliases:\n  old: system ps\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	setDefaults(viper.GetViper())
+	viper.Set("log_level", "debug") // as an environment override would
+	t.Cleanup(func() {
+		viper.Reset()
+		setDefaults(viper.GetViper())
+	})
+
+	if err := SetInFile(file, "aliases", map[string]interface{}{"up": "system info"}); err != nil {
+		t.Fatalf("SetInFile() error = %v", err)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "aliases:\n    up: system info\nlog_level: warn\n"; string(data) != want {
+		t.Errorf("config file = %q, want %q", data, want)
+	}
+	if got := viper.GetString("aliases.up"); got != "system info" {
+		t.Errorf("aliases.up = %q, want %q", got, "system info")
+	}
+
+	created := filepath.Join(t.TempDir(), "new.yaml")
+	if err := SetInFile(created, "cli.pager", "less"); err != nil {
+		t.Fatalf("SetInFile() on a new file error = %v", err)
+	}
+	if data, err := os.ReadFile(created); err != nil || string(data) != "cli:\n    pager: less\n" {
+		t.Errorf("new config file = %q, %v", data, err)
+	}
+}
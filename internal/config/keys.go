@@ -0,0 +1,68 @@
+package config
+
+import (
+	"slices"
+	"sort"
+
+	"github.com/spf13/viper"
+)
+
+// KeyInfo documents a configuration key and its default value.
+type KeyInfo struct {
+	Key         string
+	Default     interface{}
+	Description string
+}
+
+// keyDescriptions documents the configuration keys for generated reference docs
+var keyDescriptions = map[string]string{
+	"aliases":                  "User-defined commands: a command line, or a list of them for a macro, with $1 and $@ placeholders",
+	"log_level":                "Log level: debug, info, warn or error",
+	"log_file":                 "Log file path; empty logs to stdout",
+	"cli.default_output":       "Default output format: table, json or yaml",
+	"cli.color_output":         "Colorize output when --color is auto and the environment does not decide",
+	"cli.verbose":              "Enable verbose output by default",
+	"cli.pager":                "Pager for output taller than the terminal; empty uses $PAGER or less -FRX, false disables paging",
+	"tui.theme":                "Color palette for CLI and TUI output: default, light, dracula, solarized, monochrome or a palette under themes",
+	"tui.mouse_events":         "Enable mouse support in the terminal UI",
+	"file.max_file_size":       "Largest file processed by file commands, e.g. 100MB or 1GiB",
+	"file.recursive_search":    "Search directories recursively by default",
+	"file.show_hidden":         "Include hidden files by default",
+	"network.timeout":          "Timeout for network operations, e.g. 5s or 1m30s",
+	"network.concurrent_scans": "Maximum concurrent connections during port scans",
+	"network.default_ports":    "Ports scanned when none are given",
+	"system.refresh_interval":  "Refresh interval for live system views, e.g. 1s",
+	"system.show_processes":    "Show processes in system views",
+	"system.max_processes":     "Maximum number of processes listed",
+}
+
+// Describe returns the documentation for a configuration key, or "" if it is undocumented
+func Describe(key string) string {
+	return keyDescriptions[key]
+}
+
+// KnownKeys returns every key with a built-in default, sorted by key. Values
+// are the defaults, not the values from config files or the environment.
+func KnownKeys() []KeyInfo {
+	v := viper.New()
+	setDefaults(v)
+
+	// Sections that default to an empty map have no leaf keys of their own
+	keys := v.AllKeys()
+	for key := range keyDescriptions {
+		if !slices.Contains(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	infos := make([]KeyInfo, 0, len(keys))
+	for _, key := range keys {
+		infos = append(infos, KeyInfo{
+			Key:         key,
+			Default:     v.Get(key),
+			Description: keyDescriptions[key],
+		})
+	}
+	return infos
+}
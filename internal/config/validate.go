@@ -0,0 +1,168 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// ErrInvalidValue is wrapped by every validation failure.
+var ErrInvalidValue = errors.New("invalid value")
+
+// DateTimeLayouts are the layouts accepted by the DateTime rule, most specific first.
+var DateTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// Rule validates a single configuration or input value. The same rules are
+// used for configuration keys and for interactive prompts.
+type Rule func(value string) error
+
+// Required rejects empty values.
+func Required() Rule {
+	return func(value string) error {
+		if strings.TrimSpace(value) == "" {
+			return fmt.Errorf("%w: value is required", ErrInvalidValue)
+		}
+		return nil
+	}
+}
+
+// OneOf accepts only the listed values.
+func OneOf(allowed ...string) Rule {
+	return func(value string) error {
+		for _, candidate := range allowed {
+			if value == candidate {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: %q must be one of %s", ErrInvalidValue, value, strings.Join(allowed, ", "))
+	}
+}
+
+// IntRange accepts integers between minValue and maxValue inclusive.
+func IntRange(minValue, maxValue int) Rule {
+	return func(value string) error {
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("%w: %q is not a whole number", ErrInvalidValue, value)
+		}
+		if n < minValue || n > maxValue {
+			return fmt.Errorf("%w: %d is outside the range %d-%d", ErrInvalidValue, n, minValue, maxValue)
+		}
+		return nil
+	}
+}
+
+// Matches accepts values matching the regular expression. The description
+// is used in the error message, e.g. "lowercase letters and hyphens".
+func Matches(pattern *regexp.Regexp, description string) Rule {
+	return func(value string) error {
+		if !pattern.MatchString(value) {
+			return fmt.Errorf("%w: %q must contain %s", ErrInvalidValue, value, description)
+		}
+		return nil
+	}
+}
+
+// DateTime accepts values in one of DateTimeLayouts.
+func DateTime() Rule {
+	return func(value string) error {
+		if _, err := ParseDateTime(value); err != nil {
+			return err
+		}
+		return nil
+	}
+}
+
+// PathExists accepts paths that exist on disk.
+func PathExists() Rule {
+	return func(value string) error {
+		if _, err := os.Stat(value); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidValue, err)
+		}
+		return nil
+	}
+}
+
+// All combines rules, returning the first failure.
+func All(rules ...Rule) Rule {
+	return func(value string) error {
+		for _, rule := range rules {
+			if err := rule(value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// ParseDateTime parses value using the first matching layout in DateTimeLayouts.
+// Values without a zone are interpreted in local time.
+func ParseDateTime(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range DateTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%w: %q is not a date/time (use YYYY-MM-DD [HH:MM[:SS]] or RFC 3339)", ErrInvalidValue, value)
+}
+
+var (
+	rulesMu sync.RWMutex
+	rules   = map[string]Rule{
+		"log_level":          OneOf("debug", "info", "warn", "error"),
+		"cli.default_output": OneOf("table", "json", "yaml"),
+	}
+)
+
+// RegisterRule sets the validation rule for a configuration key. Packages that
+// own a value format (sizes, durations) register rules for the keys using it.
+func RegisterRule(key string, rule Rule) {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	rules[key] = rule
+}
+
+// RuleFor returns the validation rule registered for key, if any.
+func RuleFor(key string) (Rule, bool) {
+	rulesMu.RLock()
+	defer rulesMu.RUnlock()
+	rule, ok := rules[key]
+	return rule, ok
+}
+
+// Validate checks every configured key that has a registered rule.
+func Validate() error {
+	rulesMu.RLock()
+	keys := make([]string, 0, len(rules))
+	for key := range rules {
+		keys = append(keys, key)
+	}
+	rulesMu.RUnlock()
+	sort.Strings(keys)
+
+	var errs []error
+	for _, key := range keys {
+		if !viper.IsSet(key) {
+			continue
+		}
+		rule, _ := RuleFor(key)
+		if err := rule(viper.GetString(key)); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  Rule
+		value string
+		ok    bool
+	}{
+		{"required ok", Required(), "x", true},
+		{"required empty", Required(), "  ", false},
+		{"one of ok", OneOf("a", "b"), "b", true},
+		{"one of bad", OneOf("a", "b"), "c", false},
+		{"int range ok", IntRange(1, 10), "10", true},
+		{"int range low", IntRange(1, 10), "0", false},
+		{"int range nan", IntRange(1, 10), "ten", false},
+		{"matches ok", Matches(regexp.MustCompile(`^[a-z-]+$`), "lowercase letters"), "go-tool", true},
+		{"matches bad", Matches(regexp.MustCompile(`^[a-z-]+$`), "lowercase letters"), "Go Tool", false},
+		{"date ok", DateTime(), "2025-03-01 14:30", true},
+		{"date bad", DateTime(), "tomorrow", false},
+		{"all", All(Required(), OneOf("x")), "", false},
+	}
+	for _, tt := range tests {
+		err := tt.rule(tt.value)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: rule(%q) error = %v, want ok=%v", tt.name, tt.value, err, tt.ok)
+		}
+		if err != nil && !errors.Is(err, ErrInvalidValue) {
+			t.Errorf("%s: error %v does not wrap ErrInvalidValue", tt.name, err)
+		}
+	}
+}
+
+func TestParseDateTime(t *testing.T) {
+	got, err := ParseDateTime("2025-03-01")
+	if err != nil {
+		t.Fatalf("ParseDateTime error = %v", err)
+	}
+	want := time.Date(2025, 3, 1, 0, 0, 0, 0, time.Local)
+	if !got.Equal(want) {
+		t.Errorf("ParseDateTime = %v, want %v", got, want)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	viper.Set("log_level", "loud")
+	t.Cleanup(func() { viper.Set("log_level", "info") })
+
+	if err := Validate(); !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("Validate() error = %v, want ErrInvalidValue", err)
+	}
+}
@@ -0,0 +1,321 @@
+package filecmd
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/spf13/cobra"
+
+	"github.com/nate3d/go-toolbox/internal/cli"
+)
+
+// archiveCreateOptions are the flags of "file archive create".
+type archiveCreateOptions struct {
+	format string
+	filter archiveFilter
+	walk   walkOptions
+}
+
+// archiveMember is a file to add to an archive under name.
+type archiveMember struct {
+	path string
+	name string
+	info fs.FileInfo
+	link string
+}
+
+// archiveWriter adds members to an archive of one format.
+type archiveWriter interface {
+	add(member archiveMember, body io.Reader) error
+	Close() error
+}
+
+func newArchiveCreateCommand(parent *cli.BaseCommand) *cobra.Command {
+	opts := &archiveCreateOptions{}
+	cmd := &cobra.Command{
+		Use:   "create <archive> <path>...",
+		Short: "Create an archive from files and directories",
+		Long: `Create an archive holding the given paths and everything below them.
+Members are named relative to the directory containing each path, so
+"create site.zip public" stores public/index.html.
+
+Permissions, modification times and symbolic links are preserved.
+--include keeps only matching files and --exclude skips matching files and
+directories; patterns match a member's whole name or its last element.
+Hidden entries are skipped unless --all is given or file.show_hidden is
+set. The archive is written to a temporary file and renamed when complete.`,
+		Example: `  toolbox file archive create backup.tar.gz ~/projects/site
+  toolbox file archive create src.tar.zst . --gitignore --exclude '*.log'
+  toolbox file archive create images.zip photos --include '*.jpg' --include '*.png'`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			applyWalkDefaults(cmd, &opts.walk)
+			return runArchiveCreate(parent, args[0], args[1:], opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.format, "format", "", "Archive format: "+strings.Join(archiveFormats, ", ")+" (default: from the archive name)")
+	opts.filter.addFlags(cmd, "add")
+	addWalkFlags(cmd, &opts.walk)
+	_ = cmd.RegisterFlagCompletionFunc("format", cli.CompleteValues(archiveFormats...))
+
+	return cmd
+}
+
+func runArchiveCreate(cmd *cli.BaseCommand, archive string, paths []string, opts *archiveCreateOptions) error {
+	format := opts.format
+	if format == "" {
+		if format = formatFromName(archive); format == "" {
+			return cli.UsageErrorf("cannot tell the format of %s", archive).
+				WithHint("name it .tar, .tar.gz, .tar.zst or .zip, or pass --format")
+		}
+	}
+	if !slices.Contains(archiveFormats, format) {
+		return cli.UsageErrorf("unknown archive format %q", format).WithSuggestions(format, archiveFormats)
+	}
+	if err := opts.filter.validate(); err != nil {
+		return err
+	}
+
+	members, errs := collectArchiveMembers(cmd, archive, paths, opts)
+	if err := inputFailures(cmd, errs, len(paths), "archived"); err != nil {
+		return err
+	}
+
+	summary := archiveSummary{Archive: archive, Format: format, Entries: len(members)}
+	for _, member := range members {
+		if member.info.Mode().IsRegular() {
+			summary.Bytes += member.info.Size()
+		}
+	}
+	if err := writeArchive(archive, format, members, newBytesProgress(summary.Bytes, "Archiving")); err != nil {
+		return err
+	}
+
+	if printed, err := cmd.PrintData(summary); printed {
+		return err
+	}
+	compressed := ""
+	if info, err := os.Stat(archive); err == nil {
+		compressed = ", " + cli.FormatSize(info.Size()) + " written"
+	}
+	cmd.PrintSuccessf("Created %s: %s%s", archive, summary, compressed)
+	return nil
+}
+
+// collectArchiveMembers walks the paths and returns the members to add.
+// The archive itself is left out when it lies below a path.
+func collectArchiveMembers(cmd *cli.BaseCommand, archive string, paths []string, opts *archiveCreateOptions) ([]archiveMember, []error) {
+	archiveAbs, _ := filepath.Abs(archive)
+	var members []archiveMember
+	var errs []error
+	for _, root := range paths {
+		root = filepath.Clean(root)
+		base := filepath.Dir(root)
+		err := walkTree(root, opts.walk, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				if path == root {
+					return err
+				}
+				cmd.PrintWarnf("%v", err)
+				return nil
+			}
+			rel, err := filepath.Rel(base, path)
+			if err != nil || rel == "." {
+				return err
+			}
+			if abs, _ := filepath.Abs(path); abs == archiveAbs {
+				return nil
+			}
+			name := filepath.ToSlash(rel)
+			if !opts.filter.match(name, entry.IsDir()) {
+				if entry.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if entry.IsDir() && len(opts.filter.include) > 0 {
+				// Only the included files are archived, with their directories
+				return nil
+			}
+			member, err := newArchiveMember(path, name, entry)
+			if err != nil {
+				cmd.PrintWarnf("%v", err)
+				return nil
+			}
+			members = append(members, member)
+			return nil
+		})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return members, errs
+}
+
+func newArchiveMember(path, name string, entry fs.DirEntry) (archiveMember, error) {
+	info, err := statEntry(path, entry)
+	if err != nil {
+		return archiveMember{}, err
+	}
+	member := archiveMember{path: path, name: name, info: info}
+	if !info.Mode().IsRegular() && !info.IsDir() && info.Mode()&fs.ModeSymlink == 0 {
+		return archiveMember{}, fmt.Errorf("skipped %s: cannot archive a %s", path, fileType(info.Mode()))
+	}
+	if info.Mode()&fs.ModeSymlink != 0 {
+		if member.link, err = os.Readlink(path); err != nil {
+			return archiveMember{}, err
+		}
+	}
+	return member, nil
+}
+
+// writeArchive writes the members to a temporary file next to archive and
+// renames it into place once complete.
+func writeArchive(archive, format string, members []archiveMember, progress *cli.ProgressBar) error {
+	temp, err := os.CreateTemp(filepath.Dir(archive), "."+filepath.Base(archive)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+
+	if err := writeMembers(temp, format, members, progress); err != nil {
+		_ = temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	if progress != nil {
+		progress.Finish()
+	}
+	// #nosec G302 - archives are shared like the files tar and zip create
+	if err := os.Chmod(temp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), archive)
+}
+
+func writeMembers(w io.Writer, format string, members []archiveMember, progress *cli.ProgressBar) error {
+	aw, err := newArchiveWriter(w, format)
+	if err != nil {
+		return err
+	}
+	for _, member := range members {
+		if err := addMember(aw, member, progress); err != nil {
+			_ = aw.Close()
+			return err
+		}
+	}
+	return aw.Close()
+}
+
+func addMember(aw archiveWriter, member archiveMember, progress *cli.ProgressBar) error {
+	if !member.info.Mode().IsRegular() {
+		return aw.add(member, nil)
+	}
+	f, err := os.Open(member.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var body io.Reader = f
+	if progress != nil {
+		body = &progressReader{reader: f, bar: progress}
+	}
+	return aw.add(member, body)
+}
+
+func newArchiveWriter(w io.Writer, format string) (archiveWriter, error) {
+	switch format {
+	case ArchiveZip:
+		return &zipArchiveWriter{zw: zip.NewWriter(w)}, nil
+	case ArchiveTarGz:
+		gz := gzip.NewWriter(w)
+		return &tarArchiveWriter{tw: tar.NewWriter(gz), compressor: gz}, nil
+	case ArchiveTarZst:
+		zw, err := zstd.NewWriter(w)
+		if err != nil {
+			return nil, err
+		}
+		return &tarArchiveWriter{tw: tar.NewWriter(zw), compressor: zw}, nil
+	default:
+		return &tarArchiveWriter{tw: tar.NewWriter(w)}, nil
+	}
+}
+
+// tarArchiveWriter writes tar archives, compressed when compressor is set.
+type tarArchiveWriter struct {
+	tw         *tar.Writer
+	compressor io.WriteCloser
+}
+
+func (t *tarArchiveWriter) add(member archiveMember, body io.Reader) error {
+	header, err := tar.FileInfoHeader(member.info, member.link)
+	if err != nil {
+		return cli.WrapError(cli.KindGeneral, err, "cannot archive %s", member.path)
+	}
+	header.Name = member.name
+	if member.info.IsDir() {
+		header.Name += "/"
+	}
+	if err := t.tw.WriteHeader(header); err != nil {
+		return err
+	}
+	if body == nil {
+		return nil
+	}
+	_, err = io.Copy(t.tw, body)
+	return err
+}
+
+func (t *tarArchiveWriter) Close() error {
+	err := t.tw.Close()
+	if t.compressor != nil {
+		if closeErr := t.compressor.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// zipArchiveWriter writes zip archives, storing the targets of symbolic
+// links as their content as Info-ZIP does.
+type zipArchiveWriter struct {
+	zw *zip.Writer
+}
+
+func (z *zipArchiveWriter) add(member archiveMember, body io.Reader) error {
+	header, err := zip.FileInfoHeader(member.info)
+	if err != nil {
+		return cli.WrapError(cli.KindGeneral, err, "cannot archive %s", member.path)
+	}
+	header.Name = member.name
+	switch {
+	case member.info.IsDir():
+		header.Name += "/"
+	case member.info.Mode().IsRegular():
+		header.Method = zip.Deflate
+	case member.link != "":
+		body = strings.NewReader(member.link)
+	}
+	w, err := z.zw.CreateHeader(header)
+	if err != nil || body == nil {
+		return err
+	}
+	_, err = io.Copy(w, body)
+	return err
+}
+
+func (z *zipArchiveWriter) Close() error {
+	return z.zw.Close()
+}
@@ -0,0 +1,429 @@
+package filecmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nate3d/go-toolbox/internal/cli"
+	"github.com/nate3d/go-toolbox/internal/config"
+)
+
+// archiveExtractOptions are the flags of "file archive extract".
+type archiveExtractOptions struct {
+	filter    archiveFilter
+	strip     int
+	overwrite bool
+	maxSize   string
+}
+
+// extractedDir is a directory whose mode and time are set once its
+// contents are extracted.
+type extractedDir struct {
+	path     string
+	mode     fs.FileMode
+	modified time.Time
+}
+
+// extractor writes the members of an archive below a destination. All
+// files are created through an os.Root, so neither the archive nor links
+// already in the destination can place them outside it.
+type extractor struct {
+	cmd  *cli.BaseCommand
+	root *os.Root
+	dest string
+	// realDest is the destination with links resolved
+	realDest string
+	opts     *archiveExtractOptions
+	limit    int64
+
+	entries int
+	written int64
+	dirs    []extractedDir
+	created map[string]bool
+}
+
+func newArchiveExtractCommand(parent *cli.BaseCommand) *cobra.Command {
+	opts := &archiveExtractOptions{}
+	cmd := &cobra.Command{
+		Use:   "extract <archive> [dir]",
+		Short: "Extract an archive into a directory",
+		Long: `Extract an archive into a directory (default: the current one), which is
+created if needed. Permissions and modification times are restored.
+
+Members with absolute names or names that leave the directory with ..,
+and symbolic links pointing outside it, stop the extraction; files are
+never written through links that lead out of the directory. Existing
+files are kept unless --overwrite is given.
+
+The total size of the extracted files is capped by --max-size, which
+defaults to the file.max_file_size setting, so archive bombs are stopped
+early; 0 removes the cap. --strip-components drops leading directories
+from member names and skips members that have no more elements.`,
+		Example: `  toolbox file archive extract release.tar.gz /opt/app --strip-components 1
+  toolbox file archive extract site.zip public --include '*.html'
+  toolbox file archive extract dump.tar.zst --max-size 10GiB --overwrite`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !cmd.Flags().Changed("max-size") {
+				opts.maxSize = config.GetString("file.max_file_size")
+			}
+			dest := "."
+			if len(args) > 1 {
+				dest = args[1]
+			}
+			return runArchiveExtract(parent, args[0], dest, opts)
+		},
+	}
+
+	opts.filter.addFlags(cmd, "extract")
+	cmd.Flags().IntVar(&opts.strip, "strip-components", 0, "Leading path elements removed from member names")
+	cmd.Flags().BoolVar(&opts.overwrite, "overwrite", false, "Replace existing files")
+	cmd.Flags().StringVar(&opts.maxSize, "max-size", "", "Largest total size extracted, e.g. 2GB; 0 for no limit (default: file.max_file_size)")
+
+	return cmd
+}
+
+func runArchiveExtract(cmd *cli.BaseCommand, archive, dest string, opts *archiveExtractOptions) error {
+	if opts.strip < 0 {
+		return cli.UsageErrorf("--strip-components must not be negative")
+	}
+	if err := opts.filter.validate(); err != nil {
+		return err
+	}
+	limit, err := extractLimit(opts.maxSize)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(archive)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dest, 0o750); err != nil {
+		return err
+	}
+	root, err := os.OpenRoot(dest)
+	if err != nil {
+		return err
+	}
+	defer root.Close()
+
+	realDest, err := filepath.EvalSymlinks(dest)
+	if err != nil {
+		return err
+	}
+	if realDest, err = filepath.Abs(realDest); err != nil {
+		return err
+	}
+
+	x := &extractor{cmd: cmd, root: root, dest: dest, realDest: realDest, opts: opts, limit: limit, created: make(map[string]bool)}
+	err = readArchive(archive, newBytesProgress(info.Size(), "Extracting"), x.extract)
+	if dirErr := x.finishDirs(); err == nil {
+		err = dirErr
+	}
+	if err != nil {
+		return err
+	}
+
+	summary := archiveSummary{Archive: archive, Destination: dest, Entries: x.entries, Bytes: x.written}
+	if printed, err := cmd.PrintData(summary); printed {
+		return err
+	}
+	cmd.PrintSuccessf("Extracted %s to %s: %s", archive, dest, summary)
+	return nil
+}
+
+// extractLimit parses --max-size; an empty value means no limit.
+func extractLimit(maxSize string) (int64, error) {
+	if maxSize == "" {
+		return 0, nil
+	}
+	limit, err := cli.ParseSize(maxSize)
+	if err != nil {
+		return 0, cli.WrapError(cli.KindUsage, err, "invalid --max-size")
+	}
+	return limit, nil
+}
+
+// extract writes one member. It is an archiveVisitor.
+func (x *extractor) extract(entry ArchiveEntry, body io.Reader) error {
+	name, err := safeMemberName(entry.Name)
+	if err != nil {
+		return err
+	}
+	name, ok := stripComponents(name, x.opts.strip)
+	isDir := entry.mode.IsDir()
+	if !ok || !x.opts.filter.match(name, isDir) {
+		return nil
+	}
+
+	switch {
+	case isDir:
+		err = x.extractDir(name, entry)
+	case entry.Type == hardlinkType:
+		err = x.extractHardlink(name, entry)
+	case entry.mode&fs.ModeSymlink != 0:
+		err = x.extractSymlink(name, entry)
+	case entry.mode.IsRegular():
+		err = x.extractFile(name, entry, body)
+	default:
+		x.cmd.PrintWarnf("Skipped %s: cannot extract a %s", entry.Name, entry.Type)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	x.entries++
+	return nil
+}
+
+// safeMemberName cleans the name of a member and rejects names that are
+// absolute or leave the destination. Backslashes count as separators, as
+// zip archives made on Windows may use them.
+func safeMemberName(name string) (string, error) {
+	slashed := strings.ReplaceAll(name, `\`, "/")
+	if path.IsAbs(slashed) || filepath.VolumeName(name) != "" {
+		return "", unsafeMember(name, "absolute path")
+	}
+	cleaned := path.Clean(slashed)
+	if cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", unsafeMember(name, "path leaves the destination")
+	}
+	return cleaned, nil
+}
+
+func unsafeMember(name, reason string) error {
+	return cli.NewError(cli.KindGeneral, "refusing to extract %q: %s", name, reason)
+}
+
+// stripComponents removes the first n elements of name and reports
+// whether anything is left.
+func stripComponents(name string, n int) (string, bool) {
+	if name == "." {
+		return "", false
+	}
+	parts := strings.Split(name, "/")
+	if len(parts) <= n {
+		return "", false
+	}
+	return strings.Join(parts[n:], "/"), true
+}
+
+func (x *extractor) extractDir(name string, entry ArchiveEntry) error {
+	if err := x.mkdirAll(name); err != nil {
+		return err
+	}
+	x.dirs = append(x.dirs, extractedDir{path: name, mode: entry.mode.Perm(), modified: entry.Modified})
+	return nil
+}
+
+func (x *extractor) extractFile(name string, entry ArchiveEntry, body io.Reader) error {
+	if err := x.prepare(name); err != nil {
+		return err
+	}
+	f, err := x.root.OpenFile(filepath.FromSlash(name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, entry.mode.Perm())
+	if err != nil {
+		return err
+	}
+	if err := x.copyLimited(f, body); err != nil {
+		_ = f.Close()
+		_ = x.root.Remove(filepath.FromSlash(name))
+		return err
+	}
+	// The mode given to OpenFile is subject to the umask
+	if err := f.Chmod(entry.mode.Perm()); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return rootChtimes(x.root, filepath.FromSlash(name), entry.Modified)
+}
+
+// copyLimited copies body to w, failing once the total written exceeds
+// the limit.
+func (x *extractor) copyLimited(w io.Writer, body io.Reader) error {
+	if x.limit <= 0 {
+		n, err := io.Copy(w, body)
+		x.written += n
+		return err
+	}
+	remaining := x.limit - x.written
+	n, err := io.CopyN(w, body, remaining+1)
+	x.written += n
+	if n > remaining {
+		return cli.NewError(cli.KindGeneral, "archive expands beyond %s; nothing more was extracted", cli.FormatSize(x.limit)).
+			WithHint("raise the limit with --max-size or the file.max_file_size setting")
+	}
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	return err
+}
+
+func (x *extractor) extractSymlink(name string, entry ArchiveEntry) error {
+	target := strings.ReplaceAll(entry.Link, `\`, "/")
+	if path.IsAbs(target) || filepath.VolumeName(entry.Link) != "" {
+		return unsafeMember(entry.Name, "link to an absolute path")
+	}
+	if err := x.prepare(name); err != nil {
+		return err
+	}
+	// Links extracted earlier may lead the parent of name elsewhere, so
+	// the target is resolved on disk rather than by name
+	inside, err := x.within(path.Dir(name) + "/" + target)
+	if err != nil {
+		return err
+	}
+	if !inside {
+		return unsafeMember(entry.Name, "link points outside the destination")
+	}
+	return rootSymlink(x.root, entry.Link, filepath.FromSlash(name))
+}
+
+// within reports whether the slash-separated name, relative to the
+// destination, stays inside it when resolved on disk. Links are followed
+// as far as the path exists; below a missing directory .. is refused, as
+// a link created there later could change where it leads.
+func (x *extractor) within(name string) (bool, error) {
+	current, missing := x.realDest, false
+	for _, elem := range strings.Split(name, "/") {
+		switch {
+		case elem == "" || elem == ".":
+			continue
+		case elem == ".." && missing:
+			return false, nil
+		case elem == "..":
+			current = filepath.Dir(current)
+		case missing:
+			current = filepath.Join(current, elem)
+		default:
+			next := filepath.Join(current, elem)
+			resolved, err := filepath.EvalSymlinks(next)
+			switch {
+			case errors.Is(err, fs.ErrNotExist):
+				current, missing = next, true
+			case err != nil:
+				return false, err
+			default:
+				current = resolved
+			}
+		}
+		if rel, err := filepath.Rel(x.realDest, current); err != nil || !filepath.IsLocal(rel) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (x *extractor) extractHardlink(name string, entry ArchiveEntry) error {
+	target, err := safeMemberName(entry.Link)
+	if err != nil {
+		return err
+	}
+	target, ok := stripComponents(target, x.opts.strip)
+	if !ok {
+		return unsafeMember(entry.Name, "link target is stripped")
+	}
+	// The target must be a file of this extraction, reached within the root
+	info, err := x.root.Lstat(filepath.FromSlash(target))
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return unsafeMember(entry.Name, "link target is not a regular file")
+	}
+	if err := x.prepare(name); err != nil {
+		return err
+	}
+	return rootLink(x.root, filepath.FromSlash(target), filepath.FromSlash(name))
+}
+
+// prepare creates the parent directories of name and clears the way for
+// it: an existing file is removed with --overwrite and an error otherwise.
+func (x *extractor) prepare(name string) error {
+	if err := x.mkdirAll(path.Dir(name)); err != nil {
+		return err
+	}
+	native := filepath.FromSlash(name)
+	info, err := x.root.Lstat(native)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return nil
+	case err != nil:
+		return err
+	case info.IsDir():
+		return cli.NewError(cli.KindGeneral, "cannot extract %s: a directory is in the way", name)
+	case !x.opts.overwrite:
+		return cli.NewError(cli.KindGeneral, "%s already exists", x.path(name)).WithHint("pass --overwrite to replace existing files")
+	}
+	return x.root.Remove(native)
+}
+
+// mkdirAll creates a directory and its parents within the root.
+func (x *extractor) mkdirAll(name string) error {
+	if name == "." || x.created[name] {
+		return nil
+	}
+	if err := x.mkdirAll(path.Dir(name)); err != nil {
+		return err
+	}
+	native := filepath.FromSlash(name)
+	if err := x.root.Mkdir(native, 0o750); err != nil && !errors.Is(err, fs.ErrExist) {
+		return err
+	}
+	// Stat follows links only while they stay within the root
+	info, err := x.root.Stat(native)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return cli.NewError(cli.KindGeneral, "cannot extract into %s: not a directory", name)
+	}
+	x.created[name] = true
+	return nil
+}
+
+// finishDirs sets the mode and time of the extracted directories, deepest
+// first, since extracting their contents changed the times.
+func (x *extractor) finishDirs() error {
+	var errs []error
+	for i := len(x.dirs) - 1; i >= 0; i-- {
+		dir := x.dirs[i]
+		if err := x.chmod(dir.path, dir.mode); err != nil {
+			errs = append(errs, err)
+		}
+		if err := rootChtimes(x.root, filepath.FromSlash(dir.path), dir.modified); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("cannot restore directory metadata: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// chmod sets the mode of a member through a handle opened from the root.
+func (x *extractor) chmod(name string, mode fs.FileMode) error {
+	f, err := x.root.Open(filepath.FromSlash(name))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return f.Chmod(mode)
+}
+
+// path returns the location of a member name on disk.
+func (x *extractor) path(name string) string {
+	return filepath.Join(x.dest, filepath.FromSlash(name))
+}
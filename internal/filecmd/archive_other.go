@@ -0,0 +1,26 @@
+//go:build !unix
+
+package filecmd
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// rootSymlink creates the symbolic link name pointing at target below
+// root. Without *at system calls the parents of name are checked by
+// extractSymlink only.
+func rootSymlink(root *os.Root, target, name string) error {
+	return os.Symlink(target, filepath.Join(root.Name(), name))
+}
+
+// rootLink creates the hard link newname to oldname below root.
+func rootLink(root *os.Root, oldname, newname string) error {
+	return os.Link(filepath.Join(root.Name(), oldname), filepath.Join(root.Name(), newname))
+}
+
+// rootChtimes sets the access and modification times of name below root.
+func rootChtimes(root *os.Root, name string, modified time.Time) error {
+	return os.Chtimes(filepath.Join(root.Name(), name), modified, modified)
+}
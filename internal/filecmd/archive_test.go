@@ -0,0 +1,319 @@
+package filecmd
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nate3d/go-toolbox/internal/cli"
+)
+
+func newArchiveFixture(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"site/index.html":     "<h1>hi</h1>",
+		"site/css/style.css":  "body{}",
+		"site/debug.log":      "log",
+		"site/.cache/entries": "hidden",
+	})
+	if err := os.Chmod(filepath.Join(dir, "site/index.html"), 0o640); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := os.Chtimes(filepath.Join(dir, "site/index.html"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("index.html", filepath.Join(dir, "site/home.html")); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// archiveNames lists the member names of an archive.
+func archiveNames(t *testing.T, archive string) []string {
+	t.Helper()
+	var names []string
+	err := readArchive(archive, nil, func(entry ArchiveEntry, _ io.Reader) error {
+		names = append(names, entry.Name)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return names
+}
+
+func TestArchiveRoundTrip(t *testing.T) {
+	dir := newArchiveFixture(t)
+	for _, format := range archiveFormats {
+		t.Run(format, func(t *testing.T) {
+			archive := filepath.Join(t.TempDir(), "site."+format)
+			cmd, _ := newTestCommand(cli.OutputTable)
+			opts := &archiveCreateOptions{filter: archiveFilter{exclude: []string{"*.log"}}}
+			if err := runArchiveCreate(cmd, archive, []string{filepath.Join(dir, "site")}, opts); err != nil {
+				t.Fatal(err)
+			}
+			want := []string{"site/", "site/css/", "site/css/style.css", "site/home.html", "site/index.html"}
+			if got := archiveNames(t, archive); !reflect.DeepEqual(got, want) {
+				t.Errorf("members = %q, want %q", got, want)
+			}
+
+			dest := filepath.Join(t.TempDir(), "out")
+			if err := runArchiveExtract(cmd, archive, dest, &archiveExtractOptions{strip: 1}); err != nil {
+				t.Fatal(err)
+			}
+			info, err := os.Stat(filepath.Join(dest, "index.html"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Mode().Perm() != 0o640 || info.ModTime().Unix() != time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC).Unix() {
+				t.Errorf("index.html mode %v, modified %v", info.Mode(), info.ModTime())
+			}
+			if target, err := os.Readlink(filepath.Join(dest, "home.html")); err != nil || target != "index.html" {
+				t.Errorf("home.html -> %q, %v", target, err)
+			}
+			if data, err := os.ReadFile(filepath.Join(dest, "css/style.css")); err != nil || string(data) != "body{}" {
+				t.Errorf("style.css = %q, %v", data, err)
+			}
+		})
+	}
+}
+
+func TestArchiveCreateInclude(t *testing.T) {
+	dir := newArchiveFixture(t)
+	archive := filepath.Join(t.TempDir(), "site.tar")
+	cmd, _ := newTestCommand(cli.OutputTable)
+	opts := &archiveCreateOptions{
+		filter: archiveFilter{include: []string{"*.css", "*.log"}},
+		walk:   walkOptions{showHidden: true},
+	}
+	if err := runArchiveCreate(cmd, archive, []string{filepath.Join(dir, "site")}, opts); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"site/css/style.css", "site/debug.log"}
+	if got := archiveNames(t, archive); !reflect.DeepEqual(got, want) {
+		t.Errorf("members = %q, want %q", got, want)
+	}
+
+	if err := runArchiveCreate(cmd, "site.rar", []string{dir}, &archiveCreateOptions{}); cli.ExitCode(err) != cli.ExitUsage {
+		t.Errorf("unknown extension: error = %v, want a usage error", err)
+	}
+}
+
+type tarMember struct {
+	header  tar.Header
+	content string
+}
+
+func writeTestTar(t *testing.T, members ...tarMember) string {
+	t.Helper()
+	archive := filepath.Join(t.TempDir(), "test.tar")
+	f, err := os.Create(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	tw := tar.NewWriter(f)
+	for _, member := range members {
+		header := member.header
+		header.Size = int64(len(member.content))
+		if header.Mode == 0 {
+			header.Mode = 0o644
+		}
+		if err := tw.WriteHeader(&header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(member.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return archive
+}
+
+func TestArchiveExtractRejectsEscapes(t *testing.T) {
+	file := func(name string) tarMember {
+		return tarMember{header: tar.Header{Name: name, Typeflag: tar.TypeReg}, content: "x"}
+	}
+	symlink := func(name, target string) tarMember {
+		return tarMember{header: tar.Header{Name: name, Typeflag: tar.TypeSymlink, Linkname: target}}
+	}
+
+	outside := t.TempDir()
+	tests := map[string][]tarMember{
+		"dot dot":          {file("ok.txt"), file("a/../../evil.txt")},
+		"absolute":         {file("/tmp/evil.txt")},
+		"absolute link":    {symlink("passwd", "/etc/passwd")},
+		"escaping link":    {symlink("a/up", "../../..")},
+		"through link":     {symlink("out", outside), file("out/evil.txt")},
+		"link then file":   {symlink("up", ".."), file("up/evil.txt")},
+		"chained links":    {symlink("p/q", ".."), symlink("p/q/r", "../..")},
+		"missing parent":   {symlink("a/r", "z/../.."), symlink("a/z", "..")},
+		"hardlink outside": {{header: tar.Header{Name: "h", Typeflag: tar.TypeLink, Linkname: "../x"}}},
+	}
+	for name, members := range tests {
+		archive := writeTestTar(t, members...)
+		dest := filepath.Join(t.TempDir(), "dest")
+		cmd, _ := newTestCommand(cli.OutputTable)
+		if err := runArchiveExtract(cmd, archive, dest, &archiveExtractOptions{}); err == nil {
+			t.Errorf("%s: extraction succeeded", name)
+		}
+		if _, err := os.Stat(filepath.Join(filepath.Dir(dest), "evil.txt")); err == nil {
+			t.Errorf("%s: wrote outside the destination", name)
+		}
+	}
+	if entries, _ := os.ReadDir(outside); len(entries) > 0 {
+		t.Errorf("wrote through a link: %v", entries)
+	}
+
+	// A link already in the destination is not followed out of it
+	dest := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(dest, "out")); err != nil {
+		t.Fatal(err)
+	}
+	cmd, _ := newTestCommand(cli.OutputTable)
+	if err := runArchiveExtract(cmd, writeTestTar(t, file("out/evil.txt")), dest, &archiveExtractOptions{}); err == nil {
+		t.Error("extraction through an existing link succeeded")
+	}
+	if entries, _ := os.ReadDir(outside); len(entries) > 0 {
+		t.Errorf("wrote through an existing link: %v", entries)
+	}
+}
+
+func TestArchiveExtractZipSlip(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "slip.zip")
+	f, err := os.Create(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for _, name := range []string{"fine.txt", `..\..\evil.txt`} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = w.Write([]byte("x"))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	_ = f.Close()
+
+	dest := filepath.Join(t.TempDir(), "dest")
+	cmd, _ := newTestCommand(cli.OutputTable)
+	err = runArchiveExtract(cmd, archive, dest, &archiveExtractOptions{})
+	if err == nil || !strings.Contains(err.Error(), "refusing") {
+		t.Errorf("error = %v, want a refusal", err)
+	}
+}
+
+func TestArchiveExtractLimits(t *testing.T) {
+	archive := writeTestTar(t,
+		tarMember{header: tar.Header{Name: "small", Typeflag: tar.TypeReg}, content: "abc"},
+		tarMember{header: tar.Header{Name: "big", Typeflag: tar.TypeReg}, content: strings.Repeat("x", 2000)},
+	)
+	dest := t.TempDir()
+	cmd, _ := newTestCommand(cli.OutputTable)
+	if err := runArchiveExtract(cmd, archive, dest, &archiveExtractOptions{maxSize: "1KB"}); err == nil {
+		t.Fatal("extraction beyond --max-size succeeded")
+	}
+	if _, err := os.Stat(filepath.Join(dest, "big")); !os.IsNotExist(err) {
+		t.Errorf("partial file left behind: %v", err)
+	}
+
+	// small exists now
+	if err := runArchiveExtract(cmd, archive, dest, &archiveExtractOptions{maxSize: "0"}); err == nil {
+		t.Error("extraction over an existing file succeeded")
+	}
+	if err := runArchiveExtract(cmd, archive, dest, &archiveExtractOptions{maxSize: "0", overwrite: true}); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(filepath.Join(dest, "big")); err != nil || info.Size() != 2000 {
+		t.Errorf("big = %v, %v", info, err)
+	}
+}
+
+func TestStripComponents(t *testing.T) {
+	tests := []struct {
+		name string
+		n    int
+		want string
+		ok   bool
+	}{
+		{"a/b/c", 0, "a/b/c", true},
+		{"a/b/c", 2, "c", true},
+		{"a/b", 2, "", false},
+		{".", 0, "", false},
+	}
+	for _, tt := range tests {
+		if got, ok := stripComponents(tt.name, tt.n); got != tt.want || ok != tt.ok {
+			t.Errorf("stripComponents(%q, %d) = %q, %v", tt.name, tt.n, got, ok)
+		}
+	}
+}
+
+func TestArchiveExtractLinks(t *testing.T) {
+	archive := writeTestTar(t,
+		tarMember{header: tar.Header{Name: "lib/data", Typeflag: tar.TypeReg}, content: "data"},
+		tarMember{header: tar.Header{Name: "current", Typeflag: tar.TypeSymlink, Linkname: "lib"}},
+		tarMember{header: tar.Header{Name: "current/alias", Typeflag: tar.TypeSymlink, Linkname: "data"}},
+		tarMember{header: tar.Header{Name: "copy", Typeflag: tar.TypeLink, Linkname: "lib/data"}},
+	)
+	dest := t.TempDir()
+	cmd, _ := newTestCommand(cli.OutputTable)
+	if err := runArchiveExtract(cmd, archive, dest, &archiveExtractOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"lib/alias", "copy"} {
+		if data, err := os.ReadFile(filepath.Join(dest, name)); err != nil || string(data) != "data" {
+			t.Errorf("%s = %q, %v", name, data, err)
+		}
+	}
+}
+
+func TestExtractorMetadataStaysInRoot(t *testing.T) {
+	dest, outside := t.TempDir(), t.TempDir()
+	writeFiles(t, outside, map[string]string{"target": "x"})
+	before, err := os.Stat(filepath.Join(outside, "target"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// An extracted directory swapped for a link out of the destination
+	if err := os.Symlink(outside, filepath.Join(dest, "dir")); err != nil {
+		t.Fatal(err)
+	}
+	root, err := os.OpenRoot(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer root.Close()
+
+	x := &extractor{root: root, dest: dest}
+	x.dirs = append(x.dirs, extractedDir{path: "dir", mode: 0o711, modified: time.Unix(0, 0)})
+	if err := rootChtimes(root, filepath.Join("dir", "target"), time.Unix(0, 0)); err == nil {
+		t.Error("set times through a link out of the root")
+	}
+	if err := x.finishDirs(); err == nil {
+		t.Error("set directory metadata through a link out of the root")
+	}
+
+	after, err := os.Stat(filepath.Join(outside, "target"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !after.ModTime().Equal(before.ModTime()) {
+		t.Errorf("outside file modified %v, was %v", after.ModTime(), before.ModTime())
+	}
+	if info, err := os.Stat(outside); err != nil || info.Mode().Perm() == 0o711 {
+		t.Errorf("outside directory = %v, %v", info, err)
+	}
+}
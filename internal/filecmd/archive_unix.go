@@ -0,0 +1,65 @@
+//go:build unix
+
+package filecmd
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// rootSymlink creates the symbolic link name pointing at target. The
+// directory of name is opened through root, so links on the way cannot
+// lead outside it.
+func rootSymlink(root *os.Root, target, name string) error {
+	dir, err := root.Open(filepath.Dir(name))
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	// #nosec G115 - file descriptors fit in an int
+	if err := unix.Symlinkat(target, int(dir.Fd()), filepath.Base(name)); err != nil {
+		return &os.LinkError{Op: "symlink", Old: target, New: name, Err: err}
+	}
+	return nil
+}
+
+// rootLink creates the hard link newname to oldname, both opened through
+// root.
+func rootLink(root *os.Root, oldname, newname string) error {
+	oldDir, err := root.Open(filepath.Dir(oldname))
+	if err != nil {
+		return err
+	}
+	defer oldDir.Close()
+	newDir, err := root.Open(filepath.Dir(newname))
+	if err != nil {
+		return err
+	}
+	defer newDir.Close()
+	// #nosec G115 - file descriptors fit in an int
+	err = unix.Linkat(int(oldDir.Fd()), filepath.Base(oldname), int(newDir.Fd()), filepath.Base(newname), 0)
+	if err != nil {
+		return &os.LinkError{Op: "link", Old: oldname, New: newname, Err: err}
+	}
+	return nil
+}
+
+// rootChtimes sets the access and modification times of name, opening its
+// directory through root. A symbolic link at name is changed itself rather
+// than followed.
+func rootChtimes(root *os.Root, name string, modified time.Time) error {
+	dir, err := root.Open(filepath.Dir(name))
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	ts := []unix.Timespec{unix.NsecToTimespec(modified.UnixNano()), unix.NsecToTimespec(modified.UnixNano())}
+	// #nosec G115 - file descriptors fit in an int
+	if err := unix.UtimesNanoAt(int(dir.Fd()), filepath.Base(name), ts, unix.AT_SYMLINK_NOFOLLOW); err != nil {
+		return &os.PathError{Op: "chtimes", Path: name, Err: err}
+	}
+	return nil
+}
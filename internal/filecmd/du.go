@@ -0,0 +1,463 @@
+package filecmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/nate3d/go-toolbox/internal/cli"
+)
+
+// defaultTopEntries is how many directories, files and extensions are listed
+const defaultTopEntries = 10
+
+// noExtension groups files without an extension
+const noExtension = "(none)"
+
+// duOptions are the flags of "file du".
+type duOptions struct {
+	top         int
+	workers     int
+	apparent    bool
+	byExt       bool
+	interactive bool
+}
+
+// inodeKey identifies a file with several hard links.
+type inodeKey struct {
+	dev, ino uint64
+}
+
+// duNode is a file or directory of a disk usage walk. Directory sizes
+// include everything below them.
+type duNode struct {
+	name      string
+	path      string
+	dir       bool
+	apparent  int64
+	allocated int64
+	// files counts the files below a directory, or 1 for a file
+	files    int
+	children []*duNode
+	parent   *duNode
+	err      error
+	// link marks a further hard link to a file counted at another path
+	link bool
+}
+
+// size returns the apparent or allocated size of the node.
+func (n *duNode) size(apparent bool) int64 {
+	if apparent {
+		return n.apparent
+	}
+	return n.allocated
+}
+
+// DiskUsage is the report of "file du".
+type DiskUsage struct {
+	Path         string           `json:"path"                 yaml:"path"`
+	Apparent     int64            `json:"apparent"             yaml:"apparent"`
+	Allocated    int64            `json:"allocated"            yaml:"allocated"`
+	Files        int              `json:"files"                yaml:"files"`
+	Dirs         int              `json:"dirs"                 yaml:"dirs"`
+	Hardlinks    int              `json:"hardlinks"            yaml:"hardlinks"`
+	LargestDirs  []UsageEntry     `json:"largest_dirs"         yaml:"largest_dirs"`
+	LargestFiles []UsageEntry     `json:"largest_files"        yaml:"largest_files"`
+	Extensions   []ExtensionUsage `json:"extensions,omitempty" yaml:"extensions,omitempty"`
+	Errors       []string         `json:"errors,omitempty"     yaml:"errors,omitempty"`
+}
+
+// UsageEntry is the usage of one file or directory.
+type UsageEntry struct {
+	Path      string `json:"path"            yaml:"path"`
+	Apparent  int64  `json:"apparent"        yaml:"apparent"`
+	Allocated int64  `json:"allocated"       yaml:"allocated"`
+	Files     int    `json:"files,omitempty" yaml:"files,omitempty"`
+}
+
+// ExtensionUsage is the usage of all files with one extension.
+type ExtensionUsage struct {
+	Extension string `json:"extension" yaml:"extension"`
+	Files     int    `json:"files"     yaml:"files"`
+	Apparent  int64  `json:"apparent"  yaml:"apparent"`
+	Allocated int64  `json:"allocated" yaml:"allocated"`
+}
+
+func newDuCommand(parent *cli.BaseCommand) *cobra.Command {
+	opts := &duOptions{}
+	cmd := &cobra.Command{
+		Use:   "du [dir]",
+		Short: "Analyze disk usage",
+		Long: `Analyze the disk usage of a directory (default: the current one) and list
+its largest directories and files.
+
+Directories are read in parallel. Sizes are reported both as apparent (the
+file lengths) and allocated (the blocks on disk, which is what fills a
+disk); rankings use the allocated size unless --apparent is set. Files with
+several hard links are counted and listed once, under the first of their
+paths in sort order, and symbolic links are not followed.
+
+--by-ext adds the usage per file extension. --interactive opens a browser
+that moves into and out of directories and deletes marked entries after
+confirmation.`,
+		Example: `  toolbox file du
+  toolbox file du /var/lib/docker --top 20
+  toolbox file du ~/builds --by-ext --apparent
+  toolbox file du /tmp --interactive
+  toolbox file du . --output json`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			dir := "."
+			if len(args) > 0 {
+				dir = args[0]
+			}
+			return runFileDu(parent, dir, *opts)
+		},
+	}
+
+	cmd.Flags().IntVarP(&opts.top, "top", "n", defaultTopEntries, "Number of largest directories, files and extensions listed")
+	cmd.Flags().IntVarP(&opts.workers, "workers", "j", 0, "Directories read in parallel (default: number of CPUs)")
+	cmd.Flags().BoolVar(&opts.apparent, "apparent", false, "Rank by apparent size instead of allocated size")
+	cmd.Flags().BoolVar(&opts.byExt, "by-ext", false, "Group usage by file extension")
+	cmd.Flags().BoolVarP(&opts.interactive, "interactive", "i", false, "Browse the results and delete entries")
+
+	parent.AddWatchFlag(cmd)
+
+	return cmd
+}
+
+func runFileDu(cmd *cli.BaseCommand, dir string, opts duOptions) error {
+	if opts.top < 0 {
+		return cli.UsageErrorf("--top must not be negative")
+	}
+	if opts.interactive && cmd.Watch > 0 {
+		return cli.UsageErrorf("--interactive cannot be combined with --watch")
+	}
+	if opts.interactive && (cmd.NoInput || cmd.Output != cli.OutputTable || !isTerminal(os.Stdin) || !isTerminal(os.Stdout)) {
+		return cli.UsageErrorf("--interactive needs a terminal and table output").
+			WithHint("run it without --no-input, --output or redirection")
+	}
+
+	root, walker, err := walkUsage(dir, opts.workers)
+	if err != nil {
+		return err
+	}
+	if opts.interactive {
+		return browseUsage(cmd, root, opts.apparent)
+	}
+
+	report := summarizeUsage(root, walker, opts)
+	if printed, err := cmd.PrintData(report); printed {
+		return err
+	}
+	printUsage(cmd, report, opts)
+	return nil
+}
+
+func isTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd())) // #nosec G115 - file descriptors fit in int
+}
+
+// usageWalker reads directory trees in parallel.
+type usageWalker struct {
+	// slots bounds the goroutines reading directories
+	slots chan struct{}
+
+	mu sync.Mutex
+	// links holds the paths of each file with several hard links
+	links     map[inodeKey][]*duNode
+	hardlinks int
+	errs      []error
+}
+
+// walkUsage reads the tree below dir with up to workers directories read
+// at once.
+func walkUsage(dir string, workers int) (*duNode, *usageWalker, error) {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	walker := &usageWalker{slots: make(chan struct{}, workers), links: make(map[inodeKey][]*duNode)}
+	root := &duNode{name: dir, path: dir, dir: info.IsDir()}
+	if !root.dir {
+		walker.addFile(root, info)
+		return root, walker, nil
+	}
+	root.apparent = info.Size()
+	root.allocated, _, _ = fileUsage(info)
+	walker.walk(root)
+	walker.countLinks()
+	return root, walker, nil
+}
+
+// walk reads a directory, handing subdirectories to other goroutines while
+// slots are free, and totals the sizes once all of them are done.
+func (w *usageWalker) walk(node *duNode) {
+	entries, err := os.ReadDir(node.path)
+	if err != nil {
+		node.err = err
+		w.addError(err)
+		return
+	}
+
+	var wg sync.WaitGroup
+	node.children = make([]*duNode, 0, len(entries))
+	for _, entry := range entries {
+		child := &duNode{name: entry.Name(), path: filepath.Join(node.path, entry.Name()), parent: node}
+		node.children = append(node.children, child)
+		info, err := entry.Info()
+		if err != nil {
+			child.err = err
+			w.addError(err)
+			continue
+		}
+		if !entry.IsDir() {
+			w.addFile(child, info)
+			continue
+		}
+
+		child.dir = true
+		child.apparent = info.Size()
+		child.allocated, _, _ = fileUsage(info)
+		select {
+		case w.slots <- struct{}{}:
+			wg.Add(1)
+			go func() {
+				defer func() { <-w.slots; wg.Done() }()
+				w.walk(child)
+			}()
+		default:
+			w.walk(child)
+		}
+	}
+	wg.Wait()
+
+	for _, child := range node.children {
+		node.apparent += child.apparent
+		node.allocated += child.allocated
+		node.files += child.files
+	}
+	sortUsage(node.children, false)
+}
+
+// addFile records the sizes of a file and remembers files with several
+// hard links for countLinks.
+func (w *usageWalker) addFile(node *duNode, info os.FileInfo) {
+	node.files = 1
+	allocated, key, linked := fileUsage(info)
+	node.apparent, node.allocated = info.Size(), allocated
+	if linked {
+		w.mu.Lock()
+		w.links[key] = append(w.links[key], node)
+		w.mu.Unlock()
+	}
+}
+
+// countLinks credits each file with several hard links in the tree to its
+// lexically first path, whatever the order of the walk. The other links
+// keep no size and are left out of the rankings.
+func (w *usageWalker) countLinks() {
+	for _, nodes := range w.links {
+		sort.Slice(nodes, func(i, j int) bool { return nodes[i].path < nodes[j].path })
+		for _, node := range nodes[1:] {
+			w.hardlinks++
+			apparent, allocated := node.apparent, node.allocated
+			node.apparent, node.allocated, node.link = 0, 0, true
+			for parent := node.parent; parent != nil; parent = parent.parent {
+				parent.apparent -= apparent
+				parent.allocated -= allocated
+				sortUsage(parent.children, false)
+			}
+		}
+	}
+}
+
+func (w *usageWalker) addError(err error) {
+	w.mu.Lock()
+	w.errs = append(w.errs, err)
+	w.mu.Unlock()
+}
+
+// sortUsage orders nodes by size, largest first, then by name.
+func sortUsage(nodes []*duNode, apparent bool) {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		a, b := nodes[i].size(apparent), nodes[j].size(apparent)
+		if a != b {
+			return a > b
+		}
+		return nodes[i].name < nodes[j].name
+	})
+}
+
+// summarizeUsage builds the report of a walk.
+func summarizeUsage(root *duNode, walker *usageWalker, opts duOptions) DiskUsage {
+	report := DiskUsage{
+		Path:      root.path,
+		Apparent:  root.apparent,
+		Allocated: root.allocated,
+		Files:     root.files,
+		Hardlinks: walker.hardlinks,
+	}
+	for _, err := range walker.errs {
+		report.Errors = append(report.Errors, err.Error())
+	}
+
+	var dirs, files []*duNode
+	extensions := make(map[string]*ExtensionUsage)
+	var collect func(node *duNode)
+	collect = func(node *duNode) {
+		for _, child := range node.children {
+			switch {
+			case child.dir:
+				dirs = append(dirs, child)
+				collect(child)
+			case child.err == nil && !child.link:
+				files = append(files, child)
+				addExtension(extensions, child)
+			}
+		}
+	}
+	collect(root)
+
+	report.Dirs = len(dirs)
+	report.LargestDirs = topUsage(dirs, opts.top, opts.apparent)
+	report.LargestFiles = topUsage(files, opts.top, opts.apparent)
+	if opts.byExt {
+		report.Extensions = topExtensions(extensions, opts.top, opts.apparent)
+	}
+	return report
+}
+
+func addExtension(extensions map[string]*ExtensionUsage, node *duNode) {
+	ext := strings.ToLower(filepath.Ext(node.name))
+	if ext == "" || ext == node.name {
+		ext = noExtension
+	}
+	usage, ok := extensions[ext]
+	if !ok {
+		usage = &ExtensionUsage{Extension: ext}
+		extensions[ext] = usage
+	}
+	usage.Files++
+	usage.Apparent += node.apparent
+	usage.Allocated += node.allocated
+}
+
+// topUsage returns the n largest nodes.
+func topUsage(nodes []*duNode, n int, apparent bool) []UsageEntry {
+	sortUsage(nodes, apparent)
+	entries := make([]UsageEntry, 0, min(n, len(nodes)))
+	for _, node := range nodes[:min(n, len(nodes))] {
+		entry := UsageEntry{Path: node.path, Apparent: node.apparent, Allocated: node.allocated}
+		if node.dir {
+			entry.Files = node.files
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// topExtensions returns the n extensions using the most space.
+func topExtensions(extensions map[string]*ExtensionUsage, n int, apparent bool) []ExtensionUsage {
+	usages := make([]ExtensionUsage, 0, len(extensions))
+	for _, usage := range extensions {
+		usages = append(usages, *usage)
+	}
+	sort.Slice(usages, func(i, j int) bool {
+		a, b := usages[i].Allocated, usages[j].Allocated
+		if apparent {
+			a, b = usages[i].Apparent, usages[j].Apparent
+		}
+		if a != b {
+			return a > b
+		}
+		return usages[i].Extension < usages[j].Extension
+	})
+	return usages[:min(n, len(usages))]
+}
+
+// printUsage renders the report as a summary and tables.
+func printUsage(cmd *cli.BaseCommand, report DiskUsage, opts duOptions) {
+	cmd.PrintHeaderf("%s: %s allocated, %s apparent, %d files, %d directories", report.Path,
+		cli.FormatSize(report.Allocated), cli.FormatSize(report.Apparent), report.Files, report.Dirs)
+	if report.Hardlinks > 0 {
+		cmd.PrintInfof("%d additional hard links were counted once", report.Hardlinks)
+	}
+
+	printUsageTable(cmd, "Largest directories", report.LargestDirs, true)
+	printUsageTable(cmd, "Largest files", report.LargestFiles, false)
+	if opts.byExt && len(report.Extensions) > 0 {
+		cmd.PrintHeaderf("By extension")
+		table := cmd.NewTable([]string{"Extension", "Files", "Allocated", "Apparent"})
+		for _, ext := range report.Extensions {
+			table.AddRow(ext.Extension, strconv.Itoa(ext.Files), cli.FormatSize(ext.Allocated), cli.FormatSize(ext.Apparent))
+		}
+		table.Render()
+	}
+
+	if len(report.Errors) > 0 {
+		cmd.PrintWarnf("%d entries could not be read, so the totals are incomplete", len(report.Errors))
+		if cmd.Verbose {
+			for _, msg := range report.Errors {
+				cmd.PrintWarnf("  %s", msg)
+			}
+		}
+	}
+}
+
+func printUsageTable(cmd *cli.BaseCommand, title string, entries []UsageEntry, dirs bool) {
+	if len(entries) == 0 {
+		return
+	}
+	cmd.PrintHeaderf("%s", title)
+	headers := []string{"Allocated", "Apparent", "Path"}
+	if dirs {
+		headers = []string{"Allocated", "Apparent", "Files", "Path"}
+	}
+	table := cmd.NewTable(headers)
+	for _, entry := range entries {
+		row := []string{cli.FormatSize(entry.Allocated), cli.FormatSize(entry.Apparent)}
+		if dirs {
+			row = append(row, strconv.Itoa(entry.Files))
+		}
+		table.AddRow(append(row, entry.Path)...)
+	}
+	table.Render()
+}
+
+// removeUsage deletes the file or directory of node and takes its sizes
+// off its ancestors.
+func removeUsage(node *duNode, remove func(string) error) error {
+	if node.parent == nil {
+		return errors.New("the analyzed directory itself cannot be deleted")
+	}
+	if err := remove(node.path); err != nil {
+		return fmt.Errorf("delete %s: %w", node.path, err)
+	}
+	parent := node.parent
+	for i, child := range parent.children {
+		if child == node {
+			parent.children = append(parent.children[:i], parent.children[i+1:]...)
+			break
+		}
+	}
+	for p := parent; p != nil; p = p.parent {
+		p.apparent -= node.apparent
+		p.allocated -= node.allocated
+		p.files -= node.files
+	}
+	return nil
+}
@@ -0,0 +1,289 @@
+package filecmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/nate3d/go-toolbox/internal/cli"
+	"github.com/nate3d/go-toolbox/internal/theme"
+)
+
+const (
+	// browserChrome is the number of lines around the entry list
+	browserChrome = 8
+
+	// defaultBrowserHeight is used until the terminal reports its size
+	defaultBrowserHeight = 24
+
+	// usageBarWidth is the width of the bar showing an entry's share
+	usageBarWidth = 20
+
+	// percent scales shares for display
+	percent = 100
+)
+
+// duBrowser is the interactive view of "file du --interactive". It moves
+// into and out of directories and deletes marked entries after
+// confirmation.
+type duBrowser struct {
+	current    *duNode
+	cursor     int
+	offset     int
+	marked     map[*duNode]bool
+	confirming bool
+	apparent   bool
+	height     int
+	status     string
+
+	// deleted and freed sum up the deletions for the final message
+	deleted int
+	freed   int64
+	remove  func(string) error
+}
+
+func newDuBrowser(root *duNode, apparent bool) *duBrowser {
+	browser := &duBrowser{
+		current:  root,
+		marked:   make(map[*duNode]bool),
+		apparent: apparent,
+		height:   defaultBrowserHeight,
+		remove:   os.RemoveAll,
+	}
+	sortUsage(root.children, apparent)
+	return browser
+}
+
+// browseUsage runs the browser on a terminal.
+func browseUsage(cmd *cli.BaseCommand, root *duNode, apparent bool) error {
+	browser := newDuBrowser(root, apparent)
+	if _, err := tea.NewProgram(browser, tea.WithAltScreen()).Run(); err != nil {
+		return cli.WrapError(cli.KindGeneral, err, "disk usage browser failed")
+	}
+	if browser.deleted > 0 {
+		cmd.PrintSuccessf("Deleted %d %s, freeing %s", browser.deleted,
+			plural(browser.deleted, "entry", "entries"), cli.FormatSize(browser.freed))
+	}
+	return nil
+}
+
+// Init implements tea.Model.
+func (m *duBrowser) Init() tea.Cmd {
+	return nil
+}
+
+// Update implements tea.Model.
+func (m *duBrowser) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.height = msg.Height
+	case tea.KeyMsg:
+		if m.confirming {
+			m.confirmKey(msg.String())
+			return m, nil
+		}
+		return m, m.browseKey(msg.String())
+	}
+	return m, nil
+}
+
+func (m *duBrowser) browseKey(key string) tea.Cmd {
+	m.status = ""
+	switch key {
+	case "ctrl+c", "q":
+		return tea.Quit
+	case "up", "k":
+		m.move(-1)
+	case "down", "j":
+		m.move(1)
+	case "enter", "right", "l":
+		m.enter()
+	case "left", "h", "backspace", "esc":
+		m.leave()
+	case " ":
+		if entry := m.selected(); entry != nil {
+			m.marked[entry] = !m.marked[entry]
+			if !m.marked[entry] {
+				delete(m.marked, entry)
+			}
+			m.move(1)
+		}
+	case "d", "delete":
+		if len(m.marked) == 0 && m.selected() != nil {
+			m.marked[m.selected()] = true
+		}
+		m.confirming = len(m.marked) > 0
+	case "a":
+		m.apparent = !m.apparent
+		sortUsage(m.current.children, m.apparent)
+	}
+	return nil
+}
+
+func (m *duBrowser) confirmKey(key string) {
+	m.confirming = false
+	if key != "y" && key != "Y" {
+		m.status = "Deletion cancelled"
+		return
+	}
+	m.deleteMarked()
+}
+
+// selected returns the entry under the cursor, or nil in an empty directory.
+func (m *duBrowser) selected() *duNode {
+	if m.cursor >= len(m.current.children) {
+		return nil
+	}
+	return m.current.children[m.cursor]
+}
+
+func (m *duBrowser) move(delta int) {
+	m.cursor = max(0, min(m.cursor+delta, len(m.current.children)-1))
+}
+
+func (m *duBrowser) enter() {
+	entry := m.selected()
+	if entry == nil || !entry.dir || entry.err != nil {
+		return
+	}
+	m.current, m.cursor, m.offset = entry, 0, 0
+	sortUsage(m.current.children, m.apparent)
+}
+
+// leave goes to the parent directory with the cursor on the one left.
+func (m *duBrowser) leave() {
+	if m.current.parent == nil {
+		return
+	}
+	left := m.current
+	m.current, m.offset = left.parent, 0
+	sortUsage(m.current.children, m.apparent)
+	m.cursor = 0
+	for i, child := range m.current.children {
+		if child == left {
+			m.cursor = i
+		}
+	}
+}
+
+// deleteMarked removes the marked entries. Entries inside a marked
+// directory go with it.
+func (m *duBrowser) deleteMarked() {
+	var failures []string
+	removed := make(map[*duNode]bool)
+	for entry := range m.marked {
+		if hasMarkedAncestor(entry, m.marked) {
+			continue
+		}
+		if err := removeUsage(entry, m.remove); err != nil {
+			failures = append(failures, err.Error())
+			continue
+		}
+		removed[entry] = true
+		m.deleted++
+		m.freed += entry.size(m.apparent)
+	}
+	m.marked = make(map[*duNode]bool)
+
+	// Leave directories that no longer exist
+	for node := m.current; node != nil; node = node.parent {
+		if removed[node] {
+			m.current = node.parent
+		}
+	}
+	m.move(0)
+
+	m.status = fmt.Sprintf("Deleted %d %s", len(removed), plural(len(removed), "entry", "entries"))
+	if len(failures) > 0 {
+		m.status = strings.Join(failures, "; ")
+	}
+}
+
+func hasMarkedAncestor(node *duNode, marked map[*duNode]bool) bool {
+	for p := node.parent; p != nil; p = p.parent {
+		if marked[p] {
+			return true
+		}
+	}
+	return false
+}
+
+// View implements tea.Model.
+func (m *duBrowser) View() string {
+	styles := theme.Current().Styles()
+	sizeName := "allocated"
+	if m.apparent {
+		sizeName = "apparent"
+	}
+
+	var b strings.Builder
+	b.WriteString(styles.Title.Render("Disk usage") + " " + m.current.path + "\n")
+	b.WriteString(styles.Muted.Render(fmt.Sprintf("%s %s, %d files", cli.FormatSize(m.current.size(m.apparent)),
+		sizeName, m.current.files)) + "\n\n")
+
+	rows := max(1, m.height-browserChrome)
+	m.offset = max(min(m.offset, m.cursor), m.cursor-rows+1)
+	children := m.current.children
+	if len(children) == 0 {
+		b.WriteString(styles.Item.Render("(empty)") + "\n")
+	}
+	for i := m.offset; i < len(children) && i < m.offset+rows; i++ {
+		line := m.entryLine(children[i])
+		switch {
+		case i == m.cursor:
+			b.WriteString(styles.Selected.Render("> "+line) + "\n")
+		case children[i].err != nil:
+			b.WriteString(styles.Item.Inherit(styles.Error).Render(line) + "\n")
+		default:
+			b.WriteString(styles.Item.Render(line) + "\n")
+		}
+	}
+
+	b.WriteString("\n")
+	switch {
+	case m.confirming:
+		b.WriteString(styles.Error.Render(m.confirmQuestion()) + "\n")
+	case m.status != "":
+		b.WriteString(m.status + "\n")
+	default:
+		b.WriteString("\n")
+	}
+	b.WriteString(styles.Help.Render("↑/↓ move • enter open • ← back • space mark • d delete • a apparent/allocated • q quit"))
+	return b.String()
+}
+
+// entryLine shows an entry's mark, size, share of the directory and name.
+func (m *duBrowser) entryLine(entry *duNode) string {
+	mark := "[ ]"
+	if m.marked[entry] {
+		mark = "[x]"
+	}
+	share := 0.0
+	if total := m.current.size(m.apparent); total > 0 {
+		share = float64(entry.size(m.apparent)) / float64(total)
+	}
+	filled := int(share * usageBarWidth)
+	bar := strings.Repeat("█", filled) + strings.Repeat("░", usageBarWidth-filled)
+
+	name := entry.name
+	switch {
+	case entry.err != nil:
+		name += " (" + entry.err.Error() + ")"
+	case entry.dir:
+		name += "/"
+	}
+	return fmt.Sprintf("%s %10s %s %5.1f%% %s", mark, cli.FormatSize(entry.size(m.apparent)), bar, share*percent, name)
+}
+
+func (m *duBrowser) confirmQuestion() string {
+	var size int64
+	for entry := range m.marked {
+		if !hasMarkedAncestor(entry, m.marked) {
+			size += entry.size(m.apparent)
+		}
+	}
+	return fmt.Sprintf("Delete %d marked %s (%s)? [y/N]", len(m.marked),
+		plural(len(m.marked), "entry", "entries"), cli.FormatSize(size))
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package filecmd
+
+import "io/fs"
+
+// fileUsage reports the apparent size as allocated where the platform does
+// not expose block counts, and no hard link identity.
+func fileUsage(info fs.FileInfo) (int64, inodeKey, bool) {
+	return info.Size(), inodeKey{}, false
+}
@@ -0,0 +1,176 @@
+package filecmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/nate3d/go-toolbox/internal/cli"
+)
+
+func newUsageFixture(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"big/data.bin":       strings.Repeat("x", 4000),
+		"big/nested/log.txt": strings.Repeat("l", 1000),
+		"small/a.txt":        "aa",
+		"small/Makefile":     "all:\n",
+		"top.TXT":            strings.Repeat("t", 300),
+	})
+	return dir
+}
+
+func keys(m *duBrowser, keys ...string) {
+	for _, key := range keys {
+		msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+		switch key {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case "left":
+			msg = tea.KeyMsg{Type: tea.KeyLeft}
+		case " ":
+			msg = tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")}
+		}
+		m.Update(msg)
+	}
+}
+
+func TestWalkUsage(t *testing.T) {
+	dir := newUsageFixture(t)
+	if err := os.Link(filepath.Join(dir, "big", "data.bin"), filepath.Join(dir, "small", "copy.bin")); err != nil {
+		t.Skipf("hard links are not supported: %v", err)
+	}
+
+	root, walker, err := walkUsage(dir, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := summarizeUsage(root, walker, duOptions{top: 2, apparent: true, byExt: true})
+
+	if report.Files != 6 || report.Dirs != 3 || report.Hardlinks != 1 {
+		t.Errorf("report = %+v", report)
+	}
+	// The second link to data.bin adds nothing
+	var dirSizes int64
+	for _, node := range []string{"", "big", "big/nested", "small"} {
+		info, err := os.Stat(filepath.Join(dir, node))
+		if err != nil {
+			t.Fatal(err)
+		}
+		dirSizes += info.Size()
+	}
+	if want := dirSizes + 4000 + 1000 + 2 + 5 + 300; report.Apparent != want {
+		t.Errorf("apparent = %d, want %d", report.Apparent, want)
+	}
+	if report.Allocated <= 0 {
+		t.Errorf("allocated = %d", report.Allocated)
+	}
+
+	if len(report.LargestDirs) != 2 || report.LargestDirs[0].Path != filepath.Join(dir, "big") || report.LargestDirs[0].Files != 2 {
+		t.Errorf("largest dirs = %+v", report.LargestDirs)
+	}
+	if len(report.LargestFiles) != 2 || report.LargestFiles[0].Apparent != 4000 || report.LargestFiles[1].Apparent != 1000 {
+		t.Errorf("largest files = %+v", report.LargestFiles)
+	}
+	if len(report.Extensions) != 2 || report.Extensions[0].Extension != ".bin" || report.Extensions[1].Extension != ".txt" ||
+		report.Extensions[1].Files != 3 {
+		t.Errorf("extensions = %+v", report.Extensions)
+	}
+
+	// The size goes to the lexically first link; later ones are not listed
+	report = summarizeUsage(root, walker, duOptions{top: 10, apparent: true})
+	var files []string
+	for _, entry := range report.LargestFiles {
+		files = append(files, entry.Path)
+	}
+	if len(files) != 5 || files[0] != filepath.Join(dir, "big", "data.bin") || slices.Contains(files, filepath.Join(dir, "small", "copy.bin")) {
+		t.Errorf("largest files = %q", files)
+	}
+	small, err := os.Stat(filepath.Join(dir, "small"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range report.LargestDirs {
+		if entry.Path == filepath.Join(dir, "small") && entry.Apparent != small.Size()+2+5 {
+			t.Errorf("small = %+v, want the copy counted in big", entry)
+		}
+	}
+}
+
+func TestRunFileDu(t *testing.T) {
+	dir := newUsageFixture(t)
+
+	cmd, out := newTestCommand(cli.OutputJSON)
+	if err := runFileDu(cmd, dir, duOptions{top: 1}); err != nil {
+		t.Fatal(err)
+	}
+	var report DiskUsage
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("invalid JSON %q: %v", out.String(), err)
+	}
+	if report.Files != 5 || len(report.LargestFiles) != 1 || report.Extensions != nil {
+		t.Errorf("report = %+v", report)
+	}
+
+	cmd, _ = newTestCommand(cli.OutputTable)
+	if err := runFileDu(cmd, dir, duOptions{interactive: true}); cli.ExitCode(err) != cli.ExitUsage {
+		t.Errorf("interactive without a terminal: error = %v", err)
+	}
+	cmd.Watch = time.Second
+	if err := runFileDu(cmd, dir, duOptions{interactive: true}); err == nil || !strings.Contains(err.Error(), "--watch") {
+		t.Errorf("interactive with --watch: error = %v", err)
+	}
+	cmd.Watch = 0
+	if err := runFileDu(cmd, filepath.Join(dir, "missing"), duOptions{}); cli.ExitCode(err) != cli.ExitNotFound {
+		t.Errorf("missing dir: error = %v", err)
+	}
+}
+
+func TestDuBrowser(t *testing.T) {
+	dir := newUsageFixture(t)
+	root, _, err := walkUsage(dir, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var removed []string
+	browser := newDuBrowser(root, true)
+	browser.remove = func(path string) error {
+		removed = append(removed, path)
+		return nil
+	}
+
+	// Entries are sorted by size: big, small (with its own directory size), top.TXT
+	keys(browser, "enter")
+	if browser.current.name != "big" {
+		t.Fatalf("entered %q, want big", browser.current.name)
+	}
+	keys(browser, "left", "j")
+	if browser.current != root || browser.selected().name != "small" {
+		t.Fatalf("after going back, selected %q", browser.selected().name)
+	}
+
+	before := root.apparent
+	keys(browser, " ", "k", "d")
+	if !browser.confirming || !strings.Contains(browser.View(), "Delete 1 marked entry") {
+		t.Fatalf("no confirmation in view:\n%s", browser.View())
+	}
+	keys(browser, "n")
+	if len(removed) != 0 || browser.status != "Deletion cancelled" {
+		t.Fatalf("cancelled deletion removed %v", removed)
+	}
+
+	keys(browser, "d", "y")
+	if len(removed) != 1 || removed[0] != filepath.Join(dir, "small") {
+		t.Fatalf("removed %v", removed)
+	}
+	if len(root.children) != 2 || root.apparent != before-browser.freed || browser.deleted != 1 {
+		t.Errorf("after deletion: %d children, size %d of %d, freed %d", len(root.children), root.apparent, before, browser.freed)
+	}
+}
@@ -0,0 +1,26 @@
+//go:build unix
+
+package filecmd
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// blockSize is the unit of st_blocks
+const blockSize = 512
+
+// fileUsage returns the space allocated to a file and the identity of its
+// inode. The identity is only reported for files with several hard links,
+// which must be counted once.
+func fileUsage(info fs.FileInfo) (int64, inodeKey, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return info.Size(), inodeKey{}, false
+	}
+	allocated := int64(st.Blocks) * blockSize  //nolint:unconvert // Blocks is int32 on some platforms
+	if info.IsDir() || uint64(st.Nlink) <= 1 { //nolint:unconvert // Nlink is uint16 or uint32 on some platforms
+		return allocated, inodeKey{}, false
+	}
+	return allocated, inodeKey{dev: uint64(st.Dev), ino: uint64(st.Ino)}, true //nolint:unconvert,gosec // Dev is int32 on darwin
+}
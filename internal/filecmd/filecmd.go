@@ -0,0 +1,45 @@
+// Package filecmd implements the "file" command of the toolbox binaries.
+package filecmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/nate3d/go-toolbox/internal/cli"
+)
+
+// NewCommand creates the "file" command with its subcommands.
+func NewCommand() *cobra.Command {
+	baseCmd := cli.NewBaseCommand("file", "File operations and utilities")
+
+	baseCmd.AddCommand(newArchiveCommand(baseCmd))
+	baseCmd.AddCommand(newDuCommand(baseCmd))
+	baseCmd.AddCommand(newFindCommand(baseCmd))
+	baseCmd.AddCommand(newGrepCommand(baseCmd))
+	baseCmd.AddCommand(newHashCommand(baseCmd))
+	baseCmd.AddCommand(newInfoCommand(baseCmd))
+	baseCmd.AddCommand(newRenameCommand(baseCmd))
+	baseCmd.AddCommand(newSyncCommand(baseCmd))
+	baseCmd.AddCommand(newTreeCommand(baseCmd))
+
+	return baseCmd.Command
+}
+
+// inputFailures reports the errors of a command that processes several
+// inputs. With a single input its error is returned as is; otherwise each
+// failure is printed in table output and a summary error, with the kind of
+// the first failure, is returned. verb completes "could not be ...".
+func inputFailures(cmd *cli.BaseCommand, errs []error, total int, verb string) error {
+	switch {
+	case len(errs) == 0:
+		return nil
+	case total == 1:
+		return errs[0]
+	}
+
+	if cmd.Output == cli.OutputTable {
+		for _, err := range errs {
+			cli.ReportError(cmd.ErrOrStderr(), err, cli.OutputTable)
+		}
+	}
+	return cli.NewError(cli.AsError(errs[0]).Kind, "%d of %d inputs could not be %s", len(errs), total, verb)
+}
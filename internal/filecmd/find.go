@@ -0,0 +1,274 @@
+package filecmd
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nate3d/go-toolbox/internal/cli"
+)
+
+const (
+	// execPlaceholder is replaced by matched paths in --exec commands
+	execPlaceholder = "{}"
+
+	// execBatchSize bounds the paths passed to one --exec-batch command,
+	// keeping the command line well below the system limit
+	execBatchSize = 1000
+)
+
+// findOptions are the flags of "file find".
+type findOptions struct {
+	walk      walkOptions
+	expr      findExpr
+	exec      string
+	execBatch string
+	paths     bool
+	print0    bool
+}
+
+// FindResult is an entry matched by "file find".
+type FindResult struct {
+	Path     string    `json:"path"     yaml:"path"`
+	Type     string    `json:"type"     yaml:"type"`
+	Size     int64     `json:"size"     yaml:"size"`
+	Mode     string    `json:"mode"     yaml:"mode"`
+	Modified time.Time `json:"modified" yaml:"modified"`
+}
+
+func newFindCommand(parent *cli.BaseCommand) *cobra.Command {
+	opts := &findOptions{}
+	cmd := &cobra.Command{
+		Use:   "find [path]...",
+		Short: "Find files by name, type, size, age and more",
+		Long: `Find entries below the given paths (default: the current directory) that
+match an expression built from predicate flags.
+
+Predicates given one after another must all match. --or separates
+alternatives and --not negates the next predicate; --not binds tightest,
+then --and, then --or, as in find(1). Flags are evaluated in the order
+given.
+
+Ranges for --size, --mtime and --atime are written LOW..HIGH with either
+bound optional, or +LOW for "at least". Sizes use the units of size
+settings (10MB, 1.5GiB); ages use durations (90m, 7d, 2w). A bare size
+matches exactly and a bare age means "within".
+
+--exec runs a command for each match, replacing {} with its path (or
+appending the path when there is no {}). --exec-batch runs the command
+once with all matches in place of {}. Hidden entries are skipped unless
+--all is given or file.show_hidden is set.`,
+		Example: `  toolbox file find --name '*.go' --not --name '*_test.go'
+  toolbox file find /var/log --size 100MB.. --mtime 7d..
+  toolbox file find --type dir --empty
+  toolbox file find --name '*.tmp' --or --name '*.bak' --exec 'rm {}'
+  toolbox file find src --mime 'image/*' --exec-batch 'du -ch {}'
+  toolbox file find --newer go.mod --type file --output json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			applyWalkDefaults(cmd, &opts.walk)
+			if len(args) == 0 {
+				args = []string{"."}
+			}
+			return runFileFind(parent, args, opts)
+		},
+	}
+
+	opts.expr.addExprFlags(cmd.Flags())
+	addWalkFlags(cmd, &opts.walk)
+	cmd.Flags().StringVar(&opts.exec, "exec", "", "Run a command for each match, with {} replaced by its path")
+	cmd.Flags().StringVar(&opts.execBatch, "exec-batch", "", "Run a command once with all matches in place of {}")
+	cmd.Flags().BoolVar(&opts.paths, "paths", false, "Print only the matching paths, one per line")
+	cmd.Flags().BoolVar(&opts.print0, "print0", false, "Print only the matching paths, separated by NUL bytes")
+	cmd.MarkFlagsMutuallyExclusive("exec", "exec-batch", "paths", "print0")
+
+	parent.AddWatchFlag(cmd)
+
+	return cmd
+}
+
+func runFileFind(cmd *cli.BaseCommand, roots []string, opts *findOptions) error {
+	match, err := opts.expr.compile()
+	if err != nil {
+		return err
+	}
+	results, errs := findAll(cmd, roots, opts.walk, match)
+
+	switch {
+	case opts.exec != "":
+		err = execEach(cmd, opts.exec, results)
+	case opts.execBatch != "":
+		err = execBatch(cmd, opts.execBatch, results)
+	default:
+		err = printFindResults(cmd, results, opts)
+	}
+	if err != nil {
+		return err
+	}
+	return inputFailures(cmd, errs, len(roots), "searched")
+}
+
+// findAll walks the roots and returns the matching entries. Entries that
+// cannot be read are reported as warnings; roots that cannot be read are
+// returned as errors.
+func findAll(cmd *cli.BaseCommand, roots []string, walk walkOptions, match findPredicate) ([]FindResult, []error) {
+	var results []FindResult
+	var errs []error
+	for _, root := range roots {
+		err := walkTree(root, walk, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				if path == root {
+					return err
+				}
+				cmd.PrintWarnf("%v", err)
+				return nil
+			}
+			e := &findEntry{path: path, dirEntry: entry}
+			if match(e) {
+				results = append(results, newFindResult(e))
+			}
+			return nil
+		})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return results, errs
+}
+
+func newFindResult(e *findEntry) FindResult {
+	result := FindResult{Path: e.path}
+	if info := e.stat(); info != nil {
+		result.Type = fileType(info.Mode())
+		result.Size = info.Size()
+		result.Mode = symbolicMode(info.Mode())
+		result.Modified = info.ModTime()
+	}
+	return result
+}
+
+func printFindResults(cmd *cli.BaseCommand, results []FindResult, opts *findOptions) error {
+	if opts.paths || opts.print0 {
+		separator := "\n"
+		if opts.print0 {
+			separator = "\x00"
+		}
+		out := cmd.OutOrStdout()
+		for _, result := range results {
+			if _, err := fmt.Fprint(out, result.Path, separator); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if results == nil {
+		results = []FindResult{}
+	}
+	if printed, err := cmd.PrintData(results); printed {
+		return err
+	}
+	if len(results) == 0 {
+		cmd.PrintInfof("No matches")
+		return nil
+	}
+	table := cmd.NewTable([]string{"Mode", "Size", "Modified", "Path"})
+	for _, result := range results {
+		table.AddRow(result.Mode, cli.FormatSize(result.Size), result.Modified.Format(time.DateTime), result.Path)
+	}
+	table.Render()
+	return nil
+}
+
+// parseExecCommand splits an --exec or --exec-batch command into words.
+func parseExecCommand(command string) ([]string, error) {
+	words, err := cli.SplitArgs(command)
+	if err != nil {
+		return nil, cli.WrapError(cli.KindUsage, err, "invalid command")
+	}
+	if len(words) == 0 {
+		return nil, cli.UsageErrorf("the command to run is empty")
+	}
+	return words, nil
+}
+
+// expandCommand replaces {} in each word with each path. Without a {},
+// the paths are appended.
+func expandCommand(words, paths []string) []string {
+	argv := make([]string, 0, len(words)+len(paths))
+	placed := false
+	for _, word := range words {
+		if !strings.Contains(word, execPlaceholder) {
+			argv = append(argv, word)
+			continue
+		}
+		placed = true
+		for _, path := range paths {
+			argv = append(argv, strings.ReplaceAll(word, execPlaceholder, path))
+		}
+	}
+	if !placed {
+		argv = append(argv, paths...)
+	}
+	return argv
+}
+
+func execEach(cmd *cli.BaseCommand, command string, results []FindResult) error {
+	words, err := parseExecCommand(command)
+	if err != nil {
+		return err
+	}
+	failed := 0
+	for _, result := range results {
+		if err := runCommand(cmd, expandCommand(words, []string{result.Path})); err != nil {
+			cmd.PrintErrorf("%s: %v", result.Path, err)
+			failed++
+		}
+	}
+	return execFailures(failed, len(results))
+}
+
+func execBatch(cmd *cli.BaseCommand, command string, results []FindResult) error {
+	words, err := parseExecCommand(command)
+	if err != nil {
+		return err
+	}
+	paths := make([]string, len(results))
+	for i, result := range results {
+		paths[i] = result.Path
+	}
+
+	failed, runs := 0, 0
+	for start := 0; start < len(paths); start += execBatchSize {
+		runs++
+		batch := paths[start:min(start+execBatchSize, len(paths))]
+		if err := runCommand(cmd, expandCommand(words, batch)); err != nil {
+			cmd.PrintErrorf("%v", err)
+			failed++
+		}
+	}
+	return execFailures(failed, runs)
+}
+
+// runCommand runs argv with the command's output streams.
+func runCommand(cmd *cli.BaseCommand, argv []string) error {
+	run := exec.Command(argv[0], argv[1:]...) // #nosec G204 - running the user's command is the point
+	run.Stdin = os.Stdin
+	run.Stdout = cmd.OutOrStdout()
+	run.Stderr = cmd.ErrOrStderr()
+	return run.Run()
+}
+
+func execFailures(failed, total int) error {
+	if failed == 0 {
+		return nil
+	}
+	if total == 1 {
+		return cli.NewError(cli.KindGeneral, "the command failed")
+	}
+	return cli.NewError(cli.KindGeneral, "%d of %d commands failed", failed, total)
+}
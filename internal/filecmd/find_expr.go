@@ -0,0 +1,457 @@
+package filecmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/user"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"github.com/nate3d/go-toolbox/internal/cli"
+	"github.com/nate3d/go-toolbox/pkg/utils"
+)
+
+// Operators of a find expression
+const (
+	opAnd = "and"
+	opOr  = "or"
+	opNot = "not"
+)
+
+// rangeSeparator splits the bounds of --size, --mtime and --atime ranges
+const rangeSeparator = ".."
+
+// findPredicate tests one walked entry.
+type findPredicate func(entry *findEntry) bool
+
+// findEntry is a walked entry whose metadata is read on demand.
+type findEntry struct {
+	path     string
+	dirEntry fs.DirEntry
+	info     fs.FileInfo
+	statted  bool
+}
+
+// stat returns the metadata of the entry, or nil if it cannot be read.
+func (e *findEntry) stat() fs.FileInfo {
+	if !e.statted {
+		e.statted = true
+		e.info, _ = statEntry(e.path, e.dirEntry)
+	}
+	return e.info
+}
+
+// findToken is a predicate or an operator of a find expression.
+type findToken struct {
+	flag string
+	op   string
+	pred findPredicate
+}
+
+// findExpr collects predicates and operators in command-line order, as
+// pflag sets flag values in the order they are given.
+type findExpr struct {
+	tokens []findToken
+}
+
+// exprValue is a flag that adds a token to a findExpr each time it is
+// given. It is a pflag.SliceValue so that resetting flags between batch
+// and shell commands clears the expression.
+type exprValue struct {
+	expr     *findExpr
+	name     string
+	op       string
+	build    func(value string) (findPredicate, error)
+	typeName string
+}
+
+var _ pflag.SliceValue = (*exprValue)(nil)
+
+func (v *exprValue) Set(value string) error {
+	token := findToken{flag: "--" + v.name, op: v.op}
+	if v.build != nil {
+		pred, err := v.build(value)
+		if err != nil {
+			return err
+		}
+		token.pred = pred
+	} else if value != "true" {
+		return fmt.Errorf("--%s takes no value", v.name)
+	}
+	v.expr.tokens = append(v.expr.tokens, token)
+	return nil
+}
+
+func (v *exprValue) String() string { return "" }
+
+func (v *exprValue) Type() string { return v.typeName }
+
+func (v *exprValue) Append(value string) error { return v.Set(value) }
+
+func (v *exprValue) Replace([]string) error {
+	v.expr.tokens = nil
+	return nil
+}
+
+func (v *exprValue) GetSlice() []string { return nil }
+
+// addPredicate registers a predicate flag taking a value.
+func (e *findExpr) addPredicate(flags *pflag.FlagSet, name, valueName, usage string,
+	build func(string) (findPredicate, error),
+) {
+	flags.Var(&exprValue{expr: e, name: name, build: build, typeName: valueName}, name, usage)
+}
+
+// addSwitch registers a flag without a value, for an operator or a
+// predicate such as --empty.
+func (e *findExpr) addSwitch(flags *pflag.FlagSet, name, shorthand, op, usage string, pred findPredicate) {
+	value := &exprValue{expr: e, name: name, op: op, typeName: "bool"}
+	if pred != nil {
+		value.build = func(string) (findPredicate, error) { return pred, nil }
+	}
+	flags.VarPF(value, name, shorthand, usage).NoOptDefVal = "true"
+}
+
+// addExprFlags registers the predicates and operators of "file find".
+func (e *findExpr) addExprFlags(flags *pflag.FlagSet) {
+	e.addPredicate(flags, "name", "glob", "Base name matches a glob", nameGlob(false))
+	e.addPredicate(flags, "iname", "glob", "Base name matches a glob, ignoring case", nameGlob(true))
+	e.addPredicate(flags, "regex", "regexp", "Base name matches a regular expression", nameRegexp)
+	e.addPredicate(flags, "type", "type", "Entry type: file, dir or symlink", entryType)
+	e.addPredicate(flags, "size", "range", "Size range, e.g. 10MB.., ..1KiB, 1M..1G, +100k or 0", sizeRange)
+	e.addPredicate(flags, "mtime", "range", "Modified this long ago, e.g. 7d.. (older), ..1h (newer) or +30d",
+		ageRange(func(info fs.FileInfo) time.Time { return info.ModTime() }))
+	e.addPredicate(flags, "atime", "range", "Accessed this long ago, as for --mtime", ageRange(accessTime))
+	e.addPredicate(flags, "perm", "mode", "Permissions: 644 exactly, -644 all of these bits or /111 any of them",
+		permissions)
+	e.addPredicate(flags, "owner", "user", "Owned by a user name or ID", owner)
+	e.addPredicate(flags, "newer", "file", "Modified after the given file", newerThan)
+	e.addPredicate(flags, "mime", "glob", "Detected MIME type matches a glob, e.g. 'image/*'", mimeType)
+	e.addSwitch(flags, "empty", "", "", "Empty file or directory", isEmpty)
+	e.addSwitch(flags, "and", "", opAnd, "Both sides must match (the default between predicates)", nil)
+	e.addSwitch(flags, "or", "o", opOr, "Either side may match; binds weaker than --and", nil)
+	e.addSwitch(flags, "not", "", opNot, "Negate the next predicate", nil)
+}
+
+// compile builds the expression. --not binds tightest, then --and
+// (implied between adjacent predicates) and then --or. An empty
+// expression matches everything.
+func (e *findExpr) compile() (findPredicate, error) {
+	if len(e.tokens) == 0 {
+		return func(*findEntry) bool { return true }, nil
+	}
+	p := &exprParser{tokens: e.tokens}
+	pred, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, cli.UsageErrorf("unexpected %s", p.tokens[p.pos].flag)
+	}
+	return pred, nil
+}
+
+// exprParser is a recursive descent parser over find tokens.
+type exprParser struct {
+	tokens []findToken
+	pos    int
+}
+
+func (p *exprParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos].op
+	}
+	return ""
+}
+
+func (p *exprParser) or() (findPredicate, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.pos < len(p.tokens) && p.peek() == opOr {
+		p.pos++
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = orPredicate(left, right)
+	}
+	return left, nil
+}
+
+func (p *exprParser) and() (findPredicate, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.pos < len(p.tokens) && p.peek() != opOr {
+		if p.peek() == opAnd {
+			p.pos++
+		}
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		left = andPredicate(left, right)
+	}
+	return left, nil
+}
+
+func (p *exprParser) unary() (findPredicate, error) {
+	if p.pos >= len(p.tokens) {
+		last := p.tokens[len(p.tokens)-1]
+		return nil, cli.UsageErrorf("%s must be followed by a predicate", last.flag)
+	}
+	token := p.tokens[p.pos]
+	p.pos++
+	switch token.op {
+	case opNot:
+		inner, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return func(e *findEntry) bool { return !inner(e) }, nil
+	case opAnd, opOr:
+		return nil, cli.UsageErrorf("%s must follow a predicate", token.flag)
+	}
+	return token.pred, nil
+}
+
+func andPredicate(left, right findPredicate) findPredicate {
+	return func(e *findEntry) bool { return left(e) && right(e) }
+}
+
+func orPredicate(left, right findPredicate) findPredicate {
+	return func(e *findEntry) bool { return left(e) || right(e) }
+}
+
+func nameGlob(ignoreCase bool) func(string) (findPredicate, error) {
+	return func(pattern string) (findPredicate, error) {
+		if ignoreCase {
+			pattern = strings.ToLower(pattern)
+		}
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("%w: %q", err, pattern)
+		}
+		return func(e *findEntry) bool {
+			name := filepath.Base(e.path)
+			if ignoreCase {
+				name = strings.ToLower(name)
+			}
+			matched, _ := filepath.Match(pattern, name)
+			return matched
+		}, nil
+	}
+}
+
+func nameRegexp(expr string) (findPredicate, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	return func(e *findEntry) bool { return re.MatchString(filepath.Base(e.path)) }, nil
+}
+
+// entryTypes maps the --type values to file types
+var entryTypes = map[string]fs.FileMode{
+	"f": 0, "file": 0,
+	"d": fs.ModeDir, "dir": fs.ModeDir, "directory": fs.ModeDir,
+	"l": fs.ModeSymlink, "symlink": fs.ModeSymlink, "link": fs.ModeSymlink,
+}
+
+func entryType(value string) (findPredicate, error) {
+	want, ok := entryTypes[strings.ToLower(value)]
+	if !ok {
+		return nil, fmt.Errorf("unknown type %q, use file, dir or symlink", value)
+	}
+	return func(e *findEntry) bool {
+		if e.dirEntry != nil {
+			return e.dirEntry.Type().Type() == want
+		}
+		info := e.stat()
+		return info != nil && info.Mode().Type() == want
+	}, nil
+}
+
+// bounds is an inclusive range; a missing bound is unlimited.
+type bounds struct {
+	low, high       int64
+	hasLow, hasHigh bool
+}
+
+func (b bounds) contains(v int64) bool {
+	return (!b.hasLow || v >= b.low) && (!b.hasHigh || v <= b.high)
+}
+
+// parseBounds reads "LOW..HIGH", "LOW..", "..HIGH" or "+LOW". A bare value
+// is an exact match, or an upper bound when bareIsHigh is set.
+func parseBounds(value string, parse func(string) (int64, error), bareIsHigh bool) (bounds, error) {
+	var b bounds
+	low, high, isRange := strings.Cut(value, rangeSeparator)
+	switch {
+	case strings.HasPrefix(value, "+"):
+		low, high = value[1:], ""
+	case !isRange && bareIsHigh:
+		low, high = "", value
+	case !isRange:
+		high = low
+	}
+
+	var err error
+	if low != "" {
+		if b.low, err = parse(low); err != nil {
+			return b, err
+		}
+		b.hasLow = true
+	}
+	if high != "" {
+		if b.high, err = parse(high); err != nil {
+			return b, err
+		}
+		b.hasHigh = true
+	}
+	if !b.hasLow && !b.hasHigh {
+		return b, fmt.Errorf("empty range %q", value)
+	}
+	if b.hasLow && b.hasHigh && b.low > b.high {
+		return b, fmt.Errorf("range %q ends before it starts", value)
+	}
+	return b, nil
+}
+
+func sizeRange(value string) (findPredicate, error) {
+	b, err := parseBounds(value, cli.ParseSize, false)
+	if err != nil {
+		return nil, err
+	}
+	return func(e *findEntry) bool {
+		info := e.stat()
+		return info != nil && b.contains(info.Size())
+	}, nil
+}
+
+func ageRange(timeOf func(fs.FileInfo) time.Time) func(string) (findPredicate, error) {
+	return func(value string) (findPredicate, error) {
+		b, err := parseBounds(value, func(s string) (int64, error) {
+			d, err := cli.ParseDuration(s)
+			return int64(d), err
+		}, true)
+		if err != nil {
+			return nil, err
+		}
+		now := time.Now()
+		return func(e *findEntry) bool {
+			info := e.stat()
+			return info != nil && b.contains(int64(now.Sub(timeOf(info))))
+		}, nil
+	}
+}
+
+func permissions(value string) (findPredicate, error) {
+	match := func(bits, want uint32) bool { return bits == want }
+	mode := value
+	switch {
+	case strings.HasPrefix(value, "-"):
+		match = func(bits, want uint32) bool { return bits&want == want }
+		mode = value[1:]
+	case strings.HasPrefix(value, "/"):
+		match = func(bits, want uint32) bool { return bits&want != 0 }
+		mode = value[1:]
+	}
+	want, err := strconv.ParseUint(mode, 8, 12)
+	if err != nil {
+		return nil, fmt.Errorf("invalid octal mode %q", value)
+	}
+	return func(e *findEntry) bool {
+		info := e.stat()
+		return info != nil && match(permBits(info.Mode()), uint32(want))
+	}, nil
+}
+
+func owner(value string) (findPredicate, error) {
+	id := value
+	if _, err := strconv.ParseUint(value, 10, 32); err != nil {
+		u, err := user.Lookup(value)
+		if err != nil {
+			return nil, err
+		}
+		id = u.Uid
+	}
+	uid, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("user %q has no numeric ID", value)
+	}
+	return func(e *findEntry) bool {
+		info := e.stat()
+		if info == nil {
+			return false
+		}
+		got, ok := fileOwner(info)
+		return ok && uint64(got) == uid
+	}, nil
+}
+
+func newerThan(reference string) (findPredicate, error) {
+	info, err := os.Stat(reference)
+	if err != nil {
+		return nil, err
+	}
+	after := info.ModTime()
+	return func(e *findEntry) bool {
+		info := e.stat()
+		return info != nil && info.ModTime().After(after)
+	}, nil
+}
+
+func mimeType(pattern string) (findPredicate, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("%w: %q", err, pattern)
+	}
+	return func(e *findEntry) bool {
+		info := e.stat()
+		if info == nil || !info.Mode().IsRegular() {
+			return false
+		}
+		detection, err := utils.Detect().File(e.path)
+		if err != nil {
+			return false
+		}
+		full := detection.MIMEType
+		essence, _, _ := strings.Cut(full, ";")
+		matched, _ := path.Match(pattern, essence)
+		if !matched {
+			matched, _ = path.Match(pattern, full)
+		}
+		return matched
+	}, nil
+}
+
+// isEmpty matches empty regular files and directories without entries.
+func isEmpty(e *findEntry) bool {
+	info := e.stat()
+	switch {
+	case info == nil:
+		return false
+	case info.Mode().IsRegular():
+		return info.Size() == 0
+	case !info.IsDir():
+		return false
+	}
+	dir, err := os.Open(e.path) // #nosec G304 - walked directory
+	if err != nil {
+		return false
+	}
+	defer func() { _ = dir.Close() }()
+	_, err = dir.Readdirnames(1)
+	return errors.Is(err, io.EOF)
+}
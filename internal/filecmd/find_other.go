@@ -0,0 +1,18 @@
+//go:build !linux
+
+package filecmd
+
+import (
+	"io/fs"
+	"time"
+)
+
+// fileOwner is not available on this platform, so --owner matches nothing.
+func fileOwner(fs.FileInfo) (uint32, bool) {
+	return 0, false
+}
+
+// accessTime falls back to the modification time on this platform.
+func accessTime(info fs.FileInfo) time.Time {
+	return info.ModTime()
+}
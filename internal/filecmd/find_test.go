@@ -0,0 +1,224 @@
+package filecmd
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"github.com/nate3d/go-toolbox/internal/cli"
+)
+
+func newFindFixture(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"main.go":       "package main\n",
+		"main_test.go":  "package main\n",
+		"README.md":     strings.Repeat("r", 2000),
+		"empty.txt":     "",
+		"docs/guide.MD": "guide",
+		"logo.png":      "\x89PNG\r\n\x1a\n",
+		"old.log":       "old",
+		"run.sh":        "#!/bin/sh\n",
+	})
+	if err := os.Mkdir(filepath.Join(dir, "void"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(filepath.Join(dir, "run.sh"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-10 * cli.Day)
+	if err := os.Chtimes(filepath.Join(dir, "old.log"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// findNames parses args as find flags and returns the base names of the
+// matches below dir, sorted.
+func findNames(t *testing.T, dir string, args ...string) []string {
+	t.Helper()
+	opts := &findOptions{}
+	flags := pflag.NewFlagSet("find", pflag.ContinueOnError)
+	opts.expr.addExprFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		t.Fatalf("parse %q: %v", args, err)
+	}
+	match, err := opts.expr.compile()
+	if err != nil {
+		t.Fatalf("compile %q: %v", args, err)
+	}
+
+	cmd, _ := newTestCommand(cli.OutputTable)
+	results, errs := findAll(cmd, []string{dir}, walkOptions{}, match)
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	names := []string{}
+	for _, result := range results {
+		if result.Path != dir {
+			names = append(names, filepath.Base(result.Path))
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestFindPredicates(t *testing.T) {
+	dir := newFindFixture(t)
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"--name", "*.go"}, []string{"main.go", "main_test.go"}},
+		{[]string{"--name", "*.go", "--not", "--name", "*_test.go"}, []string{"main.go"}},
+		{[]string{"--iname", "*.md"}, []string{"README.md", "guide.MD"}},
+		{[]string{"--regex", `^main(_test)?\.go$`, "--and", "--size", "..100"}, []string{"main.go", "main_test.go"}},
+		{[]string{"--type", "d"}, []string{"docs", "void"}},
+		{[]string{"--type", "file", "--size", "1KB.."}, []string{"README.md"}},
+		{[]string{"--size", "+1k", "--not", "--type", "d"}, []string{"README.md"}},
+		{[]string{"--empty"}, []string{"empty.txt", "void"}},
+		{[]string{"--mtime", "7d.."}, []string{"old.log"}},
+		{[]string{"--type", "f", "--mtime", "+7d", "--or", "--name", "*.sh"}, []string{"old.log", "run.sh"}},
+		{[]string{"--not", "--type", "f", "--or", "--empty"}, []string{"docs", "empty.txt", "void"}},
+		{[]string{"--perm", "755", "--type", "f"}, []string{"run.sh"}},
+		{[]string{"--perm", "/111", "--type", "f"}, []string{"run.sh"}},
+		{[]string{"--newer", filepath.Join(dir, "old.log"), "--name", "*.log"}, []string{}},
+		{[]string{"--mime", "image/*"}, []string{"logo.png"}},
+		{[]string{"--mime", "text/x-shellscript"}, []string{"run.sh"}},
+	}
+	for _, tt := range tests {
+		if got := findNames(t, dir, tt.args...); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("find %q = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+
+	if runtime.GOOS == "linux" {
+		uid := strconv.Itoa(os.Getuid())
+		if got := findNames(t, dir, "--owner", uid, "--name", "main.go"); !reflect.DeepEqual(got, []string{"main.go"}) {
+			t.Errorf("--owner %s = %q", uid, got)
+		}
+	}
+}
+
+func TestFindExprErrors(t *testing.T) {
+	tests := [][]string{
+		{"--or", "--name", "a"},
+		{"--name", "a", "--or"},
+		{"--name", "a", "--not"},
+		{"--name", "a", "--and", "--or", "--name", "b"},
+	}
+	for _, args := range tests {
+		expr := &findExpr{}
+		flags := pflag.NewFlagSet("find", pflag.ContinueOnError)
+		expr.addExprFlags(flags)
+		if err := flags.Parse(args); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := expr.compile(); cli.ExitCode(err) != cli.ExitUsage {
+			t.Errorf("compile %q: error = %v, want a usage error", args, err)
+		}
+	}
+
+	for _, args := range [][]string{
+		{"--size", "1..x"}, {"--size", "2M..1M"}, {"--size", ".."}, {"--mtime", "soon"},
+		{"--type", "pipe"}, {"--perm", "999"}, {"--name", "[a"}, {"--newer", "/does/not/exist"},
+	} {
+		flags := pflag.NewFlagSet("find", pflag.ContinueOnError)
+		(&findExpr{}).addExprFlags(flags)
+		if err := flags.Parse(args); err == nil {
+			t.Errorf("parse %q succeeded", args)
+		}
+	}
+}
+
+func TestExprValueReset(t *testing.T) {
+	expr := &findExpr{}
+	flags := pflag.NewFlagSet("find", pflag.ContinueOnError)
+	expr.addExprFlags(flags)
+	if err := flags.Parse([]string{"--name", "a", "--empty"}); err != nil {
+		t.Fatal(err)
+	}
+	value, ok := flags.Lookup("name").Value.(pflag.SliceValue)
+	if !ok || len(expr.tokens) != 2 {
+		t.Fatalf("tokens = %d, slice value = %v", len(expr.tokens), ok)
+	}
+	if err := value.Replace(nil); err != nil || len(expr.tokens) != 0 {
+		t.Errorf("Replace left %d tokens, error %v", len(expr.tokens), err)
+	}
+}
+
+func TestExpandCommand(t *testing.T) {
+	tests := []struct {
+		words []string
+		paths []string
+		want  []string
+	}{
+		{[]string{"rm", "{}"}, []string{"a"}, []string{"rm", "a"}},
+		{[]string{"wc", "-l"}, []string{"a", "b"}, []string{"wc", "-l", "a", "b"}},
+		{[]string{"cp", "{}", "dest/"}, []string{"a", "b"}, []string{"cp", "a", "b", "dest/"}},
+		{[]string{"tool", "--file={}"}, []string{"a", "b"}, []string{"tool", "--file=a", "--file=b"}},
+	}
+	for _, tt := range tests {
+		if got := expandCommand(tt.words, tt.paths); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("expandCommand(%q, %q) = %q, want %q", tt.words, tt.paths, got, tt.want)
+		}
+	}
+}
+
+func TestRunFileFind(t *testing.T) {
+	dir := newFindFixture(t)
+	opts := &findOptions{}
+	flags := pflag.NewFlagSet("find", pflag.ContinueOnError)
+	opts.expr.addExprFlags(flags)
+	if err := flags.Parse([]string{"--name", "*.go"}); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd, out := newTestCommand(cli.OutputJSON)
+	if err := runFileFind(cmd, []string{dir}, opts); err != nil {
+		t.Fatal(err)
+	}
+	var results []FindResult
+	if err := json.Unmarshal(out.Bytes(), &results); err != nil {
+		t.Fatalf("invalid JSON %q: %v", out.String(), err)
+	}
+	if len(results) != 2 || results[0].Type != "file" || results[0].Size != 13 {
+		t.Errorf("results = %+v", results)
+	}
+
+	cmd, out = newTestCommand(cli.OutputTable)
+	opts.print0 = true
+	if err := runFileFind(cmd, []string{dir, filepath.Join(dir, "missing")}, opts); cli.ExitCode(err) != cli.ExitNotFound {
+		t.Errorf("missing root: error = %v", err)
+	}
+	if want := filepath.Join(dir, "main.go") + "\x00" + filepath.Join(dir, "main_test.go") + "\x00"; !strings.HasPrefix(out.String(), want) {
+		t.Errorf("print0 output = %q", out.String())
+	}
+
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no shell to run commands with")
+	}
+	cmd, out = newTestCommand(cli.OutputTable)
+	opts.print0 = false
+	opts.execBatch = `sh -c 'echo "$#"' sh {}`
+	if err := runFileFind(cmd, []string{dir}, opts); err != nil || strings.TrimSpace(out.String()) != "2" {
+		t.Errorf("--exec-batch printed %q, error %v", out.String(), err)
+	}
+
+	cmd, _ = newTestCommand(cli.OutputTable)
+	opts.execBatch, opts.exec = "", "false"
+	if err := runFileFind(cmd, []string{dir}, opts); err == nil || !strings.Contains(err.Error(), "2 of 2 commands failed") {
+		t.Errorf("failing --exec: error = %v", err)
+	}
+}
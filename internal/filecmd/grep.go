@@ -0,0 +1,552 @@
+package filecmd
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"unicode/utf16"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nate3d/go-toolbox/internal/cli"
+	"github.com/nate3d/go-toolbox/internal/theme"
+	"github.com/nate3d/go-toolbox/pkg/utils"
+)
+
+const (
+	// grepQueuePerWorker is the number of files queued per worker ahead of
+	// the one being printed
+	grepQueuePerWorker = 4
+
+	// Line types of GrepLine
+	grepMatch   = "match"
+	grepContext = "context"
+)
+
+// grepOptions are the flags of "file grep".
+type grepOptions struct {
+	walk             walkOptions
+	fixed            bool
+	ignoreCase       bool
+	word             bool
+	before           int
+	after            int
+	context          int
+	maxCount         int
+	filesWithMatches bool
+	binary           bool
+	workers          int
+}
+
+// GrepSpan is the byte range of a match within its line, counted from 0
+// with End exclusive.
+type GrepSpan struct {
+	Start int `json:"start" yaml:"start"`
+	End   int `json:"end"   yaml:"end"`
+}
+
+// GrepLine is a matching line, or a context line around one, found by
+// "file grep". Column is the 1-based byte column of the first match and
+// Offset the byte offset of the line in the file.
+type GrepLine struct {
+	Path    string     `json:"path"              yaml:"path"`
+	Type    string     `json:"type"              yaml:"type"`
+	Line    int        `json:"line"              yaml:"line"`
+	Column  int        `json:"column,omitempty"  yaml:"column,omitempty"`
+	Offset  int64      `json:"offset"            yaml:"offset"`
+	Text    string     `json:"text"              yaml:"text"`
+	Matches []GrepSpan `json:"matches,omitempty" yaml:"matches,omitempty"`
+}
+
+// grepResult is the outcome of searching one file.
+type grepResult struct {
+	path    string
+	lines   []GrepLine
+	matched bool
+	binary  bool
+	err     error
+}
+
+// grepJob is a file queued for searching. done is closed once result is set.
+type grepJob struct {
+	path   string
+	result grepResult
+	done   chan struct{}
+}
+
+func newGrepCommand(parent *cli.BaseCommand) *cobra.Command {
+	opts := &grepOptions{}
+	cmd := &cobra.Command{
+		Use:   "grep <pattern> [path]...",
+		Short: "Search file contents for a pattern",
+		Long: `Search the files below the given paths (default: the current directory) for
+lines matching a regular expression, in RE2 syntax, or a literal string
+with --fixed-strings.
+
+Files are searched in parallel and printed in path order. Binary files
+are skipped unless --binary is given; UTF-16 text is searched after
+conversion to UTF-8. Hidden entries are skipped unless --all is given or
+file.show_hidden is set.
+
+JSON and YAML output list matching and context lines with their line
+number, the 1-based column of the first match, the byte offset of the
+line and the byte range of each match within it.
+
+The command exits with status 1 when nothing matches, as grep(1) does.`,
+		Example: `  toolbox file grep TODO
+  toolbox file grep -i -w error /var/log --gitignore
+  toolbox file grep -F 'a.b(c)' src -C 2
+  toolbox file grep -l 'func main' --max-depth 3
+  toolbox file grep 'panic\(' --output json`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			applyWalkDefaults(cmd, &opts.walk)
+			if !cmd.Flags().Changed("before-context") {
+				opts.before = opts.context
+			}
+			if !cmd.Flags().Changed("after-context") {
+				opts.after = opts.context
+			}
+			roots := args[1:]
+			if len(roots) == 0 {
+				roots = []string{"."}
+			}
+			return runFileGrep(parent, args[0], roots, opts)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&opts.fixed, "fixed-strings", "F", false, "Match the pattern as a literal string")
+	cmd.Flags().BoolVarP(&opts.ignoreCase, "ignore-case", "i", false, "Match without regard to case")
+	cmd.Flags().BoolVarP(&opts.word, "word-regexp", "w", false, "Match whole words only")
+	cmd.Flags().IntVarP(&opts.before, "before-context", "B", 0, "Lines shown before each match")
+	cmd.Flags().IntVarP(&opts.after, "after-context", "A", 0, "Lines shown after each match")
+	cmd.Flags().IntVarP(&opts.context, "context", "C", 0, "Lines shown before and after each match")
+	cmd.Flags().IntVarP(&opts.maxCount, "max-count", "m", 0, "Stop searching a file after this many matching lines (0 for all)")
+	cmd.Flags().BoolVarP(&opts.filesWithMatches, "files-with-matches", "l", false, "Print only the paths of matching files")
+	cmd.Flags().BoolVar(&opts.binary, "binary", false, "Search binary files too")
+	cmd.Flags().IntVarP(&opts.workers, "workers", "j", 0, "Files searched in parallel (default: number of CPUs)")
+	addWalkFlags(cmd, &opts.walk)
+
+	parent.AddWatchFlag(cmd)
+
+	return cmd
+}
+
+func runFileGrep(cmd *cli.BaseCommand, pattern string, roots []string, opts *grepOptions) error {
+	if opts.workers < 0 || opts.before < 0 || opts.after < 0 || opts.maxCount < 0 {
+		return cli.UsageErrorf("--workers, --max-count and context lines must not be negative")
+	}
+	re, err := compileGrepPattern(pattern, opts)
+	if err != nil {
+		return err
+	}
+
+	printer := newGrepPrinter(cmd, roots, opts)
+	matched := false
+	errs, err := grepAll(roots, opts, re, func(result *grepResult) error {
+		switch {
+		case result.err != nil:
+			cmd.PrintWarnf("%v", result.err)
+			return nil
+		case result.binary:
+			if cmd.Verbose {
+				cmd.PrintInfof("Skipped binary file %s", result.path)
+			}
+			return nil
+		}
+		matched = matched || result.matched
+		return printer.print(result)
+	})
+	if err != nil {
+		return err
+	}
+	if err := printer.finish(); err != nil {
+		return err
+	}
+
+	if err := inputFailures(cmd, errs, len(roots), "searched"); err != nil {
+		return err
+	}
+	if !matched {
+		return &cli.ExitStatusError{Code: cli.ExitGeneral}
+	}
+	return nil
+}
+
+// compileGrepPattern turns the pattern and the matching flags into a
+// regular expression.
+func compileGrepPattern(pattern string, opts *grepOptions) (*regexp.Regexp, error) {
+	if opts.fixed {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if opts.word {
+		pattern = `\b(?:` + pattern + `)\b`
+	}
+	if opts.ignoreCase {
+		pattern = `(?i)` + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, cli.WrapError(cli.KindUsage, err, "invalid pattern")
+	}
+	return re, nil
+}
+
+// grepAll searches the files below roots with up to opts.workers files at
+// a time and calls emit with each result in walk order. Roots that cannot
+// be read are returned as errors; an error from emit ends the search and
+// is returned on its own.
+func grepAll(roots []string, opts *grepOptions, re *regexp.Regexp, emit func(*grepResult) error) ([]error, error) {
+	workers := opts.workers
+	if workers == 0 {
+		workers = runtime.NumCPU()
+	}
+	jobs := make(chan *grepJob)
+	ordered := make(chan *grepJob, workers*grepQueuePerWorker)
+	stop := make(chan struct{})
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				job.result = searchFile(job.path, re, opts)
+				close(job.done)
+			}
+		}()
+	}
+
+	var errs []error
+	go func() {
+		defer close(ordered)
+		defer close(jobs)
+		for _, root := range roots {
+			if err := walkGrepFiles(root, opts.walk, jobs, ordered, stop); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}()
+
+	var emitErr error
+	for job := range ordered {
+		<-job.done
+		if emitErr != nil {
+			continue
+		}
+		if emitErr = emit(&job.result); emitErr != nil {
+			close(stop)
+		}
+	}
+	wg.Wait()
+	return errs, emitErr
+}
+
+// walkGrepFiles queues the files below root for searching. Below the root
+// only regular files are searched; a root that is not a directory is
+// searched whatever its type, so links to files can be given.
+func walkGrepFiles(root string, walk walkOptions, jobs, ordered chan<- *grepJob, stop <-chan struct{}) error {
+	return walkTree(root, walk, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			// Reported in order with the results
+			job := &grepJob{path: path, result: grepResult{path: path, err: err}, done: make(chan struct{})}
+			close(job.done)
+			return queueGrepJob(job, nil, ordered, stop)
+		}
+		if entry.IsDir() || (path != root && !entry.Type().IsRegular()) {
+			return nil
+		}
+		return queueGrepJob(&grepJob{path: path, done: make(chan struct{})}, jobs, ordered, stop)
+	})
+}
+
+// queueGrepJob hands job to a worker, unless jobs is nil, and keeps its
+// place in the output order.
+func queueGrepJob(job *grepJob, jobs, ordered chan<- *grepJob, stop <-chan struct{}) error {
+	if jobs != nil {
+		select {
+		case jobs <- job:
+		case <-stop:
+			return filepath.SkipAll
+		}
+	}
+	select {
+	case ordered <- job:
+		return nil
+	case <-stop:
+		return filepath.SkipAll
+	}
+}
+
+// searchFile searches one file, skipping it when it is binary and
+// opts.binary is not set.
+func searchFile(path string, re *regexp.Regexp, opts *grepOptions) grepResult {
+	result := grepResult{path: path}
+	f, err := os.Open(path) // #nosec G304 - searching user-specified files is the point
+	if err != nil {
+		result.err = err
+		return result
+	}
+	defer f.Close()
+
+	reader := bufio.NewReaderSize(f, utils.SniffLength)
+	head, err := reader.Peek(utils.SniffLength)
+	if err != nil && !errors.Is(err, io.EOF) {
+		result.err = fmt.Errorf("%s: %w", path, err)
+		return result
+	}
+	detection := utils.Detect().Bytes(head)
+	if detection.Kind == "empty" {
+		return result
+	}
+	if !detection.IsText() && !opts.binary {
+		result.binary = true
+		return result
+	}
+
+	var input io.Reader = reader
+	if detection.Encoding == utils.EncodingUTF16LE || detection.Encoding == utils.EncodingUTF16BE {
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			result.err = fmt.Errorf("%s: %w", path, err)
+			return result
+		}
+		input = bytes.NewReader(decodeUTF16(data, detection.Encoding == utils.EncodingUTF16BE))
+	}
+
+	scanner := &grepScanner{path: path, re: re, opts: opts}
+	if err := scanner.scan(input); err != nil {
+		result.err = fmt.Errorf("%s: %w", path, err)
+	}
+	result.lines, result.matched = scanner.lines, scanner.matches > 0
+	return result
+}
+
+// decodeUTF16 converts UTF-16 text, with or without a byte order mark, to
+// UTF-8.
+func decodeUTF16(data []byte, bigEndian bool) []byte {
+	units := make([]uint16, 0, len(data)/2)
+	for i := 0; i+1 < len(data); i += 2 {
+		if bigEndian {
+			units = append(units, uint16(data[i])<<8|uint16(data[i+1]))
+		} else {
+			units = append(units, uint16(data[i+1])<<8|uint16(data[i]))
+		}
+	}
+	text := string(utf16.Decode(units))
+	return []byte(strings.TrimPrefix(text, "\ufeff"))
+}
+
+// grepScanner collects the matching lines of a file with their context.
+type grepScanner struct {
+	path string
+	re   *regexp.Regexp
+	opts *grepOptions
+
+	lines   []GrepLine
+	matches int
+	// pending holds the lines that may precede the next match
+	pending []GrepLine
+	// afterLeft counts the context lines still due after the last match
+	afterLeft int
+}
+
+// scan reads input line by line until it ends, --max-count is reached or,
+// with --files-with-matches, the first match.
+func (s *grepScanner) scan(input io.Reader) error {
+	reader := bufio.NewReader(input)
+	var offset int64
+	for number := 1; ; number++ {
+		raw, err := reader.ReadBytes('\n')
+		if len(raw) > 0 {
+			if !s.line(number, offset, bytes.TrimRight(raw, "\r\n")) {
+				return nil
+			}
+			offset += int64(len(raw))
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// line handles one line and reports whether scanning should go on.
+func (s *grepScanner) line(number int, offset int64, text []byte) bool {
+	done := s.opts.maxCount > 0 && s.matches >= s.opts.maxCount
+	if done {
+		// Only the context after the last counted match is left
+		if s.afterLeft == 0 {
+			return false
+		}
+		s.afterLeft--
+		s.lines = append(s.lines, GrepLine{Path: s.path, Type: grepContext, Line: number, Offset: offset, Text: string(text)})
+		return s.afterLeft > 0
+	}
+
+	spans := s.re.FindAllIndex(text, -1)
+	if spans == nil {
+		s.context(GrepLine{Path: s.path, Type: grepContext, Line: number, Offset: offset, Text: string(text)})
+		return true
+	}
+	s.matches++
+	if s.opts.filesWithMatches {
+		return false
+	}
+
+	match := GrepLine{Path: s.path, Type: grepMatch, Line: number, Column: spans[0][0] + 1, Offset: offset, Text: string(text)}
+	for _, span := range spans {
+		match.Matches = append(match.Matches, GrepSpan{Start: span[0], End: span[1]})
+	}
+	s.lines = append(s.lines, s.pending...)
+	s.lines = append(s.lines, match)
+	s.pending = s.pending[:0]
+	s.afterLeft = s.opts.after
+	return s.afterLeft > 0 || s.opts.maxCount == 0 || s.matches < s.opts.maxCount
+}
+
+// context keeps a non-matching line when it follows a match closely
+// enough, or as a candidate for the context before the next one.
+func (s *grepScanner) context(line GrepLine) {
+	if s.afterLeft > 0 {
+		s.afterLeft--
+		s.lines = append(s.lines, line)
+		return
+	}
+	if s.opts.before == 0 {
+		return
+	}
+	if len(s.pending) == s.opts.before {
+		s.pending = append(s.pending[:0], s.pending[1:]...)
+	}
+	s.pending = append(s.pending, line)
+}
+
+// grepPrinter writes results as they arrive in table output, and collects
+// them for structured output.
+type grepPrinter struct {
+	cmd      *cli.BaseCommand
+	opts     *grepOptions
+	showPath bool
+
+	lines []GrepLine
+	paths []string
+	// last is the previous line printed, to separate context groups
+	last *GrepLine
+}
+
+func newGrepPrinter(cmd *cli.BaseCommand, roots []string, opts *grepOptions) *grepPrinter {
+	showPath := true
+	if len(roots) == 1 {
+		if info, err := os.Stat(roots[0]); err == nil && !info.IsDir() {
+			showPath = false
+		}
+	}
+	return &grepPrinter{cmd: cmd, opts: opts, showPath: showPath}
+}
+
+func (p *grepPrinter) structured() bool {
+	return p.cmd.Output != cli.OutputTable
+}
+
+func (p *grepPrinter) print(result *grepResult) error {
+	if !result.matched {
+		return nil
+	}
+	if p.opts.filesWithMatches {
+		if p.structured() {
+			p.paths = append(p.paths, result.path)
+			return nil
+		}
+		_, err := fmt.Fprintln(p.cmd.OutOrStdout(), theme.Current().Sprint(theme.Accent, result.path))
+		return err
+	}
+	if p.structured() {
+		p.lines = append(p.lines, result.lines...)
+		return nil
+	}
+
+	out := p.cmd.OutOrStdout()
+	for i := range result.lines {
+		line := &result.lines[i]
+		if p.separated(line) {
+			if _, err := fmt.Fprintln(out, theme.Current().Sprint(theme.Muted, "--")); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintln(out, p.format(line)); err != nil {
+			return err
+		}
+		p.last = line
+	}
+	return nil
+}
+
+// separated reports whether a "--" goes before line, as grep(1) prints
+// between groups of lines that are not adjacent when context is shown.
+func (p *grepPrinter) separated(line *GrepLine) bool {
+	if p.last == nil || (p.opts.before == 0 && p.opts.after == 0) {
+		return false
+	}
+	return p.last.Path != line.Path || p.last.Line+1 != line.Line
+}
+
+// format renders a line as path:line:text, with - instead of : for
+// context lines and the matches highlighted.
+func (p *grepPrinter) format(line *GrepLine) string {
+	palette := theme.Current()
+	separator := ":"
+	if line.Type == grepContext {
+		separator = "-"
+	}
+
+	var b strings.Builder
+	if p.showPath {
+		b.WriteString(palette.Sprint(theme.Accent, line.Path) + separator)
+	}
+	b.WriteString(palette.Sprint(theme.Muted, fmt.Sprint(line.Line)) + separator)
+
+	end := 0
+	for _, span := range line.Matches {
+		if span.Start == span.End {
+			continue
+		}
+		b.WriteString(line.Text[end:span.Start])
+		b.WriteString(palette.Sprint(theme.Highlight, line.Text[span.Start:span.End]))
+		end = span.End
+	}
+	b.WriteString(line.Text[end:])
+	return b.String()
+}
+
+// finish prints the collected results in structured output.
+func (p *grepPrinter) finish() error {
+	if !p.structured() {
+		return nil
+	}
+	var data any = p.lines
+	switch {
+	case p.opts.filesWithMatches && p.paths == nil:
+		data = []string{}
+	case p.opts.filesWithMatches:
+		data = p.paths
+	case p.lines == nil:
+		data = []GrepLine{}
+	}
+	_, err := p.cmd.PrintData(data)
+	return err
+}
@@ -0,0 +1,135 @@
+package filecmd
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nate3d/go-toolbox/internal/cli"
+)
+
+func TestCompileGrepPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		opts    grepOptions
+		line    string
+		want    bool
+	}{
+		{"a.c", grepOptions{}, "abc", true},
+		{"a.c", grepOptions{fixed: true}, "abc", false},
+		{"a.c", grepOptions{fixed: true}, "xa.cx", true},
+		{"err", grepOptions{word: true}, "errors", false},
+		{"err", grepOptions{word: true}, "an err here", true},
+		{"ERR", grepOptions{ignoreCase: true}, "err", true},
+		{"a|b", grepOptions{word: true}, "a", true},
+	}
+	for _, tt := range tests {
+		re, err := compileGrepPattern(tt.pattern, &tt.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := re.MatchString(tt.line); got != tt.want {
+			t.Errorf("%q %+v on %q = %v, want %v", tt.pattern, tt.opts, tt.line, got, tt.want)
+		}
+	}
+
+	if _, err := compileGrepPattern("(", &grepOptions{}); cli.ExitCode(err) != cli.ExitUsage {
+		t.Errorf("invalid pattern: error = %v, want a usage error", err)
+	}
+}
+
+func TestGrepScannerContext(t *testing.T) {
+	input := "one\ntwo\nmatch 1\nthree\nfour\nfive\nsix\nmatch 2\nseven\n"
+	lineNumbers := func(opts grepOptions) []int {
+		re, _ := compileGrepPattern("match", &opts)
+		scanner := &grepScanner{path: "f", re: re, opts: &opts}
+		if err := scanner.scan(strings.NewReader(input)); err != nil {
+			t.Fatal(err)
+		}
+		numbers := []int{}
+		for _, line := range scanner.lines {
+			numbers = append(numbers, line.Line)
+		}
+		return numbers
+	}
+
+	tests := []struct {
+		opts grepOptions
+		want []int
+	}{
+		{grepOptions{}, []int{3, 8}},
+		{grepOptions{before: 1, after: 1}, []int{2, 3, 4, 7, 8, 9}},
+		{grepOptions{before: 3}, []int{1, 2, 3, 5, 6, 7, 8}},
+		{grepOptions{after: 5}, []int{3, 4, 5, 6, 7, 8, 9}},
+		{grepOptions{maxCount: 1}, []int{3}},
+		{grepOptions{maxCount: 1, after: 2}, []int{3, 4, 5}},
+		{grepOptions{filesWithMatches: true}, []int{}},
+	}
+	for _, tt := range tests {
+		if got := lineNumbers(tt.opts); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%+v: lines %v, want %v", tt.opts, got, tt.want)
+		}
+	}
+}
+
+func TestRunFileGrep(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.txt":       "alpha\r\nbeta gamma beta\n",
+		"b/c.txt":     "nothing\n",
+		"b/d.txt":     "beta\n",
+		"bin.dat":     "beta\x00\x01\x02",
+		".hidden.txt": "beta\n",
+		"utf16.txt":   "\xff\xfeb\x00e\x00t\x00a\x00\n\x00",
+	})
+
+	cmd, out := newTestCommand(cli.OutputJSON)
+	if err := runFileGrep(cmd, "beta", []string{dir}, &grepOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	var lines []GrepLine
+	if err := json.Unmarshal(out.Bytes(), &lines); err != nil {
+		t.Fatalf("invalid JSON %q: %v", out.String(), err)
+	}
+	if len(lines) != 3 {
+		t.Fatalf("lines = %+v", lines)
+	}
+	want := GrepLine{
+		Path: filepath.Join(dir, "a.txt"), Type: grepMatch, Line: 2, Column: 1, Offset: 7,
+		Text: "beta gamma beta", Matches: []GrepSpan{{0, 4}, {11, 15}},
+	}
+	if !reflect.DeepEqual(lines[0], want) {
+		t.Errorf("first line = %+v, want %+v", lines[0], want)
+	}
+	if lines[1].Path != filepath.Join(dir, "b", "d.txt") || lines[2].Path != filepath.Join(dir, "utf16.txt") {
+		t.Errorf("paths = %q, %q", lines[1].Path, lines[2].Path)
+	}
+
+	cmd, out = newTestCommand(cli.OutputTable)
+	opts := &grepOptions{filesWithMatches: true, binary: true, walk: walkOptions{showHidden: true}}
+	if err := runFileGrep(cmd, "beta", []string{dir}, opts); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(out.String(), "\n"); got != 5 {
+		t.Errorf("files with matches = %q", out.String())
+	}
+
+	cmd, out = newTestCommand(cli.OutputTable)
+	if err := runFileGrep(cmd, "gamma", []string{filepath.Join(dir, "a.txt")}, &grepOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); got != "2:beta gamma beta\n" {
+		t.Errorf("single file output = %q", got)
+	}
+
+	cmd, _ = newTestCommand(cli.OutputTable)
+	if err := runFileGrep(cmd, "omega", []string{dir}, &grepOptions{}); cli.ExitCode(err) != cli.ExitGeneral {
+		t.Errorf("no match: error = %v, want exit status 1", err)
+	}
+	cmd, _ = newTestCommand(cli.OutputTable)
+	if err := runFileGrep(cmd, "beta", []string{filepath.Join(dir, "missing")}, &grepOptions{}); cli.ExitCode(err) != cli.ExitNotFound {
+		t.Errorf("missing path: error = %v, want not found", err)
+	}
+}
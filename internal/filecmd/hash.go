@@ -0,0 +1,470 @@
+package filecmd
+
+import (
+	"crypto/sha1" // #nosec G505 - SHA-1 is offered for checksum compatibility, not security
+	"crypto/sha256"
+	"crypto/sha3"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/nate3d/go-toolbox/internal/cli"
+)
+
+const (
+	// defaultHashAlgorithm is used when --algo is not given
+	defaultHashAlgorithm = "sha256"
+
+	// progressThreshold is the total input size from which file commands
+	// show progress
+	progressThreshold = 64 << 20
+
+	// copyBufferSize is the read size for hashing; large reads help on network mounts
+	copyBufferSize = 1 << 20
+
+	// stdinPath names standard input, as in sha256sum
+	stdinPath = "-"
+)
+
+// hashAlgorithms are the digests offered by --algo. MD5 is not offered.
+var hashAlgorithms = map[string]func() hash.Hash{
+	"sha1":       sha1.New, // #nosec G401 - SHA-1 is offered for checksum compatibility, not security
+	"sha224":     sha256.New224,
+	"sha256":     sha256.New,
+	"sha384":     sha512.New384,
+	"sha512":     sha512.New,
+	"sha512-256": sha512.New512_256,
+	"sha3-256":   func() hash.Hash { return sha3.New256() },
+	"sha3-512":   func() hash.Hash { return sha3.New512() },
+}
+
+// inferredAlgorithms are tried in order to tell the algorithm of a bare digest by its length
+var inferredAlgorithms = []string{"sha256", "sha512", "sha1", "sha224", "sha384"}
+
+// hashOptions are the flags of "file hash".
+type hashOptions struct {
+	algos   []string
+	workers int
+	sum     bool
+	expect  string
+}
+
+// FileHash holds the digests of one input.
+type FileHash struct {
+	Path   string            `json:"path"            yaml:"path"`
+	Size   int64             `json:"size"            yaml:"size"`
+	Hashes map[string]string `json:"hashes"          yaml:"hashes"`
+	Match  *bool             `json:"match,omitempty" yaml:"match,omitempty"`
+	Error  string            `json:"error,omitempty" yaml:"error,omitempty"`
+
+	err error
+}
+
+// hashInput is a file to hash, or an input that could not be resolved.
+type hashInput struct {
+	path string
+	size int64
+	err  error
+}
+
+func newHashCommand(parent *cli.BaseCommand) *cobra.Command {
+	opts := &hashOptions{}
+	cmd := &cobra.Command{
+		Use:   "hash [path|glob|-]...",
+		Short: "Calculate file hashes",
+		Long: `Calculate digests of files, glob matches, directories (recursively) and
+standard input ("-", the default when no path is given). Files are hashed in
+parallel and a progress bar is shown on a terminal for large inputs.
+
+--sum prints lines compatible with sha256sum and friends, or BSD-style tagged
+lines when several algorithms are selected. --expect compares a single input
+against a digest, written as <digest> or <algo>:<digest>, and exits with
+status 1 on a mismatch. Algorithms: ` + strings.Join(hashAlgorithmNames(), ", ") + `.`,
+		Example: `  toolbox file hash go.sum
+  toolbox file hash --algo sha256 --algo sha512 'dist/*.tar.gz'
+  toolbox file hash ./build --sum > SHA256SUMS
+  toolbox file hash image.iso --expect sha256:9f86d081884c7d65...
+  curl -sL https://example.com/app.tgz | toolbox file hash -`,
+		ValidArgsFunction: func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+			return nil, cobra.ShellCompDirectiveDefault
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runFileHash(parent, cmd.InOrStdin(), args, *opts)
+		},
+	}
+
+	cmd.Flags().StringSliceVarP(&opts.algos, "algo", "a", []string{defaultHashAlgorithm}, "Hash algorithm, repeatable")
+	cmd.Flags().IntVarP(&opts.workers, "workers", "j", 0, "Files hashed in parallel (default: number of CPUs)")
+	cmd.Flags().BoolVar(&opts.sum, "sum", false, "Print sha256sum-compatible lines")
+	cmd.Flags().StringVar(&opts.expect, "expect", "", "Expected digest of a single input, as <digest> or <algo>:<digest>")
+	_ = cmd.RegisterFlagCompletionFunc("algo", cli.CompleteValues(hashAlgorithmNames()...))
+
+	parent.AddWatchFlag(cmd)
+
+	return cmd
+}
+
+// hashAlgorithmNames returns the --algo values, sorted.
+func hashAlgorithmNames() []string {
+	names := make([]string, 0, len(hashAlgorithms))
+	for name := range hashAlgorithms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func runFileHash(cmd *cli.BaseCommand, stdin io.Reader, args []string, opts hashOptions) error {
+	algos, err := checkAlgorithms(opts.algos)
+	if err != nil {
+		return err
+	}
+	if opts.workers < 0 {
+		return cli.UsageErrorf("--workers must not be negative")
+	}
+	if len(args) == 0 {
+		args = []string{stdinPath}
+	}
+
+	inputs, err := collectInputs(args)
+	if err != nil {
+		return err
+	}
+	if len(inputs) == 0 {
+		return cli.NewError(cli.KindNotFound, "no files to hash in %s", strings.Join(args, ", "))
+	}
+	expectAlgo, expectDigest := "", ""
+	if opts.expect != "" {
+		if len(inputs) != 1 {
+			return cli.UsageErrorf("--expect needs exactly one input, got %d", len(inputs))
+		}
+		if expectAlgo, expectDigest, err = parseExpected(opts.expect, algos); err != nil {
+			return err
+		}
+		if !slices.Contains(algos, expectAlgo) {
+			algos = append(algos, expectAlgo)
+		}
+	}
+
+	results := hashAll(stdin, inputs, algos, opts.workers)
+	if expectDigest != "" && results[0].err == nil {
+		match := strings.EqualFold(results[0].Hashes[expectAlgo], expectDigest)
+		results[0].Match = &match
+	}
+
+	if err := printHashes(cmd, results, algos, opts.sum); err != nil {
+		return err
+	}
+	if err := hashFailures(cmd, results); err != nil {
+		return err
+	}
+	if result := results[0]; result.Match != nil && !*result.Match {
+		return cli.NewError(cli.KindGeneral, "%s digest mismatch for %s", expectAlgo, result.Path).
+			WithHint("expected %s, got %s", strings.ToLower(expectDigest), result.Hashes[expectAlgo])
+	}
+	return nil
+}
+
+// checkAlgorithms validates and de-duplicates --algo values.
+func checkAlgorithms(names []string) ([]string, error) {
+	var algos []string
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := hashAlgorithms[name]; !ok {
+			return nil, cli.UsageErrorf("unknown hash algorithm %q", name).
+				WithSuggestions(name, hashAlgorithmNames())
+		}
+		if !slices.Contains(algos, name) {
+			algos = append(algos, name)
+		}
+	}
+	if len(algos) == 0 {
+		algos = []string{defaultHashAlgorithm}
+	}
+	return algos, nil
+}
+
+// parseExpected splits an --expect value into algorithm and digest. A bare
+// digest is matched by length, first against the selected algorithms.
+func parseExpected(expect string, algos []string) (string, string, error) {
+	algo, digest, hasAlgo := strings.Cut(expect, ":")
+	if !hasAlgo {
+		algo, digest = "", expect
+	}
+	if _, err := hex.DecodeString(digest); err != nil || digest == "" {
+		return "", "", cli.UsageErrorf("expected digest %q is not hexadecimal", digest)
+	}
+
+	if hasAlgo {
+		algo = strings.ToLower(algo)
+		newHash, known := hashAlgorithms[algo]
+		if !known {
+			return "", "", cli.UsageErrorf("unknown hash algorithm %q", algo).
+				WithSuggestions(algo, hashAlgorithmNames())
+		}
+		if size := newHash().Size() * 2; len(digest) != size {
+			return "", "", cli.UsageErrorf("a %s digest has %d characters, got %d", algo, size, len(digest))
+		}
+		return algo, digest, nil
+	}
+
+	for _, algo := range append(append([]string{}, algos...), inferredAlgorithms...) {
+		if hashAlgorithms[algo]().Size()*2 == len(digest) {
+			return algo, digest, nil
+		}
+	}
+	return "", "", cli.UsageErrorf("cannot tell the algorithm of a %d character digest", len(digest)).
+		WithHint("write it as <algo>:<digest>, e.g. sha256:%s", digest)
+}
+
+// collectInputs resolves arguments into files. Directories are walked
+// recursively and patterns that match no file name are expanded as globs.
+// Inputs that cannot be resolved carry their error; stdin can be read only
+// once.
+func collectInputs(args []string) ([]hashInput, error) {
+	var inputs []hashInput
+	stdinSeen := false
+	for _, arg := range args {
+		if arg == stdinPath {
+			if stdinSeen {
+				return nil, cli.UsageErrorf("%s (standard input) can be given only once", stdinPath)
+			}
+			stdinSeen = true
+			inputs = append(inputs, hashInput{path: stdinPath})
+			continue
+		}
+		if _, err := os.Lstat(arg); err != nil && hasGlobMeta(arg) {
+			matches, globErr := filepath.Glob(arg)
+			if globErr != nil || len(matches) == 0 {
+				inputs = append(inputs, hashInput{path: arg, err: fmt.Errorf("no files match %s: %w", arg, fs.ErrNotExist)})
+			}
+			for _, match := range matches {
+				inputs = append(inputs, expandInput(match)...)
+			}
+			continue
+		}
+		inputs = append(inputs, expandInput(arg)...)
+	}
+	return inputs, nil
+}
+
+// expandInput returns path itself, or the regular files below a directory.
+func expandInput(path string) []hashInput {
+	info, err := os.Stat(path)
+	switch {
+	case err != nil:
+		return []hashInput{{path: path, err: err}}
+	case info.Mode().IsRegular():
+		return []hashInput{{path: path, size: info.Size()}}
+	case !info.IsDir():
+		return []hashInput{{path: path, err: fmt.Errorf("%s is not a regular file", path)}}
+	}
+
+	var inputs []hashInput
+	_ = filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			inputs = append(inputs, hashInput{path: file, err: err})
+			return nil
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		// Symlinks count when they point at regular files
+		if info, err := os.Stat(file); err == nil && info.Mode().IsRegular() {
+			inputs = append(inputs, hashInput{path: file, size: info.Size()})
+		}
+		return nil
+	})
+	return inputs
+}
+
+func hasGlobMeta(path string) bool {
+	return strings.ContainsAny(path, `*?[`)
+}
+
+// hashAll hashes inputs with up to workers files at a time and returns the
+// results in input order.
+func hashAll(stdin io.Reader, inputs []hashInput, algos []string, workers int) []FileHash {
+	if workers == 0 {
+		workers = runtime.NumCPU()
+	}
+	bar := newHashProgress(inputs)
+
+	results := make([]FileHash, len(inputs))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range min(workers, len(inputs)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				results[i] = hashFile(stdin, inputs[i], algos, bar)
+			}
+		}()
+	}
+	for i := range inputs {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	if bar != nil {
+		bar.Finish()
+	}
+	return results
+}
+
+// newHashProgress returns a progress bar when the inputs are large and
+// stderr is a terminal, or nil.
+func newHashProgress(inputs []hashInput) *cli.ProgressBar {
+	var total int64
+	for _, input := range inputs {
+		total += input.size
+	}
+	return newBytesProgress(total, "Hashing")
+}
+
+// newBytesProgress returns a progress bar for total bytes when they reach
+// progressThreshold and stderr is a terminal, or nil.
+func newBytesProgress(total int64, description string) *cli.ProgressBar {
+	if total < progressThreshold || !term.IsTerminal(int(os.Stderr.Fd())) {
+		return nil
+	}
+	return cli.NewBytesProgressBar(total, description)
+}
+
+// hashFile computes all digests of one input in a single pass.
+func hashFile(stdin io.Reader, input hashInput, algos []string, bar *cli.ProgressBar) FileHash {
+	result := FileHash{Path: input.path, err: input.err}
+	if result.err != nil {
+		result.Error = result.err.Error()
+		return result
+	}
+
+	reader := stdin
+	if input.path != stdinPath {
+		file, err := os.Open(input.path) // #nosec G304 - hashing user-selected files is the point
+		if err != nil {
+			result.err, result.Error = err, err.Error()
+			return result
+		}
+		defer func() { _ = file.Close() }()
+		reader = file
+	}
+	if bar != nil {
+		reader = &progressReader{reader: reader, bar: bar}
+	}
+
+	hashes := make([]hash.Hash, len(algos))
+	writers := make([]io.Writer, len(algos))
+	for i, algo := range algos {
+		hashes[i] = hashAlgorithms[algo]()
+		writers[i] = hashes[i]
+	}
+	size, err := io.CopyBuffer(io.MultiWriter(writers...), reader, make([]byte, copyBufferSize))
+	if err != nil {
+		err = fmt.Errorf("error reading %s: %w", input.path, err)
+		result.err, result.Error = err, err.Error()
+		return result
+	}
+
+	result.Size = size
+	result.Hashes = make(map[string]string, len(algos))
+	for i, algo := range algos {
+		result.Hashes[algo] = hex.EncodeToString(hashes[i].Sum(nil))
+	}
+	return result
+}
+
+// progressReader advances a progress bar as it is read.
+type progressReader struct {
+	reader io.Reader
+	bar    *cli.ProgressBar
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.bar.Add64(int64(n))
+	return n, err
+}
+
+// printHashes prints the digests as sum lines, data or a table. Failed
+// inputs are left out; hashFailures reports them.
+func printHashes(cmd *cli.BaseCommand, results []FileHash, algos []string, sum bool) error {
+	if sum {
+		for _, result := range results {
+			if result.err == nil {
+				printSumLines(cmd.OutOrStdout(), result, algos)
+			}
+		}
+		return nil
+	}
+	if printed, err := cmd.PrintData(results); printed {
+		return err
+	}
+
+	headers := []string{"Path", "Size"}
+	for _, algo := range algos {
+		headers = append(headers, strings.ToUpper(algo))
+	}
+	table := cmd.NewTable(headers)
+	for _, result := range results {
+		if result.err != nil {
+			continue
+		}
+		row := []string{result.Path, cli.FormatSize(result.Size)}
+		for _, algo := range algos {
+			row = append(row, result.Hashes[algo])
+		}
+		table.AddRow(row...)
+	}
+	table.Render()
+
+	if result := results[0]; result.Match != nil && *result.Match {
+		cmd.PrintSuccessf("OK: %s matches the expected digest", result.Path)
+	}
+	return nil
+}
+
+// printSumLines writes the GNU "<digest>  <path>" line for a single
+// algorithm, or BSD-style "ALGO (path) = digest" lines for several. Names
+// with backslashes or newlines are escaped and the line starts with a
+// backslash, as coreutils does.
+func printSumLines(w io.Writer, result FileHash, algos []string) {
+	path, prefix := result.Path, ""
+	if strings.ContainsAny(path, "\\\n") {
+		path = strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(path)
+		prefix = `\`
+	}
+	if len(algos) == 1 {
+		_, _ = fmt.Fprintf(w, "%s%s  %s\n", prefix, result.Hashes[algos[0]], path)
+		return
+	}
+	for _, algo := range algos {
+		_, _ = fmt.Fprintf(w, "%s%s (%s) = %s\n", prefix, strings.ToUpper(algo), path, result.Hashes[algo])
+	}
+}
+
+// hashFailures collects the errors of inputs that could not be hashed.
+func hashFailures(cmd *cli.BaseCommand, results []FileHash) error {
+	var errs []error
+	for _, result := range results {
+		if result.err != nil {
+			errs = append(errs, result.err)
+		}
+	}
+	return inputFailures(cmd, errs, len(results), "hashed")
+}
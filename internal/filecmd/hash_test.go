@@ -0,0 +1,155 @@
+package filecmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nate3d/go-toolbox/internal/cli"
+)
+
+// Digests of "a\n"
+const (
+	sha256OfA = "87428fc522803d31065e7bce3cf03fe475096631e5e07bbd7a0fde60c4cf25c7"
+	sha1OfA   = "3f786850e387550fdab836ed7e6dc881de23001b"
+)
+
+// writeFiles creates files with the given contents below dir.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// newTestCommand returns a file command whose output goes to the returned buffer.
+func newTestCommand(output cli.OutputFormat) (*cli.BaseCommand, *bytes.Buffer) {
+	cmd := cli.NewBaseCommand("file", "")
+	cmd.Output = output
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	return cmd, &out
+}
+
+func TestCollectInputs(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.txt": "a\n", "sub/b.txt": "b\n", "sub/c.log": "c\n"})
+
+	inputs, err := collectInputs([]string{
+		filepath.Join(dir, "sub"),
+		filepath.Join(dir, "*.txt"),
+		filepath.Join(dir, "missing"),
+		"-",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, input := range inputs {
+		name, _ := filepath.Rel(dir, input.path)
+		if input.path == stdinPath {
+			name = stdinPath
+		}
+		if input.err != nil {
+			name += " (error)"
+		}
+		got = append(got, name)
+	}
+	want := "sub/b.txt sub/c.log a.txt missing (error) -"
+	if strings.Join(got, " ") != want {
+		t.Errorf("collectInputs() = %q, want %q", strings.Join(got, " "), want)
+	}
+
+	if _, err := collectInputs([]string{"-", "-"}); cli.ExitCode(err) != cli.ExitUsage {
+		t.Errorf("repeated stdin: error = %v, want a usage error", err)
+	}
+}
+
+func TestParseExpected(t *testing.T) {
+	tests := []struct {
+		expect   string
+		algos    []string
+		wantAlgo string
+		wantErr  bool
+	}{
+		{expect: sha256OfA, algos: []string{"sha256"}, wantAlgo: "sha256"},
+		{expect: sha256OfA, algos: []string{"sha3-256"}, wantAlgo: "sha3-256"},
+		{expect: sha1OfA, algos: []string{"sha256"}, wantAlgo: "sha1"},
+		{expect: "SHA512:" + strings.Repeat("ab", 64), algos: []string{"sha256"}, wantAlgo: "sha512"},
+		{expect: "sha256:00", wantErr: true},
+		{expect: "sha256:" + strings.Repeat("z", 64), wantErr: true},
+		{expect: "sha256:", wantErr: true},
+		{expect: "md5:abcd", wantErr: true},
+		{expect: "abc", wantErr: true},
+		{expect: "zz", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expect, func(t *testing.T) {
+			algo, _, err := parseExpected(tt.expect, tt.algos)
+			if (err != nil) != tt.wantErr || (err != nil && cli.ExitCode(err) != cli.ExitUsage) {
+				t.Fatalf("parseExpected() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if algo != tt.wantAlgo {
+				t.Errorf("parseExpected() algo = %q, want %q", algo, tt.wantAlgo)
+			}
+		})
+	}
+}
+
+func TestPrintSumLines(t *testing.T) {
+	result := FileHash{Path: `dir\a`, Hashes: map[string]string{"sha256": "aa", "sha1": "bb"}}
+
+	var out bytes.Buffer
+	printSumLines(&out, result, []string{"sha256"})
+	printSumLines(&out, result, []string{"sha256", "sha1"})
+	want := `\aa  dir\\a` + "\n" + `\SHA256 (dir\\a) = aa` + "\n" + `\SHA1 (dir\\a) = bb` + "\n"
+	if out.String() != want {
+		t.Errorf("printSumLines() = %q, want %q", out.String(), want)
+	}
+}
+
+func TestRunFileHash(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.txt": "a\n"})
+	path := filepath.Join(dir, "a.txt")
+
+	cmd, out := newTestCommand(cli.OutputTable)
+	opts := hashOptions{algos: []string{"sha256", "sha1"}, sum: true}
+	if err := runFileHash(cmd, nil, []string{path}, opts); err != nil {
+		t.Fatal(err)
+	}
+	if want := "SHA256 (" + path + ") = " + sha256OfA + "\n"; !strings.HasPrefix(out.String(), want) {
+		t.Errorf("output = %q, want prefix %q", out.String(), want)
+	}
+
+	cmd, out = newTestCommand(cli.OutputTable)
+	opts = hashOptions{algos: []string{"sha256"}, sum: true}
+	if err := runFileHash(cmd, strings.NewReader("a\n"), nil, opts); err != nil {
+		t.Fatal(err)
+	}
+	if want := sha256OfA + "  -\n"; out.String() != want {
+		t.Errorf("stdin output = %q, want %q", out.String(), want)
+	}
+
+	cmd, _ = newTestCommand(cli.OutputJSON)
+	opts = hashOptions{algos: []string{"sha256"}, expect: sha1OfA}
+	if err := runFileHash(cmd, nil, []string{path}, opts); err != nil {
+		t.Errorf("matching --expect: %v", err)
+	}
+	opts.expect = strings.Repeat("0", len(sha256OfA))
+	if err := runFileHash(cmd, nil, []string{path}, opts); cli.ExitCode(err) != cli.ExitGeneral {
+		t.Errorf("mismatching --expect error = %v, want exit code %d", err, cli.ExitGeneral)
+	}
+	if err := runFileHash(cmd, nil, []string{filepath.Join(dir, "missing")}, opts); cli.ExitCode(err) != cli.ExitNotFound {
+		t.Errorf("missing file error = %v, want not found", err)
+	}
+}
@@ -0,0 +1,208 @@
+package filecmd
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// gitignoreFile is the name of the per-directory ignore file
+const gitignoreFile = ".gitignore"
+
+// ignoreRule is one pattern of a .gitignore file.
+type ignoreRule struct {
+	// base is the directory holding the .gitignore file
+	base    string
+	pattern *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// ignoreMatcher applies the .gitignore files of a directory and its
+// parents. Matchers are immutable, so subdirectories extend a shared parent.
+type ignoreMatcher struct {
+	rules []ignoreRule
+	// cwd resolves relative paths, as rule bases are absolute
+	cwd string
+}
+
+// newIgnoreMatcher returns a matcher for walking root, with the .gitignore
+// files from the top of its git work tree down to root already applied.
+func newIgnoreMatcher(root string) *ignoreMatcher {
+	cwd, _ := os.Getwd()
+	abs := root
+	if !filepath.IsAbs(root) {
+		abs = filepath.Join(cwd, root)
+	}
+	// Collect the ancestors up to the work tree, which holds .git
+	dirs := []string{abs}
+	for dir := abs; ; {
+		if _, err := os.Lstat(filepath.Join(dir, ".git")); err == nil {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			// Not in a work tree; only root's own files apply
+			dirs = dirs[:1]
+			break
+		}
+		dir = parent
+		dirs = append(dirs, dir)
+	}
+
+	m := &ignoreMatcher{cwd: cwd}
+	for i := len(dirs) - 1; i >= 0; i-- {
+		m = m.withDir(dirs[i])
+	}
+	return m
+}
+
+// withDir returns a matcher that also applies dir/.gitignore. A nil
+// matcher ignores nothing and is returned as is.
+func (m *ignoreMatcher) withDir(dir string) *ignoreMatcher {
+	if m == nil {
+		return nil
+	}
+	rules := readIgnoreFile(m.abs(dir))
+	if len(rules) == 0 {
+		return m
+	}
+	return &ignoreMatcher{rules: append(m.rules[:len(m.rules):len(m.rules)], rules...), cwd: m.cwd}
+}
+
+// ignored reports whether path is excluded. The last matching rule wins,
+// so a later "!pattern" brings a path back. The .git directory is always
+// ignored.
+func (m *ignoreMatcher) ignored(path string, isDir bool) bool {
+	if m == nil {
+		return false
+	}
+	if isDir && filepath.Base(path) == ".git" {
+		return true
+	}
+	path = m.abs(path)
+	ignored := false
+	for _, rule := range m.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		rel, err := filepath.Rel(rule.base, path)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		if rule.pattern.MatchString(filepath.ToSlash(rel)) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// abs resolves path against the working directory of the matcher.
+func (m *ignoreMatcher) abs(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(m.cwd, path)
+}
+
+// readIgnoreFile parses dir/.gitignore. A missing or unreadable file has
+// no rules.
+func readIgnoreFile(dir string) []ignoreRule {
+	file, err := os.Open(filepath.Join(dir, gitignoreFile)) // #nosec G304 - ignore files of the walked tree
+	if err != nil {
+		return nil
+	}
+	defer func() { _ = file.Close() }()
+
+	var rules []ignoreRule
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if rule, ok := parseIgnoreLine(dir, scanner.Text()); ok {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// parseIgnoreLine turns one line of a .gitignore file into a rule,
+// following the pattern format of gitignore(5).
+func parseIgnoreLine(base, line string) (ignoreRule, bool) {
+	line = strings.TrimRight(line, " \t")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+
+	rule := ignoreRule{base: base}
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	// A slash anywhere but at the end anchors the pattern to base
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+	if line == "" {
+		return ignoreRule{}, false
+	}
+
+	expr := globToRegexp(line)
+	if !anchored {
+		expr = "(?:.*/)?" + expr
+	}
+	pattern, err := regexp.Compile("^" + expr + "$")
+	if err != nil {
+		return ignoreRule{}, false
+	}
+	rule.pattern = pattern
+	return rule, true
+}
+
+// globToRegexp translates a gitignore glob, where "*" and "?" stop at
+// slashes and "**" spans directories.
+func globToRegexp(glob string) string {
+	var expr strings.Builder
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; {
+		case strings.HasPrefix(glob[i:], "**/"):
+			expr.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			expr.WriteString(".*")
+			i++
+		case c == '*':
+			expr.WriteString("[^/]*")
+		case c == '?':
+			expr.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				expr.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			expr.WriteString("[" + class + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(glob):
+			i++
+			expr.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return expr.String()
+}
+
+// isHidden reports whether name is a dot file.
+func isHidden(name string) bool {
+	return strings.HasPrefix(name, ".") && name != "." && name != ".."
+}
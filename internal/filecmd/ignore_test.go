@@ -0,0 +1,85 @@
+package filecmd
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestParseIgnoreLine(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		isDir   bool
+		want    bool
+	}{
+		{"*.log", "a.log", false, true},
+		{"*.log", "sub/deep/a.log", false, true},
+		{"*.log", "a.txt", false, false},
+		{"build/", "build", true, true},
+		{"build/", "build", false, false},
+		{"/root.txt", "root.txt", false, true},
+		{"/root.txt", "sub/root.txt", false, false},
+		{"docs/*.md", "docs/a.md", false, true},
+		{"docs/*.md", "docs/sub/a.md", false, false},
+		{"**/cache", "a/b/cache", true, true},
+		{"logs/**", "logs/a/b.txt", false, true},
+		{"a/**/b", "a/x/y/b", false, true},
+		{"a/**/b", "a/b", false, true},
+		{"file?.txt", "file1.txt", false, true},
+		{"[!a]*.go", "b.go", false, true},
+		{"[!a]*.go", "a.go", false, false},
+		{`\#hash`, "#hash", false, true},
+	}
+	for _, tt := range tests {
+		rule, ok := parseIgnoreLine("/base", tt.pattern)
+		if !ok {
+			t.Fatalf("parseIgnoreLine(%q) was rejected", tt.pattern)
+		}
+		m := &ignoreMatcher{rules: []ignoreRule{rule}}
+		if got := m.ignored(filepath.Join("/base", tt.path), tt.isDir); got != tt.want {
+			t.Errorf("pattern %q, path %q: ignored = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+
+	for _, line := range []string{"", "   ", "# comment", "/"} {
+		if _, ok := parseIgnoreLine("/base", line); ok {
+			t.Errorf("parseIgnoreLine(%q) was accepted", line)
+		}
+	}
+}
+
+func TestIgnoreMatcher(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		".git/HEAD":      "ref: refs/heads/main\n",
+		".gitignore":     "*.log\n!keep.log\nbuild/\n",
+		"sub/.gitignore": "!debug.log\n*.tmp\n",
+	})
+
+	root := newIgnoreMatcher(filepath.Join(dir, "sub"))
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"a.log", false, true},
+		{"keep.log", false, false},
+		{"sub/debug.log", false, false},
+		{"sub/other.log", false, true},
+		{"sub/x.tmp", false, true},
+		{"x.tmp", false, false},
+		{"sub/build", true, true},
+		{".git", true, true},
+		{"sub/main.go", false, false},
+	}
+	for _, tt := range tests {
+		if got := root.ignored(filepath.Join(dir, tt.path), tt.isDir); got != tt.want {
+			t.Errorf("ignored(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+
+	var none *ignoreMatcher
+	if none.withDir(dir).ignored(filepath.Join(dir, "a.log"), false) {
+		t.Error("a nil matcher ignored a path")
+	}
+}
@@ -0,0 +1,441 @@
+package filecmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nate3d/go-toolbox/internal/cli"
+	"github.com/nate3d/go-toolbox/pkg/utils"
+)
+
+const (
+	// maxSymlinkHops bounds symlink chains, like the kernel's ELOOP limit
+	maxSymlinkHops = 40
+
+	// infoTimeFormat shows timestamps with nanoseconds, as stat(1) does
+	infoTimeFormat = "2006-01-02 15:04:05.000000000 -0700"
+)
+
+// Special permission bits in octal notation
+const (
+	octalSetuid = 0o4000
+	octalSetgid = 0o2000
+	octalSticky = 0o1000
+)
+
+// errSymlinkLoop is returned for symlink chains longer than maxSymlinkHops
+var errSymlinkLoop = errors.New("too many levels of symbolic links")
+
+// FileInfo is the metadata of one path.
+type FileInfo struct {
+	Path        string            `json:"path"                   yaml:"path"`
+	Type        string            `json:"type"                   yaml:"type"`
+	Size        int64             `json:"size"                   yaml:"size"`
+	Mode        string            `json:"mode"                   yaml:"mode"`
+	ModeOctal   string            `json:"mode_octal"             yaml:"mode_octal"`
+	UID         *uint32           `json:"uid,omitempty"          yaml:"uid,omitempty"`
+	GID         *uint32           `json:"gid,omitempty"          yaml:"gid,omitempty"`
+	Owner       string            `json:"owner,omitempty"        yaml:"owner,omitempty"`
+	Group       string            `json:"group,omitempty"        yaml:"group,omitempty"`
+	Inode       uint64            `json:"inode,omitempty"        yaml:"inode,omitempty"`
+	Links       uint64            `json:"links,omitempty"        yaml:"links,omitempty"`
+	Device      string            `json:"device,omitempty"       yaml:"device,omitempty"`
+	Accessed    *time.Time        `json:"accessed,omitempty"     yaml:"accessed,omitempty"`
+	Modified    time.Time         `json:"modified"               yaml:"modified"`
+	Changed     *time.Time        `json:"changed,omitempty"      yaml:"changed,omitempty"`
+	Born        *time.Time        `json:"born,omitempty"         yaml:"born,omitempty"`
+	Symlinks    []string          `json:"symlinks,omitempty"     yaml:"symlinks,omitempty"`
+	Target      string            `json:"target,omitempty"       yaml:"target,omitempty"`
+	Format      string            `json:"format,omitempty"       yaml:"format,omitempty"`
+	MIMEType    string            `json:"mime_type,omitempty"    yaml:"mime_type,omitempty"`
+	Encoding    string            `json:"encoding,omitempty"     yaml:"encoding,omitempty"`
+	LineEndings string            `json:"line_endings,omitempty" yaml:"line_endings,omitempty"`
+	Interpreter string            `json:"interpreter,omitempty"  yaml:"interpreter,omitempty"`
+	Xattrs      map[string]string `json:"xattrs,omitempty"       yaml:"xattrs,omitempty"`
+	Error       string            `json:"error,omitempty"        yaml:"error,omitempty"`
+
+	err error
+}
+
+// statInfo is the metadata only the platform can provide.
+type statInfo struct {
+	uid, gid *uint32
+	inode    uint64
+	links    uint64
+	device   string
+	accessed *time.Time
+	changed  *time.Time
+	born     *time.Time
+}
+
+func newInfoCommand(parent *cli.BaseCommand) *cobra.Command {
+	var dereference bool
+	cmd := &cobra.Command{
+		Use:   "info <path>...",
+		Short: "Show file information",
+		Long: `Show the metadata of files, directories and links: size, permissions in
+symbolic and octal form, owner and group, inode, link count and device,
+access, modification, change and (on Linux, via statx) birth times, the chain
+of symbolic links, extended attributes and the detected MIME type.
+
+Symbolic links are described themselves unless --dereference is set.`,
+		Example: `  toolbox file info go.mod
+  toolbox file info /usr/bin/* --output json`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return runFileInfo(parent, args, dereference)
+		},
+	}
+	cmd.Flags().BoolVarP(&dereference, "dereference", "L", false, "Describe the targets of symbolic links")
+	parent.AddWatchFlag(cmd)
+
+	return cmd
+}
+
+func runFileInfo(cmd *cli.BaseCommand, paths []string, dereference bool) error {
+	names := newNameCache()
+	infos := make([]FileInfo, len(paths))
+	var errs []error
+	for i, path := range paths {
+		infos[i] = statFile(path, dereference, names)
+		if infos[i].err != nil {
+			errs = append(errs, infos[i].err)
+		}
+	}
+
+	if printed, err := cmd.PrintData(infos); printed {
+		if err != nil {
+			return err
+		}
+		return inputFailures(cmd, errs, len(paths), "described")
+	}
+
+	for _, info := range infos {
+		if info.err == nil {
+			printFileInfo(cmd, info)
+		}
+	}
+	return inputFailures(cmd, errs, len(paths), "described")
+}
+
+// statFile gathers the metadata of path.
+func statFile(path string, dereference bool, names *nameCache) FileInfo {
+	info := FileInfo{Path: path}
+	fail := func(err error) FileInfo {
+		info.err, info.Error = err, err.Error()
+		return info
+	}
+
+	chain, target, err := symlinkChain(path)
+	if err != nil {
+		return fail(err)
+	}
+	info.Symlinks, info.Target = chain, target
+
+	statPath := path
+	if dereference {
+		statPath = target
+	}
+	fileInfo, err := os.Lstat(statPath)
+	if err != nil {
+		return fail(err)
+	}
+
+	mode := fileInfo.Mode()
+	info.Type = fileType(mode)
+	info.Size = fileInfo.Size()
+	info.Mode = symbolicMode(mode)
+	info.ModeOctal = octalMode(mode)
+	info.Modified = fileInfo.ModTime()
+
+	if st, err := platformStat(statPath); err == nil {
+		info.UID, info.GID = st.uid, st.gid
+		info.Inode, info.Links, info.Device = st.inode, st.links, st.device
+		info.Accessed, info.Changed, info.Born = st.accessed, st.changed, st.born
+	}
+	if info.UID != nil {
+		info.Owner = names.user(*info.UID)
+	}
+	if info.GID != nil {
+		info.Group = names.group(*info.GID)
+	}
+
+	detection := detectContent(target, mode)
+	info.Format, info.MIMEType = detection.Description, detection.MIMEType
+	info.Encoding, info.LineEndings, info.Interpreter = detection.Encoding, detection.LineEndings, detection.Interpreter
+	info.Xattrs = readXattrs(statPath)
+	return info
+}
+
+// symlinkChain follows path through symbolic links. It returns the links
+// visited after path itself and the final target, which is path when it is
+// not a link.
+func symlinkChain(path string) ([]string, string, error) {
+	var chain []string
+	current := path
+	for range maxSymlinkHops {
+		info, err := os.Lstat(current)
+		if err != nil {
+			if len(chain) > 0 && errors.Is(err, fs.ErrNotExist) {
+				// A dangling link is still described
+				return chain, current, nil
+			}
+			return nil, "", err
+		}
+		if info.Mode()&fs.ModeSymlink == 0 {
+			return chain, current, nil
+		}
+		link, err := os.Readlink(current)
+		if err != nil {
+			return nil, "", err
+		}
+		if !filepath.IsAbs(link) {
+			link = filepath.Join(filepath.Dir(current), link)
+		}
+		chain = append(chain, link)
+		current = link
+	}
+	return nil, "", fmt.Errorf("%s: %w", path, errSymlinkLoop)
+}
+
+// fileType names the type of a file mode.
+func fileType(mode fs.FileMode) string {
+	switch {
+	case mode.IsRegular():
+		return "file"
+	case mode.IsDir():
+		return "directory"
+	case mode&fs.ModeSymlink != 0:
+		return "symlink"
+	case mode&fs.ModeNamedPipe != 0:
+		return "fifo"
+	case mode&fs.ModeSocket != 0:
+		return "socket"
+	case mode&fs.ModeCharDevice != 0:
+		return "character device"
+	case mode&fs.ModeDevice != 0:
+		return "block device"
+	default:
+		return "unknown"
+	}
+}
+
+// symbolicMode formats mode as ls does, e.g. "drwxr-sr-x".
+func symbolicMode(mode fs.FileMode) string {
+	var b strings.Builder
+	switch {
+	case mode.IsDir():
+		b.WriteByte('d')
+	case mode&fs.ModeSymlink != 0:
+		b.WriteByte('l')
+	case mode&fs.ModeNamedPipe != 0:
+		b.WriteByte('p')
+	case mode&fs.ModeSocket != 0:
+		b.WriteByte('s')
+	case mode&fs.ModeCharDevice != 0:
+		b.WriteByte('c')
+	case mode&fs.ModeDevice != 0:
+		b.WriteByte('b')
+	default:
+		b.WriteByte('-')
+	}
+
+	const rwx = "rwxrwxrwx"
+	perm := []byte(strings.Repeat("-", len(rwx)))
+	for i := range rwx {
+		if mode&(1<<uint(len(rwx)-1-i)) != 0 {
+			perm[i] = rwx[i]
+		}
+	}
+	special := func(set bool, i int, exec, noExec byte) {
+		if !set {
+			return
+		}
+		if perm[i] == 'x' {
+			perm[i] = exec
+		} else {
+			perm[i] = noExec
+		}
+	}
+	special(mode&fs.ModeSetuid != 0, 2, 's', 'S')
+	special(mode&fs.ModeSetgid != 0, 5, 's', 'S')
+	special(mode&fs.ModeSticky != 0, 8, 't', 'T')
+	b.Write(perm)
+	return b.String()
+}
+
+// octalMode formats the permission and special bits of mode, e.g. "2755".
+func octalMode(mode fs.FileMode) string {
+	return fmt.Sprintf("%04o", permBits(mode))
+}
+
+// permBits returns the permission bits of mode as chmod numbers them,
+// including the setuid, setgid and sticky bits.
+func permBits(mode fs.FileMode) uint32 {
+	bits := uint32(mode.Perm())
+	if mode&fs.ModeSetuid != 0 {
+		bits |= octalSetuid
+	}
+	if mode&fs.ModeSetgid != 0 {
+		bits |= octalSetgid
+	}
+	if mode&fs.ModeSticky != 0 {
+		bits |= octalSticky
+	}
+	return bits
+}
+
+// detectContent identifies the format of regular files and names other
+// types with the inode/* types of the shared MIME database.
+func detectContent(path string, mode fs.FileMode) utils.Detection {
+	switch {
+	case mode.IsDir():
+		return utils.Detection{MIMEType: "inode/directory"}
+	case mode&fs.ModeSymlink != 0:
+		return utils.Detection{MIMEType: "inode/symlink"}
+	case !mode.IsRegular():
+		return utils.Detection{MIMEType: "inode/" + strings.ReplaceAll(fileType(mode), " ", "")}
+	}
+
+	detection, err := utils.Detect().File(path)
+	if err != nil {
+		return utils.Detection{}
+	}
+	if !detection.IsText() {
+		// The encoding of binary content says nothing new
+		detection.Encoding = ""
+	}
+	return detection
+}
+
+// xattrValue shows an extended attribute as text when it is printable and
+// as hex otherwise.
+func xattrValue(value []byte) string {
+	text := strings.TrimRight(string(value), "\x00")
+	if utf8.ValidString(text) && strings.IndexFunc(text, func(r rune) bool { return r < ' ' && r != '\t' }) < 0 {
+		return text
+	}
+	return fmt.Sprintf("0x%x", value)
+}
+
+// nameCache resolves user and group IDs to names once per ID.
+type nameCache struct {
+	mu     sync.Mutex
+	users  map[uint32]string
+	groups map[uint32]string
+}
+
+func newNameCache() *nameCache {
+	return &nameCache{users: make(map[uint32]string), groups: make(map[uint32]string)}
+}
+
+func (c *nameCache) user(uid uint32) string {
+	return c.lookup(c.users, uid, func(id string) (string, error) {
+		u, err := user.LookupId(id)
+		if err != nil {
+			return "", err
+		}
+		return u.Username, nil
+	})
+}
+
+func (c *nameCache) group(gid uint32) string {
+	return c.lookup(c.groups, gid, func(id string) (string, error) {
+		g, err := user.LookupGroupId(id)
+		if err != nil {
+			return "", err
+		}
+		return g.Name, nil
+	})
+}
+
+// lookup returns the cached name of id, falling back to the number.
+func (c *nameCache) lookup(cache map[uint32]string, id uint32, resolve func(string) (string, error)) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if name, ok := cache[id]; ok {
+		return name
+	}
+	key := strconv.FormatUint(uint64(id), 10)
+	name, err := resolve(key)
+	if err != nil {
+		name = key
+	}
+	cache[id] = name
+	return name
+}
+
+// printFileInfo renders one file as a property table.
+func printFileInfo(cmd *cli.BaseCommand, info FileInfo) {
+	cmd.PrintHeaderf("%s", info.Path)
+
+	table := cmd.NewTable([]string{"Property", "Value"})
+	table.AddRow("Type", info.Type)
+	table.AddRow("Size", fmt.Sprintf("%s (%d bytes)", cli.FormatSize(info.Size), info.Size))
+	table.AddRow("Mode", fmt.Sprintf("%s (%s)", info.Mode, info.ModeOctal))
+	if info.UID != nil {
+		table.AddRow("Owner", fmt.Sprintf("%s (%d)", info.Owner, *info.UID))
+	}
+	if info.GID != nil {
+		table.AddRow("Group", fmt.Sprintf("%s (%d)", info.Group, *info.GID))
+	}
+	if info.Inode != 0 {
+		table.AddRow("Inode", strconv.FormatUint(info.Inode, 10))
+		table.AddRow("Links", strconv.FormatUint(info.Links, 10))
+		table.AddRow("Device", info.Device)
+	}
+	addTime := func(name string, t *time.Time) {
+		if t != nil {
+			table.AddRow(name, t.Format(infoTimeFormat))
+		}
+	}
+	addTime("Accessed", info.Accessed)
+	addTime("Modified", &info.Modified)
+	addTime("Changed", info.Changed)
+	addTime("Born", info.Born)
+	if len(info.Symlinks) > 0 {
+		table.AddRow("Symlinks", strings.Join(append([]string{info.Path}, info.Symlinks...), " -> "))
+	}
+	for _, row := range [][2]string{
+		{"Format", info.Format},
+		{"MIME type", info.MIMEType},
+		{"Encoding", info.Encoding},
+		{"Line endings", info.LineEndings},
+		{"Interpreter", info.Interpreter},
+	} {
+		if row[1] != "" {
+			table.AddRow(row[0], row[1])
+		}
+	}
+	for _, name := range sortedNames(info.Xattrs) {
+		table.AddRow("xattr "+name, info.Xattrs[name])
+	}
+	table.Render()
+}
+
+func sortedNames(m map[string]string) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// timespec converts seconds and nanoseconds since the epoch to a time.
+func timespec(sec int64, nsec int64) *time.Time {
+	t := time.Unix(sec, nsec)
+	return &t
+}
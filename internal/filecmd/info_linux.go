@@ -0,0 +1,82 @@
+//go:build linux
+
+package filecmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// maxXattrSize bounds the extended attribute names and values read
+const maxXattrSize = 64 << 10
+
+// platformStat reads metadata with statx, which also reports the birth time
+// on file systems that record it. Kernels or sandboxes without statx fall
+// back to lstat.
+func platformStat(path string) (statInfo, error) {
+	var stx unix.Statx_t
+	err := unix.Statx(unix.AT_FDCWD, path, unix.AT_SYMLINK_NOFOLLOW|unix.AT_STATX_SYNC_AS_STAT,
+		unix.STATX_BASIC_STATS|unix.STATX_BTIME, &stx)
+	if errors.Is(err, unix.ENOSYS) || errors.Is(err, unix.EPERM) {
+		return lstatInfo(path)
+	}
+	if err != nil {
+		return statInfo{}, err
+	}
+
+	st := statInfo{
+		uid:      &stx.Uid,
+		gid:      &stx.Gid,
+		inode:    stx.Ino,
+		links:    uint64(stx.Nlink),
+		device:   fmt.Sprintf("%d:%d", stx.Dev_major, stx.Dev_minor),
+		accessed: timespec(stx.Atime.Sec, int64(stx.Atime.Nsec)),
+		changed:  timespec(stx.Ctime.Sec, int64(stx.Ctime.Nsec)),
+	}
+	// Some file systems, such as overlayfs, claim a birth time of zero
+	if stx.Mask&unix.STATX_BTIME != 0 && (stx.Btime.Sec != 0 || stx.Btime.Nsec != 0) {
+		st.born = timespec(stx.Btime.Sec, int64(stx.Btime.Nsec))
+	}
+	return st, nil
+}
+
+func lstatInfo(path string) (statInfo, error) {
+	var stat unix.Stat_t
+	if err := unix.Lstat(path, &stat); err != nil {
+		return statInfo{}, err
+	}
+	dev := uint64(stat.Dev) //nolint:unconvert // Dev is uint32 on some architectures
+	return statInfo{
+		uid:      &stat.Uid,
+		gid:      &stat.Gid,
+		inode:    stat.Ino,
+		links:    uint64(stat.Nlink), //nolint:unconvert // Nlink is uint32 on some architectures
+		device:   fmt.Sprintf("%d:%d", unix.Major(dev), unix.Minor(dev)),
+		accessed: timespec(stat.Atim.Unix()),
+		changed:  timespec(stat.Ctim.Unix()),
+	}, nil
+}
+
+// readXattrs returns the extended attributes of path, without following a
+// final symbolic link. Attributes that cannot be read are skipped.
+func readXattrs(path string) map[string]string {
+	buf := make([]byte, maxXattrSize)
+	n, err := unix.Llistxattr(path, buf)
+	if err != nil || n == 0 {
+		return nil
+	}
+
+	xattrs := make(map[string]string)
+	for _, name := range strings.Split(strings.TrimRight(string(buf[:n]), "\x00"), "\x00") {
+		value := make([]byte, maxXattrSize)
+		size, err := unix.Lgetxattr(path, name, value)
+		if err != nil {
+			continue
+		}
+		xattrs[name] = xattrValue(value[:size])
+	}
+	return xattrs
+}
@@ -0,0 +1,36 @@
+//go:build linux
+
+package filecmd
+
+import (
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestReadXattrs(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.txt": "a\n"})
+	path := filepath.Join(dir, "a.txt")
+	if err := unix.Lsetxattr(path, "user.note", []byte("hello"), 0); err != nil {
+		t.Skipf("extended attributes are not supported here: %v", err)
+	}
+
+	if got := readXattrs(path); got["user.note"] != "hello" {
+		t.Errorf("readXattrs() = %v", got)
+	}
+}
+
+func TestPlatformStat(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.txt": "a\n"})
+
+	st, err := platformStat(filepath.Join(dir, "a.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.uid == nil || st.inode == 0 || st.links != 1 || st.changed == nil {
+		t.Errorf("platformStat() = %+v", st)
+	}
+}
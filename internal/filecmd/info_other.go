@@ -0,0 +1,19 @@
+//go:build !linux
+
+package filecmd
+
+import "errors"
+
+// errNoPlatformStat reports that only portable metadata is available
+var errNoPlatformStat = errors.New("extended file metadata is only available on Linux")
+
+// platformStat is not available on this platform; file info shows the
+// portable metadata only.
+func platformStat(string) (statInfo, error) {
+	return statInfo{}, errNoPlatformStat
+}
+
+// readXattrs is not available on this platform.
+func readXattrs(string) map[string]string {
+	return nil
+}
@@ -0,0 +1,108 @@
+package filecmd
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/nate3d/go-toolbox/internal/cli"
+)
+
+func TestModeFormats(t *testing.T) {
+	tests := []struct {
+		mode     fs.FileMode
+		symbolic string
+		octal    string
+	}{
+		{0o644, "-rw-r--r--", "0644"},
+		{fs.ModeDir | 0o755, "drwxr-xr-x", "0755"},
+		{fs.ModeSymlink | 0o777, "lrwxrwxrwx", "0777"},
+		{fs.ModeSetuid | 0o755, "-rwsr-xr-x", "4755"},
+		{fs.ModeDir | fs.ModeSetgid | 0o750, "drwxr-s---", "2750"},
+		{fs.ModeDir | fs.ModeSticky | 0o776, "drwxrwxrwT", "1776"},
+		{fs.ModeDevice | fs.ModeCharDevice | 0o666, "crw-rw-rw-", "0666"},
+	}
+	for _, tt := range tests {
+		if got := symbolicMode(tt.mode); got != tt.symbolic {
+			t.Errorf("symbolicMode(%v) = %q, want %q", tt.mode, got, tt.symbolic)
+		}
+		if got := octalMode(tt.mode); got != tt.octal {
+			t.Errorf("octalMode(%v) = %q, want %q", tt.mode, got, tt.octal)
+		}
+	}
+}
+
+func TestSymlinkChain(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"target.txt": "a\n"})
+	for link, target := range map[string]string{
+		"one":  "target.txt",
+		"two":  "one",
+		"loop": "loop",
+		"gone": "missing",
+	} {
+		if err := os.Symlink(target, filepath.Join(dir, link)); err != nil {
+			t.Skipf("symlinks are not supported: %v", err)
+		}
+	}
+
+	chain, target, err := symlinkChain(filepath.Join(dir, "two"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join(dir, "one"), filepath.Join(dir, "target.txt")}
+	if !reflect.DeepEqual(chain, want) || target != want[1] {
+		t.Errorf("symlinkChain() = %q, %q", chain, target)
+	}
+
+	if _, target, err := symlinkChain(filepath.Join(dir, "gone")); err != nil || target != filepath.Join(dir, "missing") {
+		t.Errorf("dangling link: target = %q, error = %v", target, err)
+	}
+	if _, _, err := symlinkChain(filepath.Join(dir, "loop")); !errors.Is(err, errSymlinkLoop) {
+		t.Errorf("loop error = %v, want %v", err, errSymlinkLoop)
+	}
+}
+
+func TestXattrValue(t *testing.T) {
+	if got := xattrValue([]byte("hello\x00")); got != "hello" {
+		t.Errorf("xattrValue(text) = %q", got)
+	}
+	if got := xattrValue([]byte{0x01, 0xff}); got != "0x01ff" {
+		t.Errorf("xattrValue(binary) = %q", got)
+	}
+}
+
+func TestRunFileInfo(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"notes.txt": "hello\n"})
+	path := filepath.Join(dir, "notes.txt")
+
+	cmd, out := newTestCommand(cli.OutputJSON)
+	err := runFileInfo(cmd, []string{path, dir, filepath.Join(dir, "missing")}, false)
+	if cli.ExitCode(err) != cli.ExitNotFound {
+		t.Errorf("error = %v, want not found", err)
+	}
+
+	var infos []FileInfo
+	if err := json.Unmarshal(out.Bytes(), &infos); err != nil {
+		t.Fatalf("invalid JSON %q: %v", out.String(), err)
+	}
+	if len(infos) != 3 {
+		t.Fatalf("got %d entries, want 3", len(infos))
+	}
+	file := infos[0]
+	if file.Type != "file" || file.Size != 6 || file.ModeOctal != "0600" || file.MIMEType != "text/plain; charset=utf-8" ||
+		file.Encoding != "utf-8" || file.LineEndings != "lf" {
+		t.Errorf("file info = %+v", file)
+	}
+	if infos[1].Type != "directory" || infos[1].MIMEType != "inode/directory" {
+		t.Errorf("directory info = %+v", infos[1])
+	}
+	if infos[2].Error == "" {
+		t.Errorf("missing file has no error: %+v", infos[2])
+	}
+}
@@ -0,0 +1,388 @@
+package filecmd
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nate3d/go-toolbox/internal/cli"
+	"github.com/nate3d/go-toolbox/pkg/utils"
+)
+
+// Statuses of a RenameEntry
+const (
+	RenameOK        = "ok"
+	RenameUnchanged = "unchanged"
+	RenameCycle     = "cycle"
+	RenameConflict  = "conflict"
+	RenameExists    = "exists"
+	RenameMissing   = "missing"
+	RenameInvalid   = "invalid"
+)
+
+// defaultDateLayout formats {date} tokens
+const defaultDateLayout = "2006-01-02"
+
+var (
+	renameCases    = []string{"snake", "kebab", "camel", "lower", "upper"}
+	renameSortKeys = []string{"name", "mtime"}
+
+	// renameTokenPattern matches the {token} and {token:argument}
+	// placeholders of --to templates
+	renameTokenPattern = regexp.MustCompile(`\{(name|ext|n|date)(?::([^{}]*))?\}`)
+)
+
+// renameOptions are the flags of "file rename".
+type renameOptions struct {
+	pattern  string
+	template string
+	caseName string
+	ext      string
+	setExt   bool
+	start    int
+	sortBy   string
+	dryRun   bool
+	undo     bool
+}
+
+// RenameEntry is a planned rename of "file rename". Entries whose status
+// is not ok, unchanged or cycle stop the batch.
+type RenameEntry struct {
+	From   string `json:"from"   yaml:"from"`
+	To     string `json:"to"     yaml:"to"`
+	Status string `json:"status" yaml:"status"`
+}
+
+// changes reports whether the entry renames anything.
+func (e RenameEntry) changes() bool {
+	return e.Status == RenameOK || e.Status == RenameCycle
+}
+
+func newRenameCommand(parent *cli.BaseCommand) *cobra.Command {
+	opts := &renameOptions{}
+	cmd := &cobra.Command{
+		Use:   "rename <path>... [--pattern REGEX] [--to TEMPLATE] [--case CASE] [--ext EXT]",
+		Short: "Rename files in bulk with patterns, case changes and counters",
+		Long: `Rename the given files and directories in place, showing a preview and
+asking for confirmation first.
+
+Without --pattern, --to gives the whole new name. With --pattern, each
+match of the regular expression in the name is replaced by --to, where
+$1 or ${name} insert capture groups. --to may contain these tokens:
+
+  {name}         the name without its extension
+  {ext}          the extension, with its dot
+  {n}, {n:W}     a counter from --start in --sort order, zero-padded to
+                 W digits (default: the digits of the last number)
+  {date}         the modification date, 2006-01-02
+  {date:LAYOUT}  the modification time in a Go time layout
+
+--case then converts the name without its extension to snake_case,
+kebab-case, camelCase, lower or upper case, and --ext replaces the
+extension (--ext "" removes it).
+
+Targets that already exist, are given twice or are not valid names stop
+the whole batch. Renames that swap names in a cycle go through temporary
+names. Each applied batch is written to a journal in the state directory;
+--undo restores the last one.`,
+		Example: `  toolbox file rename *.JPG --case lower
+  toolbox file rename IMG_*.jpg --to 'holiday-{n:3}{ext}' --sort mtime
+  toolbox file rename *.jpeg --pattern '^IMG_(\d+)' --to 'photo-$1' --ext jpg
+  toolbox file rename *.log --to '{date}-{name}{ext}' --dry-run
+  toolbox file rename "My Notes.txt" --case snake
+  toolbox file rename --undo`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.setExt = cmd.Flags().Changed("ext")
+			if opts.undo {
+				if len(args) > 0 {
+					return cli.UsageErrorf("--undo takes no paths")
+				}
+				return runRenameUndo(parent, opts.dryRun)
+			}
+			if len(args) == 0 {
+				return cli.UsageErrorf("no paths given")
+			}
+			return runFileRename(parent, args, opts)
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.pattern, "pattern", "p", "", "Regular expression whose matches in each name are replaced by --to")
+	cmd.Flags().StringVarP(&opts.template, "to", "t", "", "New name or replacement, with $1 captures and {name}, {ext}, {n} and {date} tokens")
+	cmd.Flags().StringVar(&opts.caseName, "case", "", "Convert names to "+strings.Join(renameCases, ", ")+" case")
+	cmd.Flags().StringVar(&opts.ext, "ext", "", "Replace the extension, or remove it when empty")
+	cmd.Flags().IntVar(&opts.start, "start", 1, "First value of the {n} counter")
+	cmd.Flags().StringVar(&opts.sortBy, "sort", "name", "Order of the {n} counter: "+strings.Join(renameSortKeys, ", "))
+	cmd.Flags().BoolVarP(&opts.dryRun, "dry-run", "n", false, "Show the renames without applying them")
+	cmd.Flags().BoolVar(&opts.undo, "undo", false, "Restore the names changed by the last rename")
+	cmd.MarkFlagsMutuallyExclusive("undo", "pattern")
+	cmd.MarkFlagsMutuallyExclusive("undo", "to")
+	cmd.MarkFlagsMutuallyExclusive("undo", "case")
+	cmd.MarkFlagsMutuallyExclusive("undo", "ext")
+	_ = cmd.RegisterFlagCompletionFunc("case", cli.CompleteValues(renameCases...))
+	_ = cmd.RegisterFlagCompletionFunc("sort", cli.CompleteValues(renameSortKeys...))
+
+	return cmd
+}
+
+func runFileRename(cmd *cli.BaseCommand, paths []string, opts *renameOptions) error {
+	if opts.pattern == "" && opts.template == "" && opts.caseName == "" && !opts.setExt {
+		return cli.UsageErrorf("nothing to rename").WithHint("give --to, --pattern, --case or --ext")
+	}
+	sources, err := renameSources(paths, opts.sortBy)
+	if err != nil {
+		return err
+	}
+	entries, err := newNames(sources, opts)
+	if err != nil {
+		return err
+	}
+	checkRenames(entries)
+
+	return applyRenamePlan(cmd, entries, opts.dryRun, "Renamed", func(applied []RenameEntry) error {
+		return recordRenames(cmd, applied)
+	})
+}
+
+// renameSource is a path to rename with what its tokens need.
+type renameSource struct {
+	path    string
+	modTime time.Time
+	seq     int
+}
+
+// renameSources stats the paths and numbers them in sortBy order.
+func renameSources(paths []string, sortBy string) ([]renameSource, error) {
+	if !slices.Contains(renameSortKeys, sortBy) {
+		return nil, cli.UsageErrorf("unknown sort order %q", sortBy).WithSuggestions(sortBy, renameSortKeys)
+	}
+	sources := make([]renameSource, 0, len(paths))
+	seen := make(map[string]bool)
+	for _, path := range paths {
+		path = filepath.Clean(path)
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		info, err := os.Lstat(path)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, renameSource{path: path, modTime: info.ModTime()})
+	}
+
+	sort.SliceStable(sources, func(i, j int) bool {
+		if sortBy == "mtime" && !sources[i].modTime.Equal(sources[j].modTime) {
+			return sources[i].modTime.Before(sources[j].modTime)
+		}
+		return sources[i].path < sources[j].path
+	})
+	return sources, nil
+}
+
+// newNames computes the new name of each source.
+func newNames(sources []renameSource, opts *renameOptions) ([]RenameEntry, error) {
+	var re *regexp.Regexp
+	if opts.pattern != "" {
+		var err error
+		if re, err = regexp.Compile(opts.pattern); err != nil {
+			return nil, cli.WrapError(cli.KindUsage, err, "invalid --pattern")
+		}
+	}
+	if opts.caseName != "" && !slices.Contains(renameCases, opts.caseName) {
+		return nil, cli.UsageErrorf("unknown case %q", opts.caseName).WithSuggestions(opts.caseName, renameCases)
+	}
+	width := len(strconv.Itoa(opts.start + len(sources) - 1))
+
+	entries := make([]RenameEntry, len(sources))
+	for i := range sources {
+		sources[i].seq = opts.start + i
+		name, err := newName(sources[i], re, opts, width)
+		if err != nil {
+			return nil, err
+		}
+		entries[i] = RenameEntry{From: sources[i].path, To: filepath.Join(filepath.Dir(sources[i].path), name)}
+		if strings.ContainsRune(name, filepath.Separator) || name == "" || name == "." || name == ".." {
+			entries[i].To = name
+			entries[i].Status = RenameInvalid
+		}
+	}
+	return entries, nil
+}
+
+// newName applies the template, case conversion and extension change to
+// the name of source.
+func newName(source renameSource, re *regexp.Regexp, opts *renameOptions, width int) (string, error) {
+	name := filepath.Base(source.path)
+	switch {
+	case re != nil:
+		// Token values go through the regexp expansion, which must not
+		// take their dollar signs for captures
+		replacement, err := expandRenameTokens(opts.template, source, width, func(s string) string {
+			return strings.ReplaceAll(s, "$", "$$")
+		})
+		if err != nil {
+			return "", err
+		}
+		name = re.ReplaceAllString(name, replacement)
+	case opts.template != "":
+		var err error
+		if name, err = expandRenameTokens(opts.template, source, width, nil); err != nil {
+			return "", err
+		}
+	}
+
+	stem, ext := splitExt(name)
+	stem = convertCase(stem, opts.caseName)
+	if opts.setExt {
+		ext = ""
+		if e := strings.TrimPrefix(opts.ext, "."); e != "" {
+			ext = "." + e
+		}
+	}
+	return stem + ext, nil
+}
+
+// expandRenameTokens replaces the tokens of template with the values of
+// source, passed through quote when it is set.
+func expandRenameTokens(template string, source renameSource, width int, quote func(string) string) (string, error) {
+	stem, ext := splitExt(filepath.Base(source.path))
+	var expandErr error
+	expanded := renameTokenPattern.ReplaceAllStringFunc(template, func(token string) string {
+		parts := renameTokenPattern.FindStringSubmatch(token)
+		value := ""
+		switch parts[1] {
+		case "name":
+			value = stem
+		case "ext":
+			value = ext
+		case "n":
+			digits := width
+			if parts[2] != "" {
+				var err error
+				if digits, err = strconv.Atoi(parts[2]); err != nil || digits < 0 {
+					expandErr = cli.UsageErrorf("invalid counter width in %s", token)
+				}
+			}
+			value = utils.String().PadLeft(strconv.Itoa(source.seq), digits, '0')
+		case "date":
+			layout := parts[2]
+			if layout == "" {
+				layout = defaultDateLayout
+			}
+			value = source.modTime.Format(layout)
+		}
+		if quote != nil {
+			value = quote(value)
+		}
+		return value
+	})
+	return expanded, expandErr
+}
+
+// splitExt splits a name into the part before its extension and the
+// extension with its dot. The leading dot of hidden files does not start
+// an extension.
+func splitExt(name string) (string, string) {
+	ext := filepath.Ext(name)
+	if ext == name || ext == "." {
+		return name, ""
+	}
+	return strings.TrimSuffix(name, ext), ext
+}
+
+// convertCase converts a name without its extension to one of
+// renameCases.
+func convertCase(s, caseName string) string {
+	str := utils.String()
+	switch caseName {
+	case "snake":
+		return str.ToSnakeCase(s)
+	case "kebab":
+		return str.ToKebabCase(s)
+	case "camel":
+		return str.ToCamelCase(s)
+	case "lower":
+		return strings.ToLower(s)
+	case "upper":
+		return strings.ToUpper(s)
+	}
+	return s
+}
+
+// checkRenames sets the status of the entries that are still valid:
+// missing, unchanged, a conflict with another entry or an existing file,
+// part of a cycle, or ok.
+func checkRenames(entries []RenameEntry) {
+	targets := make(map[string]int)
+	sources := make(map[string]int)
+	for i, entry := range entries {
+		if _, err := os.Lstat(entry.From); entry.Status == "" && err != nil {
+			entries[i].Status = RenameMissing
+		}
+		if entries[i].Status == "" && entry.From == entry.To {
+			entries[i].Status = RenameUnchanged
+		}
+		if entries[i].Status == "" {
+			targets[entry.To]++
+			sources[entry.From] = i
+		}
+	}
+
+	for i := range entries {
+		entry := &entries[i]
+		if entry.Status != "" {
+			continue
+		}
+		_, vacated := sources[entry.To]
+		switch {
+		case targets[entry.To] > 1:
+			entry.Status = RenameConflict
+		case !vacated && occupied(entry.From, entry.To):
+			entry.Status = RenameExists
+		default:
+			entry.Status = RenameOK
+		}
+	}
+
+	for i := range entries {
+		if entries[i].Status == RenameOK && inCycle(entries, sources, i) {
+			entries[i].Status = RenameCycle
+		}
+	}
+}
+
+// occupied reports whether another file than from exists at to. A name
+// that differs only in case can refer to from itself on case-insensitive
+// file systems.
+func occupied(from, to string) bool {
+	target, err := os.Lstat(to)
+	if err != nil {
+		return false
+	}
+	source, err := os.Lstat(from)
+	return err != nil || !os.SameFile(source, target)
+}
+
+// inCycle reports whether following the renames from entry i leads back
+// to it.
+func inCycle(entries []RenameEntry, sources map[string]int, i int) bool {
+	next := entries[i].To
+	for range entries {
+		j, ok := sources[next]
+		if !ok || !entries[j].changes() {
+			return false
+		}
+		if j == i {
+			return true
+		}
+		next = entries[j].To
+	}
+	return false
+}
@@ -0,0 +1,264 @@
+package filecmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/nate3d/go-toolbox/internal/cli"
+	"github.com/nate3d/go-toolbox/internal/config"
+)
+
+const (
+	// renameJournalName is the file in the state directory that records
+	// applied renames for --undo
+	renameJournalName = "rename-journal.json"
+
+	// renameJournalLimit is the number of batches kept in the journal
+	renameJournalLimit = 20
+
+	// renameConfirmLabel is asked before renaming; scripted answers use
+	// the key apply_renames
+	renameConfirmLabel = "Apply renames"
+)
+
+// renameBatch is a journal record of the renames applied by one command,
+// with absolute paths.
+type renameBatch struct {
+	Time    time.Time     `json:"time"`
+	Renames []RenameEntry `json:"renames"`
+}
+
+// renameStep is a single os.Rename of a batch.
+type renameStep struct {
+	from string
+	to   string
+}
+
+// applyRenamePlan shows the planned renames and applies them after
+// confirmation, unless dryRun is set or an entry blocks the batch.
+// recorded is called with the applied renames, and done starts the
+// success message.
+func applyRenamePlan(cmd *cli.BaseCommand, entries []RenameEntry, dryRun bool, done string, recorded func([]RenameEntry) error) error {
+	var changes []RenameEntry
+	blocked := 0
+	for _, entry := range entries {
+		switch {
+		case entry.changes():
+			changes = append(changes, entry)
+		case entry.Status != RenameUnchanged:
+			blocked++
+		}
+	}
+
+	if cmd.Output == cli.OutputTable {
+		table := cmd.NewTable([]string{"From", "To", "Status"})
+		for _, entry := range entries {
+			table.AddRow(displayPath(entry.From), displayPath(entry.To), entry.Status)
+		}
+		table.Render()
+	}
+	printPlan := func() error {
+		_, err := cmd.PrintData(entries)
+		return err
+	}
+
+	switch {
+	case blocked > 0:
+		if err := printPlan(); err != nil {
+			return err
+		}
+		return cli.NewError(cli.KindGeneral, "%d of %d %s cannot be renamed; nothing was renamed", blocked,
+			len(entries), plural(len(entries), "entry", "entries"))
+	case len(changes) == 0:
+		cmd.PrintInfof("Nothing to rename")
+		return printPlan()
+	case dryRun:
+		cmd.PrintInfof("Dry run: %d %s not renamed", len(changes), plural(len(changes), "entry", "entries"))
+		return printPlan()
+	}
+
+	if err := confirmRenames(cmd); err != nil {
+		return err
+	}
+	if err := executeRenames(changes); err != nil {
+		return err
+	}
+	if err := recorded(changes); err != nil {
+		cmd.PrintWarnf("The undo journal could not be updated: %v", err)
+	}
+	cmd.PrintSuccessf("%s %d %s", done, len(changes), plural(len(changes), "entry", "entries"))
+	return printPlan()
+}
+
+// displayPath shortens an absolute path from the journal to a path
+// relative to the working directory when it is below it.
+func displayPath(path string) string {
+	if !filepath.IsAbs(path) {
+		return path
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+	if rel, err := filepath.Rel(cwd, path); err == nil && filepath.IsLocal(rel) {
+		return rel
+	}
+	return path
+}
+
+func confirmRenames(cmd *cli.BaseCommand) error {
+	prompt, err := cmd.Prompter()
+	if err != nil {
+		return err
+	}
+	confirmed, err := prompt.Confirm(renameConfirmLabel)
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		return cli.NewError(cli.KindCancelled, "nothing was renamed")
+	}
+	return nil
+}
+
+// executeRenames renames the entries. Entries whose source is the target
+// of another are first moved to a temporary name, so chains and cycles
+// cannot overwrite each other. When a rename fails the completed ones are
+// rolled back.
+func executeRenames(entries []RenameEntry) error {
+	targets := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		targets[entry.To] = true
+	}
+
+	var parked, final []renameStep
+	for _, entry := range entries {
+		from := entry.From
+		if targets[from] {
+			temp := filepath.Join(filepath.Dir(from), fmt.Sprintf(".%s.%d.renaming", filepath.Base(from), os.Getpid()))
+			parked = append(parked, renameStep{from: from, to: temp})
+			from = temp
+		}
+		final = append(final, renameStep{from: from, to: entry.To})
+	}
+
+	steps := append(parked, final...)
+	for i, step := range steps {
+		if err := os.Rename(step.from, step.to); err != nil {
+			if rollbackErr := rollbackRenames(steps[:i]); rollbackErr != nil {
+				return cli.WrapError(cli.KindGeneral, errors.Join(err, rollbackErr), "rename failed and could not be rolled back")
+			}
+			return cli.WrapError(cli.KindGeneral, err, "rename failed; nothing was renamed")
+		}
+	}
+	return nil
+}
+
+// rollbackRenames reverts completed steps, last first.
+func rollbackRenames(steps []renameStep) error {
+	var errs []error
+	for i := len(steps) - 1; i >= 0; i-- {
+		if err := os.Rename(steps[i].to, steps[i].from); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// recordRenames appends the applied renames to the undo journal.
+func recordRenames(cmd *cli.BaseCommand, applied []RenameEntry) error {
+	path, err := renameJournalPath(cmd)
+	if err != nil {
+		return err
+	}
+	batches, err := loadRenameJournal(path)
+	if err != nil {
+		return err
+	}
+
+	batch := renameBatch{Time: time.Now()}
+	for _, entry := range applied {
+		from, err := filepath.Abs(entry.From)
+		if err != nil {
+			return err
+		}
+		to, err := filepath.Abs(entry.To)
+		if err != nil {
+			return err
+		}
+		batch.Renames = append(batch.Renames, RenameEntry{From: from, To: to, Status: entry.Status})
+	}
+	batches = append(batches, batch)
+	if len(batches) > renameJournalLimit {
+		batches = batches[len(batches)-renameJournalLimit:]
+	}
+	return saveRenameJournal(path, batches)
+}
+
+// runRenameUndo reverts the last batch of the journal and removes it.
+func runRenameUndo(cmd *cli.BaseCommand, dryRun bool) error {
+	path, err := renameJournalPath(cmd)
+	if err != nil {
+		return cli.WrapError(cli.KindGeneral, err, "rename journal unavailable")
+	}
+	batches, err := loadRenameJournal(path)
+	if err != nil {
+		return err
+	}
+	if len(batches) == 0 {
+		return cli.NewError(cli.KindNotFound, "there are no renames to undo")
+	}
+
+	last := batches[len(batches)-1]
+	entries := make([]RenameEntry, 0, len(last.Renames))
+	for i := len(last.Renames) - 1; i >= 0; i-- {
+		entries = append(entries, RenameEntry{From: last.Renames[i].To, To: last.Renames[i].From})
+	}
+	checkRenames(entries)
+
+	cmd.PrintHeaderf("Undo renames of %s", last.Time.Format(time.DateTime))
+	return applyRenamePlan(cmd, entries, dryRun, "Restored", func([]RenameEntry) error {
+		return saveRenameJournal(path, batches[:len(batches)-1])
+	})
+}
+
+func renameJournalPath(cmd *cli.BaseCommand) (string, error) {
+	dir, err := config.GetStateDir(cmd.Root().Name())
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, renameJournalName), nil
+}
+
+// loadRenameJournal reads the journal at path; a missing journal is empty.
+func loadRenameJournal(path string) ([]renameBatch, error) {
+	data, err := os.ReadFile(path) // #nosec G304 - the journal lives in the state directory
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var batches []renameBatch
+	if err := json.Unmarshal(data, &batches); err != nil {
+		return nil, cli.WrapError(cli.KindGeneral, err, "invalid rename journal %s", path)
+	}
+	return batches, nil
+}
+
+// saveRenameJournal replaces the journal at path atomically.
+func saveRenameJournal(path string, batches []renameBatch) error {
+	data, err := json.MarshalIndent(batches, "", "  ")
+	if err != nil {
+		return err
+	}
+	temp := path + ".tmp"
+	if err := os.WriteFile(temp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(temp, path)
+}
@@ -0,0 +1,185 @@
+package filecmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/nate3d/go-toolbox/internal/cli"
+)
+
+func TestNewName(t *testing.T) {
+	modTime := time.Date(2024, 3, 9, 14, 30, 0, 0, time.Local)
+	tests := []struct {
+		path string
+		opts renameOptions
+		want string
+	}{
+		{"dir/My Notes.txt", renameOptions{caseName: "snake"}, "my_notes.txt"},
+		{"Holiday Photo.JPG", renameOptions{caseName: "kebab", ext: "jpg", setExt: true}, "holiday-photo.jpg"},
+		{"report_final.md", renameOptions{caseName: "camel"}, "reportFinal.md"},
+		{"IMG_0042.jpeg", renameOptions{pattern: `^IMG_(\d+)`, template: "photo-$1"}, "photo-0042.jpeg"},
+		{"IMG_0042.jpeg", renameOptions{pattern: `^IMG_(?P<num>\d+)`, template: "${num}-{n:3}"}, "0042-007.jpeg"},
+		{"a.log", renameOptions{template: "{date}-{name}{ext}"}, "2024-03-09-a.log"},
+		{"a.log", renameOptions{template: "{name}-{date:150405}{ext}"}, "a-143000.log"},
+		{"a.log", renameOptions{template: "{n}{ext}"}, "07.log"},
+		{"$x.txt", renameOptions{pattern: "txt$", template: "{name}"}, "$x.$x"},
+		{".bashrc", renameOptions{caseName: "upper"}, ".BASHRC"},
+		{"archive.tar.gz", renameOptions{ext: "", setExt: true}, "archive.tar"},
+		{"README", renameOptions{ext: ".md", setExt: true}, "README.md"},
+	}
+	for _, tt := range tests {
+		var re *regexp.Regexp
+		if tt.opts.pattern != "" {
+			re = regexp.MustCompile(tt.opts.pattern)
+		}
+		source := renameSource{path: tt.path, modTime: modTime, seq: 7}
+		got, err := newName(source, re, &tt.opts, 2)
+		if err != nil {
+			t.Errorf("newName(%q): %v", tt.path, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("newName(%q, %+v) = %q, want %q", tt.path, tt.opts, got, tt.want)
+		}
+	}
+
+	if _, err := newName(renameSource{path: "a"}, nil, &renameOptions{template: "{n:x}"}, 1); cli.ExitCode(err) != cli.ExitUsage {
+		t.Errorf("invalid width: error = %v, want a usage error", err)
+	}
+}
+
+func TestConvertCase(t *testing.T) {
+	tests := []struct {
+		input, caseName, want string
+	}{
+		{"HelloWorld", "snake", "hello_world"},
+		{"Holiday Photo-2024", "snake", "holiday_photo_2024"},
+		{"HTTPServer", "snake", "http_server"},
+		{"file2Name", "snake", "file2_name"},
+		{"parseJSONData", "kebab", "parse-json-data"},
+		{"my_file name", "kebab", "my-file-name"},
+		{"my_fileName", "camel", "myFileName"},
+		{"HELLO WORLD", "camel", "helloWorld"},
+		{"", "camel", ""},
+	}
+	for _, tt := range tests {
+		if got := convertCase(tt.input, tt.caseName); got != tt.want {
+			t.Errorf("convertCase(%q, %s) = %q, want %q", tt.input, tt.caseName, got, tt.want)
+		}
+	}
+}
+
+func TestCheckRenames(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a": "", "b": "", "c": "", "d": "", "e": "", "f": "", "kept": ""})
+	path := func(name string) string { return filepath.Join(dir, name) }
+
+	entries := []RenameEntry{
+		{From: path("a"), To: path("b")},
+		{From: path("b"), To: path("a")},
+		{From: path("c"), To: path("x")},
+		{From: path("d"), To: path("x")},
+		{From: path("e"), To: path("kept")},
+		{From: path("f"), To: path("f")},
+		{From: path("gone"), To: path("g")},
+		{From: path("kept"), To: "a/b", Status: RenameInvalid},
+	}
+	checkRenames(entries)
+	var statuses []string
+	for _, entry := range entries {
+		statuses = append(statuses, entry.Status)
+	}
+	want := []string{
+		RenameCycle, RenameCycle, RenameConflict, RenameConflict,
+		RenameExists, RenameUnchanged, RenameMissing, RenameInvalid,
+	}
+	if !reflect.DeepEqual(statuses, want) {
+		t.Errorf("statuses = %q, want %q", statuses, want)
+	}
+}
+
+func TestExecuteRenamesChainAndCycle(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"1": "one", "2": "two", "3": "three"})
+	path := func(name string) string { return filepath.Join(dir, name) }
+
+	// 1 -> 2 -> 3 -> 4 is a chain, then 4 and 2 swap
+	entries := []RenameEntry{
+		{From: path("1"), To: path("2")},
+		{From: path("2"), To: path("3")},
+		{From: path("3"), To: path("4")},
+	}
+	if err := executeRenames(entries); err != nil {
+		t.Fatal(err)
+	}
+	if err := executeRenames([]RenameEntry{{From: path("4"), To: path("2")}, {From: path("2"), To: path("4")}}); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{"2": "three", "3": "two", "4": "one"} {
+		if data, err := os.ReadFile(path(name)); err != nil || string(data) != want {
+			t.Errorf("%s = %q, %v, want %q", name, data, err, want)
+		}
+	}
+	if _, err := os.Stat(path("1")); !os.IsNotExist(err) {
+		t.Errorf("1 still exists: %v", err)
+	}
+}
+
+func TestRunFileRenameUndo(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"Photo One.JPG": "1", "Photo Two.JPG": "2"})
+	names := func() []string {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, entry := range entries {
+			got = append(got, entry.Name())
+		}
+		sort.Strings(got)
+		return got
+	}
+
+	cmd, _ := newTestCommand(cli.OutputTable)
+	cmd.SetPrompter(cli.NewFakePrompter(map[string]string{renameConfirmLabel: "yes"}))
+	paths := []string{filepath.Join(dir, "Photo Two.JPG"), filepath.Join(dir, "Photo One.JPG")}
+	opts := &renameOptions{template: "{n}-{name}{ext}", caseName: "kebab", ext: "jpg", setExt: true, start: 1, sortBy: "name"}
+	if err := runFileRename(cmd, paths, opts); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := names(), []string{"1-photo-one.jpg", "2-photo-two.jpg"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("renamed = %q, want %q", got, want)
+	}
+
+	if err := runRenameUndo(cmd, true); err != nil {
+		t.Fatal(err)
+	}
+	if got := names(); got[0] != "1-photo-one.jpg" {
+		t.Errorf("dry run undo renamed: %q", got)
+	}
+	if err := runRenameUndo(cmd, false); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := names(), []string{"Photo One.JPG", "Photo Two.JPG"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("restored = %q, want %q", got, want)
+	}
+	if err := runRenameUndo(cmd, false); cli.ExitCode(err) != cli.ExitNotFound {
+		t.Errorf("second undo: error = %v, want not found", err)
+	}
+
+	declined, _ := newTestCommand(cli.OutputTable)
+	declined.SetPrompter(cli.NewFakePrompter(map[string]string{renameConfirmLabel: "no"}))
+	if err := runFileRename(declined, paths, opts); err == nil {
+		t.Error("declined rename succeeded")
+	}
+	if got := names(); got[0] != "Photo One.JPG" {
+		t.Errorf("declined rename renamed: %q", got)
+	}
+}
@@ -0,0 +1,226 @@
+package filecmd
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/nate3d/go-toolbox/internal/cli"
+)
+
+const (
+	// limiterSlices is the number of writes per second of a bandwidth
+	// limit, so the rate stays even within large reads
+	limiterSlices = 10
+
+	// minLimitedWrite is the smallest write under a bandwidth limit
+	minLimitedWrite = 4 << 10
+)
+
+// syncer applies the actions of a sync plan.
+type syncer struct {
+	cmd      *cli.BaseCommand
+	plan     *syncPlan
+	limiter  *bandwidthLimiter
+	progress *cli.ProgressBar
+	buffer   []byte
+	// metadataWarned is set once a failure to set permissions or times,
+	// common on network shares, has been reported
+	metadataWarned bool
+}
+
+// applySync makes the changes of plan, after confirming deletes. Failed
+// actions are marked and added to the plan's failures.
+func applySync(cmd *cli.BaseCommand, plan *syncPlan, limiter *bandwidthLimiter) error {
+	if err := confirmSyncDeletes(cmd, plan); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(plan.dst, 0o750); err != nil {
+		return err
+	}
+
+	var total int64
+	for _, action := range plan.actions {
+		if action.Action != SyncDelete && action.info.Mode().IsRegular() {
+			total += action.Size
+		}
+	}
+	s := &syncer{cmd: cmd, plan: plan, limiter: limiter, progress: newBytesProgress(total, "Syncing"), buffer: make([]byte, copyBufferSize)}
+	for i := range plan.actions {
+		action := &plan.actions[i]
+		if err := s.apply(action); err != nil {
+			action.Error = err.Error()
+			plan.failures = append(plan.failures, err)
+		}
+	}
+	if s.progress != nil {
+		s.progress.Finish()
+	}
+	s.finishDirs()
+	return nil
+}
+
+func (s *syncer) apply(action *SyncAction) error {
+	target := filepath.Join(s.plan.dst, filepath.FromSlash(action.Path))
+	if action.Action == SyncDelete {
+		return os.RemoveAll(target)
+	}
+	if action.Action == SyncUpdate && action.Reason == "type" {
+		// Directories in the way were deleted by their own action
+		if err := os.Remove(target); err != nil {
+			return err
+		}
+	}
+
+	source := filepath.Join(s.plan.src, filepath.FromSlash(action.Path))
+	switch {
+	case action.info.IsDir():
+		// Permissions and times are set by finishDirs
+		return os.Mkdir(target, 0o750)
+	case action.link != "":
+		return s.replaceLink(action.link, target)
+	default:
+		return s.copyFile(source, target, action.info)
+	}
+}
+
+// copyFile copies source to a temporary file next to target, sets its
+// metadata and renames it into place.
+func (s *syncer) copyFile(source, target string, info fs.FileInfo) error {
+	in, err := os.Open(source) // #nosec G304 - syncing user-specified trees is the point
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	temp, err := os.CreateTemp(filepath.Dir(target), "."+filepath.Base(target)+".*.sync")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+
+	var r io.Reader = in
+	if s.progress != nil {
+		r = &progressReader{reader: in, bar: s.progress}
+	}
+	var w io.Writer = temp
+	if s.limiter != nil {
+		w = &limitedWriter{writer: temp, limiter: s.limiter}
+	}
+	if _, err := io.CopyBuffer(w, r, s.buffer); err != nil {
+		_ = temp.Close()
+		return err
+	}
+	// Network file systems may only report write errors on sync or close
+	if err := temp.Sync(); err != nil {
+		_ = temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	s.setMetadata(temp.Name(), info)
+	return os.Rename(temp.Name(), target)
+}
+
+// replaceLink points the symbolic link target at link, replacing any
+// existing link in one rename.
+func (s *syncer) replaceLink(link, target string) error {
+	temp := filepath.Join(filepath.Dir(target), "."+filepath.Base(target)+".sync-link")
+	_ = os.Remove(temp)
+	if err := os.Symlink(link, temp); err != nil {
+		return err
+	}
+	if err := os.Rename(temp, target); err != nil {
+		_ = os.Remove(temp)
+		return err
+	}
+	return nil
+}
+
+// setMetadata copies the permissions and modification time of info to
+// path. Failures are reported once and do not fail the sync, since some
+// network shares do not keep them.
+func (s *syncer) setMetadata(path string, info fs.FileInfo) {
+	err := os.Chmod(path, info.Mode().Perm())
+	if err == nil {
+		err = os.Chtimes(path, info.ModTime(), info.ModTime())
+	}
+	if err != nil && !s.metadataWarned {
+		s.metadataWarned = true
+		s.cmd.PrintWarnf("Cannot preserve permissions or times in %s: %v", s.plan.dst, err)
+	}
+}
+
+// finishDirs sets the metadata of the synced directories, deepest first,
+// since syncing their contents changed the times.
+func (s *syncer) finishDirs() {
+	for i := len(s.plan.dirs) - 1; i >= 0; i-- {
+		dir := s.plan.dirs[i]
+		s.setMetadata(filepath.Join(s.plan.dst, filepath.FromSlash(dir.name)), dir.info)
+	}
+}
+
+// bandwidthLimiter paces writes to an average rate in bytes per second.
+type bandwidthLimiter struct {
+	rate  int64
+	start time.Time
+	sent  int64
+	now   func() time.Time
+	sleep func(time.Duration)
+}
+
+// newBandwidthLimiter parses --bwlimit; an empty or zero value means no
+// limit and returns nil.
+func newBandwidthLimiter(limit string) (*bandwidthLimiter, error) {
+	if limit == "" {
+		return nil, nil
+	}
+	rate, err := cli.ParseSize(limit)
+	if err != nil {
+		return nil, cli.WrapError(cli.KindUsage, err, "invalid --bwlimit")
+	}
+	if rate <= 0 {
+		return nil, nil
+	}
+	return &bandwidthLimiter{rate: rate, now: time.Now, sleep: time.Sleep}, nil
+}
+
+// wait records n bytes sent and sleeps while the average rate is above
+// the limit.
+func (l *bandwidthLimiter) wait(n int) {
+	if l.start.IsZero() {
+		l.start = l.now()
+	}
+	l.sent += int64(n)
+	due := time.Duration(float64(l.sent) / float64(l.rate) * float64(time.Second))
+	if ahead := due - l.now().Sub(l.start); ahead > 0 {
+		l.sleep(ahead)
+	}
+}
+
+// chunk returns the size of the writes under the limit.
+func (l *bandwidthLimiter) chunk() int {
+	return int(max(l.rate/limiterSlices, minLimitedWrite))
+}
+
+// limitedWriter writes through a bandwidth limiter.
+type limitedWriter struct {
+	writer  io.Writer
+	limiter *bandwidthLimiter
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		n, err := w.writer.Write(p[:min(len(p), w.limiter.chunk())])
+		written += n
+		w.limiter.wait(n)
+		if err != nil {
+			return written, err
+		}
+		p = p[n:]
+	}
+	return written, nil
+}
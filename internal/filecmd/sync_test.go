@@ -0,0 +1,241 @@
+package filecmd
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nate3d/go-toolbox/internal/cli"
+)
+
+// syncActions returns "action path (reason)" for each action.
+func syncActions(plan *syncPlan) []string {
+	var got []string
+	for _, action := range plan.actions {
+		got = append(got, action.Action+" "+action.Path+" ("+action.Reason+")")
+	}
+	return got
+}
+
+func TestPlanSync(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	writeFiles(t, src, map[string]string{
+		"same.txt":    "same",
+		"grown.txt":   "longer",
+		"touched.txt": "abc",
+		"edited.txt":  "new",
+		"dir/new.txt": "new",
+		"skip.tmp":    "tmp",
+	})
+	writeFiles(t, dst, map[string]string{
+		"same.txt":      "same",
+		"grown.txt":     "short",
+		"touched.txt":   "abc",
+		"edited.txt":    "old",
+		"extra/a.txt":   "a",
+		"old.tmp":       "tmp",
+		"dir/stale.txt": "s",
+	})
+	// Equal times, except for touched.txt
+	stamp := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for _, name := range []string{"same.txt", "grown.txt", "edited.txt"} {
+		for _, root := range []string{src, dst} {
+			if err := os.Chtimes(filepath.Join(root, name), stamp, stamp); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := os.Chtimes(filepath.Join(dst, "touched.txt"), stamp, stamp); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd, _ := newTestCommand(cli.OutputTable)
+	opts := &syncOptions{delete: true, modifyWindow: time.Second, filter: archiveFilter{exclude: []string{"*.tmp"}}}
+	plan, err := planSync(cmd, src, dst, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"delete dir/stale.txt (extra)",
+		"delete extra (extra)",
+		"create dir/new.txt (missing)",
+		"update grown.txt (size)",
+		"update touched.txt (modified)",
+	}
+	if got := syncActions(plan); !reflect.DeepEqual(got, want) {
+		t.Errorf("actions = %q, want %q", got, want)
+	}
+
+	opts.checksum, opts.delete = true, false
+	plan, err = planSync(cmd, src, dst, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []string{"create dir/new.txt (missing)", "update edited.txt (checksum)", "update grown.txt (size)"}
+	if got := syncActions(plan); !reflect.DeepEqual(got, want) {
+		t.Errorf("checksum actions = %q, want %q", got, want)
+	}
+}
+
+func TestRunFileSync(t *testing.T) {
+	src := t.TempDir()
+	dst := filepath.Join(t.TempDir(), "mirror")
+	writeFiles(t, src, map[string]string{"a.txt": "alpha", "sub/b.txt": "beta", ".hidden": "h"})
+	if err := os.Chmod(filepath.Join(src, "a.txt"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Date(2021, 6, 7, 8, 9, 10, 0, time.UTC)
+	if err := os.Chtimes(filepath.Join(src, "sub/b.txt"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("a.txt", filepath.Join(src, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd, _ := newTestCommand(cli.OutputTable)
+	if err := runFileSync(cmd, src, dst, &syncOptions{dryRun: true}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(dst); !os.IsNotExist(err) {
+		t.Fatalf("dry run created the destination: %v", err)
+	}
+
+	if err := runFileSync(cmd, src, dst, &syncOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(filepath.Join(dst, "a.txt")); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("a.txt = %v, %v", info, err)
+	}
+	if info, err := os.Stat(filepath.Join(dst, "sub/b.txt")); err != nil || !info.ModTime().Equal(old) {
+		t.Errorf("sub/b.txt = %v, %v", info, err)
+	}
+	if target, err := os.Readlink(filepath.Join(dst, "link")); err != nil || target != "a.txt" {
+		t.Errorf("link -> %q, %v", target, err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dst, ".hidden")); err != nil || string(data) != "h" {
+		t.Errorf(".hidden = %q, %v", data, err)
+	}
+
+	plan, err := planSync(cmd, src, dst, &syncOptions{delete: true})
+	if err != nil || len(plan.actions) > 0 {
+		t.Errorf("second plan = %q, %v", syncActions(plan), err)
+	}
+}
+
+func TestRunFileSyncDelete(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	writeFiles(t, src, map[string]string{"keep": "k", "file-or-dir": "now a file"})
+	writeFiles(t, dst, map[string]string{"keep": "k", "extra": "x", "file-or-dir/inner": "i"})
+
+	declined, _ := newTestCommand(cli.OutputTable)
+	declined.SetPrompter(cli.NewFakePrompter(map[string]string{syncDeleteConfirmLabel: "no"}))
+	if err := runFileSync(declined, src, dst, &syncOptions{delete: true}); err == nil {
+		t.Error("declined sync succeeded")
+	}
+	if _, err := os.Stat(filepath.Join(dst, "extra")); err != nil {
+		t.Errorf("declined sync deleted: %v", err)
+	}
+
+	// Without --delete a directory in the way of a file is kept
+	cmd, _ := newTestCommand(cli.OutputTable)
+	if err := runFileSync(cmd, src, dst, &syncOptions{}); err == nil {
+		t.Error("sync over a directory succeeded without --delete")
+	}
+
+	cmd.SetPrompter(cli.NewFakePrompter(map[string]string{syncDeleteConfirmLabel: "yes"}))
+	if err := runFileSync(cmd, src, dst, &syncOptions{delete: true}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "extra")); !os.IsNotExist(err) {
+		t.Errorf("extra still exists: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dst, "file-or-dir")); err != nil || string(data) != "now a file" {
+		t.Errorf("file-or-dir = %q, %v", data, err)
+	}
+}
+
+func TestRunFileSyncConfirmsReplacedDirs(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	writeFiles(t, src, map[string]string{"x": "now a file"})
+	writeFiles(t, dst, map[string]string{"x/important/file": "keep"})
+
+	cmd, _ := newTestCommand(cli.OutputTable)
+	plan, err := planSync(cmd, src, dst, &syncOptions{delete: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := syncActions(plan), []string{"delete x (type)", "create x (type)"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("actions = %q, want %q", got, want)
+	}
+
+	// Unanswered confirmations fail instead of deleting
+	cmd.SetPrompter(cli.NewFakePrompter(map[string]string{}))
+	if err := runFileSync(cmd, src, dst, &syncOptions{delete: true}); err == nil {
+		t.Error("replacing a directory succeeded without confirmation")
+	}
+	if _, err := os.Stat(filepath.Join(dst, "x/important/file")); err != nil {
+		t.Errorf("directory contents deleted: %v", err)
+	}
+}
+
+func TestCheckSyncPaths(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"src/a": "", "file": ""})
+	tests := []struct {
+		src, dst string
+		ok       bool
+	}{
+		{"src", "dst", true},
+		{"src", "src", false},
+		{"src", "src/inner", false},
+		{"src", ".", false},
+		{"file", "dst", false},
+	}
+	for _, tt := range tests {
+		err := checkSyncPaths(filepath.Join(dir, tt.src), filepath.Join(dir, tt.dst))
+		if (err == nil) != tt.ok {
+			t.Errorf("checkSyncPaths(%q, %q) = %v", tt.src, tt.dst, err)
+		}
+	}
+}
+
+func TestBandwidthLimiter(t *testing.T) {
+	clock := time.Unix(0, 0)
+	var slept time.Duration
+	limiter := &bandwidthLimiter{
+		rate: 1000,
+		now:  func() time.Time { return clock },
+		sleep: func(d time.Duration) {
+			slept += d
+			clock = clock.Add(d)
+		},
+	}
+	w := &limitedWriter{writer: io.Discard, limiter: limiter}
+	if _, err := w.Write(make([]byte, 2500)); err != nil {
+		t.Fatal(err)
+	}
+	if slept != 2500*time.Millisecond {
+		t.Errorf("slept %v for 2500 bytes at 1000 B/s", slept)
+	}
+
+	if limiter, err := newBandwidthLimiter("0"); err != nil || limiter != nil {
+		t.Errorf("newBandwidthLimiter(0) = %v, %v", limiter, err)
+	}
+	if _, err := newBandwidthLimiter("fast"); cli.ExitCode(err) != cli.ExitUsage {
+		t.Errorf("invalid limit: error = %v, want a usage error", err)
+	}
+}
+
+func TestSyncModifyWindowFlag(t *testing.T) {
+	parent, _ := newTestCommand(cli.OutputTable)
+	cmd := newSyncCommand(parent)
+	if err := cmd.Flags().Parse([]string{"--modify-window", "1m 30s"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := cmd.Flags().Lookup("modify-window").Value.String(); got != "1m 30s" {
+		t.Errorf("--modify-window = %q, want 1m 30s", got)
+	}
+}
@@ -0,0 +1,301 @@
+package filecmd
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nate3d/go-toolbox/internal/cli"
+	"github.com/nate3d/go-toolbox/internal/config"
+	"github.com/nate3d/go-toolbox/internal/theme"
+)
+
+// Sort orders of "file tree"
+const (
+	TreeSortName  = "name"
+	TreeSortSize  = "size"
+	TreeSortMtime = "mtime"
+)
+
+// treeSortKeys are the values accepted by --sort
+var treeSortKeys = []string{TreeSortName, TreeSortSize, TreeSortMtime}
+
+// treeConnectors are the line prefixes drawing the tree.
+type treeConnectors struct {
+	branch, last, pipe, space string
+}
+
+var (
+	unicodeConnectors = treeConnectors{"├── ", "└── ", "│   ", "    "}
+	asciiConnectors   = treeConnectors{"|-- ", "`-- ", "|   ", "    "}
+)
+
+// TreeOptions control how a directory tree is built and drawn.
+type TreeOptions struct {
+	// Depth limits the levels shown below the root; 0 shows all
+	Depth      int
+	SortBy     string
+	Reverse    bool
+	DirsOnly   bool
+	Gitignore  bool
+	ShowHidden bool
+	// Sizes shows sizes; directory sizes then include entries below Depth
+	Sizes bool
+	ASCII bool
+}
+
+// TreeNode is one entry of a directory tree. The size of a directory is
+// the total size of the entries below it.
+type TreeNode struct {
+	Name     string      `json:"name"               yaml:"name"`
+	Type     string      `json:"type"               yaml:"type"`
+	Size     int64       `json:"size"               yaml:"size"`
+	Modified time.Time   `json:"modified"           yaml:"modified"`
+	Target   string      `json:"target,omitempty"   yaml:"target,omitempty"`
+	Error    string      `json:"error,omitempty"    yaml:"error,omitempty"`
+	Children []*TreeNode `json:"children,omitempty" yaml:"children,omitempty"`
+}
+
+func newTreeCommand(parent *cli.BaseCommand) *cobra.Command {
+	opts := TreeOptions{}
+	cmd := &cobra.Command{
+		Use:   "tree [dir]",
+		Short: "Show a directory tree",
+		Long: `Show the contents of a directory (default: the current one) as a tree.
+
+--size shows the size of each file and the total size of each directory,
+including entries below the --depth limit. Entries are sorted by name, by
+size (largest first) or by modification time (newest first); --reverse
+flips the order. --gitignore hides what the .gitignore files of the tree
+and its git work tree exclude.
+
+Hidden entries are shown with --all, which defaults to the file.show_hidden
+setting. JSON and YAML output is a nested document with sizes and times.`,
+		Example: `  toolbox file tree
+  toolbox file tree src --depth 2 --dirs-only
+  toolbox file tree --size --sort size --gitignore
+  toolbox file tree --ascii > TREE.txt
+  toolbox file tree ./docs --output json`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !cmd.Flags().Changed("all") {
+				opts.ShowHidden = config.GetBool("file.show_hidden")
+			}
+			dir := "."
+			if len(args) > 0 {
+				dir = args[0]
+			}
+			return runFileTree(parent, dir, opts)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.ASCII, "ascii", false, "Draw the tree with ASCII characters")
+	cmd.Flags().IntVarP(&opts.Depth, "depth", "L", 0, "Levels shown below the directory (0 for all)")
+	cmd.Flags().BoolVarP(&opts.Sizes, "size", "s", false, "Show file sizes and total directory sizes")
+	cmd.Flags().StringVar(&opts.SortBy, "sort", TreeSortName, "Sort by "+strings.Join(treeSortKeys, ", "))
+	cmd.Flags().BoolVarP(&opts.Reverse, "reverse", "r", false, "Reverse the sort order")
+	cmd.Flags().BoolVarP(&opts.DirsOnly, "dirs-only", "d", false, "Show directories only")
+	cmd.Flags().BoolVar(&opts.Gitignore, "gitignore", false, "Hide entries excluded by .gitignore files")
+	cmd.Flags().BoolVarP(&opts.ShowHidden, "all", "a", false, "Show hidden entries (default: file.show_hidden)")
+	_ = cmd.RegisterFlagCompletionFunc("sort", cli.CompleteValues(treeSortKeys...))
+
+	parent.AddWatchFlag(cmd)
+
+	return cmd
+}
+
+func runFileTree(cmd *cli.BaseCommand, dir string, opts TreeOptions) error {
+	if cmd.Output != cli.OutputTable {
+		// Structured output always carries complete sizes
+		opts.Sizes = true
+	}
+	root, err := BuildTree(dir, opts)
+	if err != nil {
+		return err
+	}
+
+	if printed, err := cmd.PrintData(root); printed {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	RenderTree(out, root, opts)
+	dirs, files := countTree(root)
+	summary := fmt.Sprintf("%d %s, %d %s", dirs, plural(dirs, "directory", "directories"), files, plural(files, "file", "files"))
+	if opts.Sizes {
+		summary += ", " + cli.FormatSize(root.Size)
+	}
+	_, _ = fmt.Fprintf(out, "\n%s\n", summary)
+	return nil
+}
+
+// BuildTree reads the tree below dir. Directories that cannot be read
+// carry an error instead of children.
+func BuildTree(dir string, opts TreeOptions) (*TreeNode, error) {
+	if !slices.Contains(treeSortKeys, opts.SortBy) {
+		return nil, cli.UsageErrorf("unknown sort order %q", opts.SortBy).WithSuggestions(opts.SortBy, treeSortKeys)
+	}
+	if opts.Depth < 0 {
+		return nil, cli.UsageErrorf("--depth must not be negative")
+	}
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	root := &TreeNode{Name: dir, Type: fileType(info.Mode()), Size: info.Size(), Modified: info.ModTime()}
+	if info.IsDir() {
+		var ignore *ignoreMatcher
+		if opts.Gitignore {
+			ignore = newIgnoreMatcher(dir)
+		}
+		root.Size = 0
+		fillTree(root, dir, ignore, 1, opts)
+	}
+	return root, nil
+}
+
+// fillTree adds the entries of the directory at path, which are at the
+// given depth, to node and totals their sizes.
+func fillTree(node *TreeNode, path string, ignore *ignoreMatcher, depth int, opts TreeOptions) {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		node.Error = err.Error()
+		return
+	}
+	ignore = ignore.withDir(path)
+	shown := opts.Depth == 0 || depth <= opts.Depth
+
+	for _, entry := range entries {
+		name := entry.Name()
+		childPath := filepath.Join(path, name)
+		if (!opts.ShowHidden && isHidden(name)) || ignore.ignored(childPath, entry.IsDir()) {
+			continue
+		}
+		child := newTreeNode(childPath, entry)
+		if entry.IsDir() && (opts.Sizes || opts.Depth == 0 || depth < opts.Depth) {
+			fillTree(child, childPath, ignore, depth+1, opts)
+		}
+		node.Size += child.Size
+		if shown && (!opts.DirsOnly || entry.IsDir()) {
+			node.Children = append(node.Children, child)
+		}
+	}
+	sortTree(node.Children, opts.SortBy, opts.Reverse)
+}
+
+func newTreeNode(path string, entry fs.DirEntry) *TreeNode {
+	node := &TreeNode{Name: entry.Name(), Type: fileType(entry.Type())}
+	info, err := entry.Info()
+	if err != nil {
+		node.Error = err.Error()
+		return node
+	}
+	node.Modified = info.ModTime()
+	switch {
+	case info.IsDir():
+		// Filled in with the sizes of the entries below
+	case info.Mode()&fs.ModeSymlink != 0:
+		node.Size = info.Size()
+		node.Target, _ = os.Readlink(path)
+	default:
+		node.Size = info.Size()
+	}
+	return node
+}
+
+// sortTree orders entries by name, by size with the largest first or by
+// modification time with the newest first. Ties are sorted by name.
+func sortTree(nodes []*TreeNode, sortBy string, reverse bool) {
+	less := func(a, b *TreeNode) bool {
+		switch {
+		case sortBy == TreeSortSize && a.Size != b.Size:
+			return a.Size > b.Size
+		case sortBy == TreeSortMtime && !a.Modified.Equal(b.Modified):
+			return a.Modified.After(b.Modified)
+		}
+		if la, lb := strings.ToLower(a.Name), strings.ToLower(b.Name); la != lb {
+			return la < lb
+		}
+		return a.Name < b.Name
+	}
+	sort.SliceStable(nodes, func(i, j int) bool {
+		if reverse {
+			return less(nodes[j], nodes[i])
+		}
+		return less(nodes[i], nodes[j])
+	})
+}
+
+// RenderTree draws root and its children with connector lines.
+func RenderTree(w io.Writer, root *TreeNode, opts TreeOptions) {
+	connectors := unicodeConnectors
+	if opts.ASCII {
+		connectors = asciiConnectors
+	}
+	_, _ = fmt.Fprintln(w, treeLabel(root, opts))
+	renderChildren(w, root, "", connectors, opts)
+}
+
+func renderChildren(w io.Writer, node *TreeNode, prefix string, connectors treeConnectors, opts TreeOptions) {
+	for i, child := range node.Children {
+		branch, indent := connectors.branch, connectors.pipe
+		if i == len(node.Children)-1 {
+			branch, indent = connectors.last, connectors.space
+		}
+		_, _ = fmt.Fprintln(w, prefix+branch+treeLabel(child, opts))
+		renderChildren(w, child, prefix+indent, connectors, opts)
+	}
+}
+
+// treeLabel is the line of one entry: its size, its name and, for links,
+// the target.
+func treeLabel(node *TreeNode, opts TreeOptions) string {
+	palette := theme.Current()
+	var label strings.Builder
+	if opts.Sizes {
+		label.WriteString(palette.Sprint(theme.Muted, fmt.Sprintf("[%9s]", cli.FormatSize(node.Size))) + "  ")
+	}
+	switch node.Type {
+	case "directory":
+		label.WriteString(palette.Sprint(theme.Accent, node.Name))
+	case "symlink":
+		label.WriteString(palette.Sprint(theme.Info, node.Name) + " -> " + node.Target)
+	default:
+		label.WriteString(node.Name)
+	}
+	if node.Error != "" {
+		label.WriteString(palette.Sprint(theme.Error, " ["+node.Error+"]"))
+	}
+	return label.String()
+}
+
+// countTree counts the directories and other entries shown below root.
+func countTree(root *TreeNode) (int, int) {
+	dirs, files := 0, 0
+	for _, child := range root.Children {
+		if child.Type == "directory" {
+			dirs++
+		} else {
+			files++
+		}
+		d, f := countTree(child)
+		dirs, files = dirs+d, files+f
+	}
+	return dirs, files
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
@@ -0,0 +1,113 @@
+package filecmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nate3d/go-toolbox/internal/cli"
+)
+
+func newTreeFixture(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"b.txt":           "bb",
+		"a.txt":           "a",
+		"src/main.go":     "package main\n",
+		"src/deep/x.go":   "package deep\n",
+		".hidden/secret":  "s",
+		"build/out.bin":   strings.Repeat("x", 100),
+		".gitignore":      "build/\n",
+		"src/deep/y.tmp":  "tmp",
+		"src/.gitignore":  "*.tmp\n",
+		"empty/.keep":     "",
+		"src/deep/z.data": "zz",
+	})
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, "b.txt"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func nodeNames(nodes []*TreeNode) []string {
+	var result []string
+	for _, node := range nodes {
+		result = append(result, node.Name)
+	}
+	return result
+}
+
+func TestBuildTree(t *testing.T) {
+	dir := newTreeFixture(t)
+
+	root, err := BuildTree(dir, TreeOptions{SortBy: TreeSortName})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(nodeNames(root.Children), ","); got != "a.txt,b.txt,build,empty,src" {
+		t.Errorf("children = %s", got)
+	}
+
+	root, err = BuildTree(dir, TreeOptions{SortBy: TreeSortSize, Gitignore: true, ShowHidden: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(nodeNames(root.Children), ","); got != "src,.gitignore,b.txt,.hidden,a.txt,empty" {
+		t.Errorf("children sorted by size = %s", got)
+	}
+	// src holds main.go (13), deep/x.go (13), deep/z.data (2) and its .gitignore (6)
+	if src := root.Children[0]; src.Size != 34 {
+		t.Errorf("src size = %d, want 34", src.Size)
+	}
+
+	root, err = BuildTree(dir, TreeOptions{SortBy: TreeSortMtime, Reverse: true, Depth: 1, DirsOnly: true, Sizes: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, child := range root.Children {
+		if child.Type != "directory" || len(child.Children) != 0 {
+			t.Errorf("dirs-only child %+v", child)
+		}
+	}
+	if root.Size != 1+2+100+13+13+3+2 {
+		t.Errorf("root size with depth 1 = %d", root.Size)
+	}
+
+	if _, err := BuildTree(dir, TreeOptions{SortBy: "colour"}); cli.ExitCode(err) != cli.ExitUsage {
+		t.Errorf("unknown sort error = %v", err)
+	}
+	if _, err := BuildTree(filepath.Join(dir, "missing"), TreeOptions{SortBy: TreeSortName}); cli.ExitCode(err) != cli.ExitNotFound {
+		t.Errorf("missing dir error = %v", err)
+	}
+}
+
+func TestRunFileTree(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.txt": "a", "sub/b.txt": "bb", "sub/c.txt": "ccc"})
+
+	cmd, out := newTestCommand(cli.OutputTable)
+	if err := runFileTree(cmd, dir, TreeOptions{SortBy: TreeSortName, ASCII: true}); err != nil {
+		t.Fatal(err)
+	}
+	want := dir + "\n|-- a.txt\n`-- sub\n    |-- b.txt\n    `-- c.txt\n\n1 directory, 3 files\n"
+	if out.String() != want {
+		t.Errorf("output =\n%s\nwant\n%s", out.String(), want)
+	}
+
+	cmd, out = newTestCommand(cli.OutputJSON)
+	if err := runFileTree(cmd, dir, TreeOptions{SortBy: TreeSortName, Depth: 1}); err != nil {
+		t.Fatal(err)
+	}
+	var root TreeNode
+	if err := json.Unmarshal(out.Bytes(), &root); err != nil {
+		t.Fatalf("invalid JSON %q: %v", out.String(), err)
+	}
+	if root.Size != 6 || len(root.Children) != 2 || root.Children[1].Size != 5 || root.Children[1].Children != nil {
+		t.Errorf("JSON tree = %+v", root)
+	}
+}
@@ -0,0 +1,107 @@
+package filecmd
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nate3d/go-toolbox/internal/config"
+)
+
+// walkOptions are the filters shared by the commands that walk trees.
+type walkOptions struct {
+	showHidden bool
+	gitignore  bool
+	// maxDepth limits the levels below the root; 0 walks all
+	maxDepth int
+}
+
+// addWalkFlags registers the walk filters on cmd. Call applyWalkDefaults
+// before walking.
+func addWalkFlags(cmd *cobra.Command, opts *walkOptions) {
+	cmd.Flags().BoolVarP(&opts.showHidden, "all", "a", false, "Include hidden entries (default: file.show_hidden)")
+	cmd.Flags().BoolVar(&opts.gitignore, "gitignore", false, "Skip entries excluded by .gitignore files")
+	cmd.Flags().IntVar(&opts.maxDepth, "max-depth", 0, "Levels walked below each path (0 for all)")
+}
+
+// applyWalkDefaults takes --all from the file.show_hidden setting unless
+// it was given.
+func applyWalkDefaults(cmd *cobra.Command, opts *walkOptions) {
+	if !cmd.Flags().Changed("all") {
+		opts.showHidden = config.GetBool("file.show_hidden")
+	}
+}
+
+// walkFunc is called for each entry of a walk. err is set for entries that
+// could not be read; directories are then not descended into.
+type walkFunc func(path string, entry fs.DirEntry, err error) error
+
+// walkTree calls fn for root and the entries below it in lexical order,
+// skipping hidden and ignored entries as opts say. Hidden entries given
+// as root are walked. Returning filepath.SkipDir from fn skips a
+// directory, filepath.SkipAll ends the walk, and other errors are returned.
+func walkTree(root string, opts walkOptions, fn walkFunc) error {
+	if opts.maxDepth < 0 {
+		opts.maxDepth = 0
+	}
+	var rootIgnore *ignoreMatcher
+	if opts.gitignore {
+		rootIgnore = newIgnoreMatcher(root)
+	}
+	// Matchers of the directories being walked, by path
+	matchers := map[string]*ignoreMatcher{}
+
+	return filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if path == root {
+			if err == nil && entry.IsDir() && rootIgnore != nil {
+				matchers[path] = rootIgnore.withDir(path)
+			}
+			return fn(path, entry, err)
+		}
+		ignore := matchers[filepath.Dir(path)]
+		if (!opts.showHidden && isHidden(entry.Name())) || ignore.ignored(path, entry.IsDir()) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if err != nil {
+			return fn(path, entry, err)
+		}
+
+		if !entry.IsDir() {
+			return fn(path, entry, nil)
+		}
+		if ignore != nil {
+			matchers[path] = ignore.withDir(path)
+		}
+		if opts.maxDepth > 0 && walkDepth(root, path) >= opts.maxDepth {
+			if err := fn(path, entry, nil); err != nil {
+				return err
+			}
+			return filepath.SkipDir
+		}
+		return fn(path, entry, nil)
+	})
+}
+
+// walkDepth returns the level of path below root, 1 for its entries.
+func walkDepth(root, path string) int {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return 0
+	}
+	return strings.Count(rel, string(filepath.Separator)) + 1
+}
+
+// statEntry returns the file info of a walked entry, without following
+// symbolic links.
+func statEntry(path string, entry fs.DirEntry) (fs.FileInfo, error) {
+	if entry == nil {
+		return os.Lstat(path)
+	}
+	return entry.Info()
+}
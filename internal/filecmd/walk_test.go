@@ -0,0 +1,54 @@
+package filecmd
+
+import (
+	"io/fs"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWalkTree(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		".gitignore":       "*.log\n",
+		".hidden/a.txt":    "a",
+		"a.txt":            "a",
+		"debug.log":        "d",
+		"sub/b.txt":        "b",
+		"sub/deeper/c.txt": "c",
+	})
+
+	walk := func(opts walkOptions) []string {
+		var got []string
+		err := walkTree(dir, opts, func(path string, _ fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, _ := filepath.Rel(dir, path)
+			got = append(got, filepath.ToSlash(rel))
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+
+	tests := []struct {
+		name string
+		opts walkOptions
+		want []string
+	}{
+		{"defaults", walkOptions{},
+			[]string{".", "a.txt", "debug.log", "sub", "sub/b.txt", "sub/deeper", "sub/deeper/c.txt"}},
+		{"hidden and gitignore", walkOptions{showHidden: true, gitignore: true},
+			[]string{".", ".gitignore", ".hidden", ".hidden/a.txt", "a.txt", "sub", "sub/b.txt", "sub/deeper", "sub/deeper/c.txt"}},
+		{"max depth", walkOptions{maxDepth: 1},
+			[]string{".", "a.txt", "debug.log", "sub"}},
+	}
+	for _, tt := range tests {
+		if got := walk(tt.opts); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: walked %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
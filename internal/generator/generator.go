@@ -10,7 +10,8 @@ import (
 	"text/template"
 
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/lipgloss"
+
+	"github.com/nate3d/go-toolbox/internal/theme"
 )
 
 // ToolType represents the type of tool to generate
@@ -50,40 +51,6 @@ type GeneratorModel struct {
 	inputPrompt string
 }
 
-// Generator styling
-var (
-	generatorTitleStyle = lipgloss.NewStyle().
-				Bold(true).
-				Foreground(lipgloss.Color("#FAFAFA")).
-				Background(lipgloss.Color("#7D56F4")).
-				Padding(0, 1)
-
-	generatorItemStyle = lipgloss.NewStyle().
-				PaddingLeft(4)
-
-	generatorSelectedStyle = lipgloss.NewStyle().
-				PaddingLeft(2).
-				Foreground(lipgloss.Color("170"))
-
-	generatorHelpStyle = lipgloss.NewStyle().
-				PaddingLeft(4).
-				PaddingTop(1).
-				Foreground(lipgloss.Color("241"))
-
-	generatorErrorStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("196")).
-				Bold(true)
-
-	generatorSuccessStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("46")).
-				Bold(true)
-
-	generatorInputStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("33")).
-				Background(lipgloss.Color("240")).
-				Padding(0, 1)
-)
-
 // NewGeneratorModel creates a new generator model
 func NewGeneratorModel() *GeneratorModel {
 	return &GeneratorModel{
@@ -228,11 +195,12 @@ func (m *GeneratorModel) handleSelection() (tea.Model, tea.Cmd) {
 
 // View implements tea.Model
 func (m *GeneratorModel) View() string {
+	styles := theme.Current().Styles()
 	if m.quitting {
-		return generatorTitleStyle.Render("Tool Generator") + "\n\nExiting...\n"
+		return styles.Title.Render("Tool Generator") + "\n\nExiting...\n"
 	}
 
-	s := generatorTitleStyle.Render("🛠️  Go Tool Generator") + "\n\n"
+	s := styles.Title.Render("🛠️  Go Tool Generator") + "\n\n"
 
 	switch m.step {
 	case 0: // Tool type selection
@@ -243,43 +211,43 @@ func (m *GeneratorModel) View() string {
 				cursor = ">"
 			}
 			if m.cursor == i {
-				s += generatorSelectedStyle.Render(fmt.Sprintf("%s %s", cursor, choice))
+				s += styles.Selected.Render(fmt.Sprintf("%s %s", cursor, choice))
 			} else {
-				s += generatorItemStyle.Render(fmt.Sprintf("%s %s", cursor, choice))
+				s += styles.Item.Render(fmt.Sprintf("%s %s", cursor, choice))
 			}
 			s += "\n"
 		}
-		s += generatorHelpStyle.Render("\nUse ↑/↓ or j/k to navigate, Enter to select, Esc to go back")
+		s += styles.Help.Render("\nUse ↑/↓ or j/k to navigate, Enter to select, Esc to go back")
 
 	case 1, 2: // Input mode
 		s += fmt.Sprintf("Creating %s Tool\n\n", m.toolType.String())
-		s += generatorItemStyle.Render(m.inputPrompt) + "\n"
-		s += generatorInputStyle.Render(m.inputText.String()+"█") + "\n\n"
+		s += styles.Item.Render(m.inputPrompt) + "\n"
+		s += styles.Input.Render(m.inputText.String()+"█") + "\n\n"
 
 		if m.step == 1 {
-			s += generatorHelpStyle.Render("Examples: filehasher, networkping, jsonformatter")
+			s += styles.Help.Render("Examples: filehasher, networkping, jsonformatter")
 		} else {
-			s += generatorHelpStyle.Render("Examples: A CLI tool for calculating file hashes")
+			s += styles.Help.Render("Examples: A CLI tool for calculating file hashes")
 		}
-		s += "\n" + generatorHelpStyle.Render("Press Enter to continue, Esc to go back")
+		s += "\n" + styles.Help.Render("Press Enter to continue, Esc to go back")
 
 	case 3: // Completion
 		s += "Tool Generation Complete!\n\n"
 		if m.success != "" {
-			s += generatorSuccessStyle.Render("✓ "+m.success) + "\n\n"
-			s += generatorItemStyle.Render(fmt.Sprintf("Tool: %s", m.toolName)) + "\n"
-			s += generatorItemStyle.Render(fmt.Sprintf("Type: %s", m.toolType.String())) + "\n"
-			s += generatorItemStyle.Render(fmt.Sprintf("Description: %s", m.toolDesc)) + "\n\n"
-			s += generatorItemStyle.Render("Files created:") + "\n"
-			s += generatorItemStyle.Render(fmt.Sprintf("  • cmd/%s/%s/main.go", strings.ToLower(m.toolType.String()), m.toolName)) + "\n"
-			s += generatorItemStyle.Render("  • README.md (updated)") + "\n"
-			s += generatorItemStyle.Render("  • Makefile (updated)") + "\n\n"
-			s += generatorHelpStyle.Render("Press 'r' to create another tool, 'b' to go back, or 'q' to quit")
+			s += styles.Success.Render("✓ "+m.success) + "\n\n"
+			s += styles.Item.Render(fmt.Sprintf("Tool: %s", m.toolName)) + "\n"
+			s += styles.Item.Render(fmt.Sprintf("Type: %s", m.toolType.String())) + "\n"
+			s += styles.Item.Render(fmt.Sprintf("Description: %s", m.toolDesc)) + "\n\n"
+			s += styles.Item.Render("Files created:") + "\n"
+			s += styles.Item.Render(fmt.Sprintf("  • cmd/%s/%s/main.go", strings.ToLower(m.toolType.String()), m.toolName)) + "\n"
+			s += styles.Item.Render("  • README.md (updated)") + "\n"
+			s += styles.Item.Render("  • Makefile (updated)") + "\n\n"
+			s += styles.Help.Render("Press 'r' to create another tool, 'b' to go back, or 'q' to quit")
 		}
 	}
 
 	if m.error != "" {
-		s += "\n" + generatorErrorStyle.Render("✗ "+m.error)
+		s += "\n" + styles.Error.Render("✗ "+m.error)
 	}
 
 	return s
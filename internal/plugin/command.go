@@ -0,0 +1,239 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/nate3d/go-toolbox/internal/cli"
+	"github.com/nate3d/go-toolbox/internal/config"
+)
+
+// GroupID groups plugin commands in the root help.
+const GroupID = "plugins"
+
+// defaultLogLevel is passed to plugins when no configuration was loaded
+const defaultLogLevel = "info"
+
+// Register adds the plugins found in Dirs to root as subcommands. Built-in
+// commands take precedence over plugins of the same name. args are the
+// command-line arguments; when they name a built-in command, discovery is
+// skipped so plugins cost nothing on the common path.
+func Register(root *cobra.Command, args []string) {
+	if first := firstCommand(root, args); first != "" && isBuiltin(root, first) {
+		return
+	}
+
+	var plugins []Plugin
+	for _, p := range Discover(Dirs()) {
+		if !isBuiltin(root, p.Name) {
+			plugins = append(plugins, p)
+		}
+	}
+	if len(plugins) == 0 {
+		return
+	}
+
+	if !root.ContainsGroup(GroupID) {
+		root.AddGroup(&cobra.Group{ID: GroupID, Title: "Plugin Commands:"})
+	}
+	infos := LoadInfo(context.Background(), plugins)
+	for i, p := range plugins {
+		root.AddCommand(NewCommand(p, infos[i]))
+	}
+}
+
+// NewCommand creates the subcommand that runs p. Flags are not parsed by
+// toolbox; everything after the global flags is handed to the plugin.
+func NewCommand(p Plugin, info Info) *cobra.Command {
+	use := p.Name
+	if _, rest, ok := strings.Cut(info.Usage, " "); ok {
+		use += " " + rest
+	}
+	long := info.Long
+	if long == "" {
+		long = info.Short
+	}
+	long += fmt.Sprintf("\n\nProvided by the plugin %s", p.Path)
+	if info.Version != "" {
+		long += fmt.Sprintf(" (version %s)", info.Version)
+	}
+	long += "."
+
+	return &cobra.Command{
+		Use:                use,
+		Short:              info.Short,
+		Long:               long,
+		GroupID:            GroupID,
+		DisableFlagParsing: true,
+		ValidArgsFunction:  completer(p, info),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			settings, pluginArgs, err := globalSettings(cmd.Root(), args)
+			if err != nil {
+				return err
+			}
+			err = p.Run(cmd.Context(), pluginArgs, settings)
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				return &cli.ExitStatusError{Code: exitErr.ExitCode()}
+			}
+			return err
+		},
+	}
+}
+
+// globalSettings consumes the root's global flags at the start of args,
+// since flag parsing is disabled for plugins, and returns the settings for
+// the plugin environment together with the remaining arguments. A "--"
+// ends the global flags explicitly.
+func globalSettings(root *cobra.Command, args []string) (Settings, []string, error) {
+	flags := root.PersistentFlags()
+	i := 0
+	for ; i < len(args); i++ {
+		if args[i] == "--" {
+			i++
+			break
+		}
+		flag, value, consumed := lookupFlag(flags, args[i:])
+		if flag == nil {
+			break
+		}
+		if err := flags.Set(flag.Name, value); err != nil {
+			return Settings{}, nil, cli.WrapError(cli.KindUsage, err, "invalid value for --%s", flag.Name)
+		}
+		i += consumed - 1
+	}
+
+	// The configuration named by --config decides the log level
+	if file := flagValue(flags, "config"); file != "" && file != config.FileUsed() {
+		if err := config.LoadFile(file); err != nil {
+			return Settings{}, nil, cli.WrapError(cli.KindConfig, err, "cannot load %s", file)
+		}
+	}
+
+	settings := Settings{
+		ConfigFile: flagValue(flags, "config"),
+		LogLevel:   config.GetString("log_level"),
+		Output:     flagValue(flags, "output"),
+		Verbose:    flagValue(flags, "verbose") == "true",
+	}
+	if settings.ConfigFile == "" {
+		settings.ConfigFile = config.FileUsed()
+	}
+	if settings.LogLevel == "" {
+		settings.LogLevel = defaultLogLevel
+	}
+	if flagValue(flags, "debug") == "true" {
+		settings.LogLevel = "debug"
+	}
+	if flag := flags.Lookup("color"); flag != nil && flag.Changed {
+		settings.Color = flag.Value.String()
+	}
+	return settings, args[i:], nil
+}
+
+// lookupFlag matches args[0] against flags and returns the flag, its value
+// and the number of arguments used, or a nil flag if args[0] is not one of them.
+func lookupFlag(flags *pflag.FlagSet, args []string) (*pflag.Flag, string, int) {
+	arg := args[0]
+	var flag *pflag.Flag
+	name, value, hasValue := "", "", false
+	switch {
+	case strings.HasPrefix(arg, "--"):
+		name, value, hasValue = strings.Cut(arg[2:], "=")
+		flag = flags.Lookup(name)
+	case strings.HasPrefix(arg, "-") && len(arg) == 2:
+		flag = flags.ShorthandLookup(arg[1:])
+	}
+	switch {
+	case flag == nil:
+		return nil, "", 0
+	case hasValue:
+		return flag, value, 1
+	case flag.NoOptDefVal != "":
+		return flag, flag.NoOptDefVal, 1
+	case len(args) > 1:
+		return flag, args[1], 2
+	default:
+		return nil, "", 0
+	}
+}
+
+// flagValue returns the value of a flag, or "" if the flag does not exist.
+func flagValue(flags *pflag.FlagSet, name string) string {
+	if flag := flags.Lookup(name); flag != nil {
+		return flag.Value.String()
+	}
+	return ""
+}
+
+// firstCommand returns the first argument that is not a global flag.
+func firstCommand(root *cobra.Command, args []string) string {
+	flags := root.PersistentFlags()
+	for i := 0; i < len(args); i++ {
+		if !strings.HasPrefix(args[i], "-") {
+			return args[i]
+		}
+		if _, _, consumed := lookupFlag(flags, args[i:]); consumed > 1 {
+			i++
+		}
+	}
+	return ""
+}
+
+// isBuiltin reports whether name is a command compiled into root.
+func isBuiltin(root *cobra.Command, name string) bool {
+	for _, cmd := range root.Commands() {
+		if cmd.GroupID != GroupID && (cmd.Name() == name || cmd.HasAlias(name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// completer completes plugin arguments, asking the plugin itself when it
+// supports dynamic completion.
+func completer(p Plugin, info Info) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if info.DynamicCompletion {
+			candidates, directive := p.Complete(cmd.Context(), args, toComplete)
+			return candidates, cobra.ShellCompDirective(directive)
+		}
+
+		if strings.HasPrefix(toComplete, "-") {
+			names := make([]string, 0, len(info.Flags))
+			for _, flag := range info.Flags {
+				names = append(names, "--"+flag.Name+"\t"+flag.Usage)
+			}
+			return withPrefix(names, toComplete), cobra.ShellCompDirectiveNoFileComp
+		}
+		if len(args) > 0 {
+			previous := strings.TrimLeft(args[len(args)-1], "-")
+			for _, flag := range info.Flags {
+				if flag.Name == previous && len(flag.Values) > 0 {
+					return withPrefix(flag.Values, toComplete), cobra.ShellCompDirectiveNoFileComp
+				}
+			}
+		}
+		if len(info.Args) > 0 {
+			return withPrefix(info.Args, toComplete), cobra.ShellCompDirectiveNoFileComp
+		}
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+}
+
+// withPrefix keeps the candidates starting with prefix.
+func withPrefix(candidates []string, prefix string) []string {
+	var matches []string
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, prefix) {
+			matches = append(matches, candidate)
+		}
+	}
+	return matches
+}
@@ -0,0 +1,250 @@
+// Package plugin discovers external toolbox-<name> executables and runs them
+// as subcommands, in the style of git and kubectl plugins.
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Prefix is the file name prefix of plugin executables
+	Prefix = "toolbox-"
+	// InfoFlag asks a plugin to describe itself as JSON on stdout
+	InfoFlag = "--toolbox-plugin-info"
+
+	// infoTimeout bounds the metadata handshake so a broken plugin cannot hang startup
+	infoTimeout = 2 * time.Second
+)
+
+// Environment variables set for plugins
+const (
+	EnvConfig   = "TOOLBOX_CONFIG"
+	EnvLogLevel = "TOOLBOX_LOG_LEVEL"
+	EnvOutput   = "TOOLBOX_OUTPUT"
+	EnvVerbose  = "TOOLBOX_VERBOSE"
+	EnvColor    = "TOOLBOX_COLOR"
+)
+
+// Plugin is an executable found on disk.
+type Plugin struct {
+	Name string
+	Path string
+}
+
+// Info is the metadata a plugin prints for InfoFlag. Every field is optional:
+//
+//	{
+//	  "short": "Deploy services",
+//	  "long": "Deploy builds services and rolls them out.",
+//	  "usage": "deploy [flags] <service>",
+//	  "version": "1.2.0",
+//	  "args": ["api", "worker"],
+//	  "flags": [{"name": "env", "usage": "Target environment", "values": ["dev", "prod"]}],
+//	  "dynamic_completion": false
+//	}
+//
+// With dynamic_completion the plugin is asked to complete arguments through
+// cobra's hidden __complete command, which cobra-based plugins provide.
+type Info struct {
+	Short             string     `json:"short"`
+	Long              string     `json:"long"`
+	Usage             string     `json:"usage"`
+	Version           string     `json:"version"`
+	Args              []string   `json:"args"`
+	Flags             []FlagInfo `json:"flags"`
+	DynamicCompletion bool       `json:"dynamic_completion"`
+}
+
+// FlagInfo describes a plugin flag for completion.
+type FlagInfo struct {
+	Name   string   `json:"name"`
+	Usage  string   `json:"usage"`
+	Values []string `json:"values"`
+}
+
+// Dirs returns the directories searched for plugins in order: the user
+// plugin directory, then $PATH.
+func Dirs() []string {
+	var dirs []string
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, ".config", "toolbox", "plugins"))
+	}
+	return append(dirs, filepath.SplitList(os.Getenv("PATH"))...)
+}
+
+// Discover finds plugin executables in dirs, sorted by name. When two
+// directories contain the same plugin, the earlier directory wins.
+func Discover(dirs []string) []Plugin {
+	seen := make(map[string]bool)
+	var plugins []Plugin
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name, ok := pluginName(entry.Name())
+			if !ok || seen[name] {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			if !isExecutable(path) {
+				continue
+			}
+			seen[name] = true
+			plugins = append(plugins, Plugin{Name: name, Path: path})
+		}
+	}
+	sort.Slice(plugins, func(i, j int) bool { return plugins[i].Name < plugins[j].Name })
+	return plugins
+}
+
+// pluginName returns the subcommand name for a plugin file name.
+func pluginName(file string) (string, bool) {
+	if runtime.GOOS == "windows" {
+		file = strings.TrimSuffix(strings.ToLower(file), ".exe")
+	}
+	name, ok := strings.CutPrefix(file, Prefix)
+	if !ok || name == "" || strings.ContainsAny(name, ". ") {
+		return "", false
+	}
+	return name, true
+}
+
+// isExecutable reports whether path is a regular file that can be run.
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		return strings.EqualFold(filepath.Ext(path), ".exe")
+	}
+	return info.Mode().Perm()&0o111 != 0
+}
+
+// Info runs the metadata handshake. A plugin that does not answer with
+// JSON still works; it gets a generic description.
+func (p Plugin) Info(ctx context.Context) Info {
+	fallback := Info{Short: fmt.Sprintf("Plugin %s", p.Path)}
+
+	ctx, cancel := context.WithTimeout(ctx, infoTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, p.Path, InfoFlag).Output() // #nosec G204 - plugins are executables the user installed
+	if err != nil {
+		return fallback
+	}
+
+	var info Info
+	if err := json.Unmarshal(out, &info); err != nil {
+		return fallback
+	}
+	if info.Short == "" {
+		info.Short = fallback.Short
+	}
+	return info
+}
+
+// LoadInfo runs the handshake for all plugins in parallel.
+func LoadInfo(ctx context.Context, plugins []Plugin) []Info {
+	infos := make([]Info, len(plugins))
+	var wg sync.WaitGroup
+	for i, p := range plugins {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			infos[i] = p.Info(ctx)
+		}()
+	}
+	wg.Wait()
+	return infos
+}
+
+// Settings are the toolbox settings passed to plugins.
+type Settings struct {
+	ConfigFile string
+	LogLevel   string
+	Output     string
+	Verbose    bool
+	Color      string
+}
+
+// Env returns the environment for a plugin: the current environment plus
+// the settings as TOOLBOX_* variables. Empty settings are not exported.
+func (s Settings) Env(environ []string) []string {
+	env := append([]string{}, environ...)
+	add := func(name, value string) {
+		if value != "" {
+			env = append(env, name+"="+value)
+		}
+	}
+	add(EnvConfig, s.ConfigFile)
+	add(EnvLogLevel, s.LogLevel)
+	add(EnvOutput, s.Output)
+	if s.Verbose {
+		add(EnvVerbose, "1")
+	}
+	add(EnvColor, s.Color)
+	return env
+}
+
+// Run executes the plugin with args, connected to the terminal. A non-zero
+// exit is returned as an *exec.ExitError.
+func (p Plugin) Run(ctx context.Context, args []string, settings Settings) error {
+	cmd := exec.CommandContext(ctx, p.Path, args...) // #nosec G204 - plugins are executables the user installed
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = settings.Env(os.Environ())
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return fmt.Errorf("error running plugin %s: %w", p.Name, err)
+	}
+	return err
+}
+
+// Complete asks a cobra-based plugin for completions of args, returning the
+// candidates and cobra's completion directive.
+func (p Plugin) Complete(ctx context.Context, args []string, toComplete string) ([]string, int) {
+	ctx, cancel := context.WithTimeout(ctx, infoTimeout)
+	defer cancel()
+	completeArgs := append(append([]string{"__complete"}, args...), toComplete)
+	out, err := exec.CommandContext(ctx, p.Path, completeArgs...).Output() // #nosec G204 - plugins are executables the user installed
+	if err != nil {
+		return nil, 0
+	}
+	return parseCompletions(string(out))
+}
+
+// parseCompletions parses cobra's __complete output: one candidate per line
+// followed by ":<directive>".
+func parseCompletions(out string) ([]string, int) {
+	lines := strings.Split(strings.TrimRight(out, "\n"), "\n")
+	directive := 0
+	if last := lines[len(lines)-1]; strings.HasPrefix(last, ":") {
+		_, _ = fmt.Sscanf(last, ":%d", &directive)
+		lines = lines[:len(lines)-1]
+	}
+	var candidates []string
+	for _, line := range lines {
+		if line != "" && !strings.HasPrefix(line, "Completion ended with directive") {
+			candidates = append(candidates, line)
+		}
+	}
+	return candidates, directive
+}
@@ -0,0 +1,163 @@
+package plugin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"slices"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/nate3d/go-toolbox/internal/cli"
+)
+
+// writePlugin writes an executable shell script to dir.
+func writePlugin(t *testing.T, dir, name, script string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("plugin scripts need a POSIX shell")
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0o755); err != nil { // #nosec G306 - test plugin must be executable
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestDiscover(t *testing.T) {
+	first, second := t.TempDir(), t.TempDir()
+	writePlugin(t, first, "toolbox-deploy", "exit 0\n")
+	writePlugin(t, second, "toolbox-deploy", "exit 1\n")
+	writePlugin(t, second, "toolbox-lint", "exit 0\n")
+	writePlugin(t, second, "other-tool", "exit 0\n")
+	if err := os.WriteFile(filepath.Join(second, "toolbox-notes"), []byte("text"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	got := Discover([]string{first, "", filepath.Join(first, "missing"), second})
+	want := []Plugin{
+		{Name: "deploy", Path: filepath.Join(first, "toolbox-deploy")},
+		{Name: "lint", Path: filepath.Join(second, "toolbox-lint")},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Discover() = %+v, want %+v", got, want)
+	}
+}
+
+func TestInfo(t *testing.T) {
+	dir := t.TempDir()
+	path := writePlugin(t, dir, "toolbox-deploy", `[ "$1" = "--toolbox-plugin-info" ] &&
+echo '{"short":"Deploy services","usage":"deploy <service>","args":["api"]}'
+`)
+	silent := writePlugin(t, dir, "toolbox-silent", "exit 1\n")
+
+	info := Plugin{Name: "deploy", Path: path}.Info(context.Background())
+	if info.Short != "Deploy services" || !reflect.DeepEqual(info.Args, []string{"api"}) {
+		t.Errorf("Info() = %+v", info)
+	}
+	if got := (Plugin{Name: "silent", Path: silent}).Info(context.Background()); got.Short != "Plugin "+silent {
+		t.Errorf("fallback Short = %q", got.Short)
+	}
+}
+
+func TestParseCompletions(t *testing.T) {
+	candidates, directive := parseCompletions("api\tThe API\nworker\n:4\n")
+	if !reflect.DeepEqual(candidates, []string{"api\tThe API", "worker"}) || directive != 4 {
+		t.Errorf("parseCompletions() = %q, %d", candidates, directive)
+	}
+}
+
+func newTestRoot() *cobra.Command {
+	root := &cobra.Command{Use: "go-toolbox"}
+	root.PersistentFlags().StringP("config", "c", "", "")
+	root.PersistentFlags().BoolP("verbose", "v", false, "")
+	root.PersistentFlags().Bool("debug", false, "")
+	root.PersistentFlags().String("output", "table", "")
+	root.AddCommand(&cobra.Command{Use: "version"})
+	return root
+}
+
+func TestGlobalSettings(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(file, []byte("log_level: warn\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(viper.Reset)
+
+	// Cases loading the config file come last, as it stays loaded
+	tests := []struct {
+		name     string
+		args     []string
+		want     Settings
+		wantArgs []string
+	}{
+		{
+			name:     "double dash",
+			args:     []string{"--output=yaml", "--", "--verbose"},
+			want:     Settings{LogLevel: "info", Output: "yaml"},
+			wantArgs: []string{"--verbose"},
+		},
+		{
+			name:     "plugin flags only",
+			args:     []string{"--env", "prod"},
+			want:     Settings{LogLevel: "info", Output: "table"},
+			wantArgs: []string{"--env", "prod"},
+		},
+		{
+			name:     "config file",
+			args:     []string{"-c", file, "deploy"},
+			want:     Settings{ConfigFile: file, LogLevel: "warn", Output: "table"},
+			wantArgs: []string{"deploy"},
+		},
+		{
+			name:     "leading global flags",
+			args:     []string{"--output", "json", "-v", "-c", file, "--debug", "deploy", "--output", "x"},
+			want:     Settings{ConfigFile: file, LogLevel: "debug", Output: "json", Verbose: true},
+			wantArgs: []string{"deploy", "--output", "x"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings, args, err := globalSettings(newTestRoot(), tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if settings != tt.want {
+				t.Errorf("settings = %+v, want %+v", settings, tt.want)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("args = %q, want %q", args, tt.wantArgs)
+			}
+		})
+	}
+
+	if _, _, err := globalSettings(newTestRoot(), []string{"-c", file + ".missing", "deploy"}); cli.ExitCode(err) != cli.ExitConfig {
+		t.Errorf("missing config: error = %v, want a config error", err)
+	}
+}
+
+func TestSettingsEnv(t *testing.T) {
+	env := Settings{Output: "json", Verbose: true}.Env([]string{"HOME=/root"})
+	for _, want := range []string{"HOME=/root", "TOOLBOX_OUTPUT=json", "TOOLBOX_VERBOSE=1"} {
+		if !slices.Contains(env, want) {
+			t.Errorf("Env() = %q, missing %q", env, want)
+		}
+	}
+	if slices.ContainsFunc(env, func(entry string) bool { return entry == "TOOLBOX_CONFIG=" }) {
+		t.Errorf("Env() exports empty settings: %q", env)
+	}
+}
+
+func TestFirstCommand(t *testing.T) {
+	root := newTestRoot()
+	if got := firstCommand(root, []string{"--output", "json", "-v", "deploy"}); got != "deploy" {
+		t.Errorf("firstCommand() = %q, want deploy", got)
+	}
+	if !isBuiltin(root, "version") || isBuiltin(root, "deploy") {
+		t.Error("isBuiltin() misclassified commands")
+	}
+}
@@ -0,0 +1,176 @@
+// Package profile implements the --timing, --cpuprofile, --memprofile and
+// --trace flags of the toolbox binaries.
+package profile
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// timingPrecision is the resolution of the timing breakdown
+const timingPrecision = time.Microsecond
+
+// Options are the profiling settings.
+type Options struct {
+	Timing     bool
+	CPUProfile string
+	MemProfile string
+	Trace      string
+}
+
+// AddFlags registers the profiling flags on flags so that cobra accepts,
+// documents and completes them. Their values are taken from the command
+// line by FromArgs before the command tree exists, so profiling covers
+// startup too. Call it on the persistent flags of a binary's root command.
+func AddFlags(flags *pflag.FlagSet) {
+	bind(flags, &Options{})
+}
+
+func bind(flags *pflag.FlagSet, o *Options) {
+	flags.BoolVar(&o.Timing, "timing", false, "Print a timing breakdown of startup and the command to stderr")
+	flags.StringVar(&o.CPUProfile, "cpuprofile", "", "Write a pprof CPU profile to a file")
+	flags.StringVar(&o.MemProfile, "memprofile", "", "Write a pprof heap profile to a file on exit")
+	flags.StringVar(&o.Trace, "trace", "", "Write a runtime execution trace to a file")
+}
+
+// FromArgs returns the profiling options set in args, ignoring all other
+// flags. Invalid values are left for cobra to report.
+func FromArgs(args []string) Options {
+	var o Options
+	flags := pflag.NewFlagSet("profile", pflag.ContinueOnError)
+	flags.ParseErrorsAllowlist.UnknownFlags = true
+	flags.SetOutput(io.Discard)
+	flags.Usage = func() {}
+	bind(flags, &o)
+	_ = flags.Parse(args)
+	return o
+}
+
+// Phase is a named part of the run and how long it took.
+type Phase struct {
+	Name     string
+	Duration time.Duration
+}
+
+// Session records phases and owns the profile files of one run.
+type Session struct {
+	opts  Options
+	out   io.Writer
+	start time.Time
+	last  time.Time
+
+	phases []Phase
+	cpu    *os.File
+	trace  *os.File
+}
+
+// Start begins CPU profiling and tracing as requested by opts. The timing
+// breakdown is written to out when the session stops.
+func Start(opts Options, out io.Writer) (*Session, error) {
+	now := time.Now()
+	s := &Session{opts: opts, out: out, start: now, last: now}
+
+	if opts.CPUProfile != "" {
+		f, err := os.Create(opts.CPUProfile) // #nosec G304 - the user names the profile file
+		if err != nil {
+			return nil, fmt.Errorf("error creating CPU profile: %w", err)
+		}
+		if err := pprof.StartCPUProfile(f); err != nil {
+			_ = f.Close()
+			return nil, fmt.Errorf("error starting CPU profile: %w", err)
+		}
+		s.cpu = f
+	}
+
+	if opts.Trace != "" {
+		f, err := os.Create(opts.Trace) // #nosec G304 - the user names the trace file
+		if err != nil {
+			_ = s.stopCPU()
+			return nil, fmt.Errorf("error creating trace: %w", err)
+		}
+		if err := trace.Start(f); err != nil {
+			_ = f.Close()
+			_ = s.stopCPU()
+			return nil, fmt.Errorf("error starting trace: %w", err)
+		}
+		s.trace = f
+	}
+	return s, nil
+}
+
+// Mark ends the current phase and names it.
+func (s *Session) Mark(name string) {
+	now := time.Now()
+	s.phases = append(s.phases, Phase{Name: name, Duration: now.Sub(s.last)})
+	s.last = now
+}
+
+// Phases returns the phases marked so far.
+func (s *Session) Phases() []Phase {
+	return s.phases
+}
+
+// Stop finishes tracing and CPU profiling, writes the heap profile and
+// prints the timing breakdown.
+func (s *Session) Stop() error {
+	var errs []error
+	if s.trace != nil {
+		trace.Stop()
+		errs = append(errs, s.trace.Close())
+		s.trace = nil
+	}
+	errs = append(errs, s.stopCPU())
+	if s.opts.MemProfile != "" {
+		errs = append(errs, writeHeapProfile(s.opts.MemProfile))
+	}
+	if s.opts.Timing {
+		s.printTiming()
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Session) stopCPU() error {
+	if s.cpu == nil {
+		return nil
+	}
+	pprof.StopCPUProfile()
+	err := s.cpu.Close()
+	s.cpu = nil
+	return err
+}
+
+func writeHeapProfile(path string) error {
+	f, err := os.Create(path) // #nosec G304 - the user names the profile file
+	if err != nil {
+		return fmt.Errorf("error creating heap profile: %w", err)
+	}
+	// Collect garbage first so the profile shows live memory
+	runtime.GC()
+	if err := pprof.WriteHeapProfile(f); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("error writing heap profile: %w", err)
+	}
+	return f.Close()
+}
+
+// printTiming writes each phase and the total, aligned in columns.
+func (s *Session) printTiming() {
+	phases := append(append([]Phase{}, s.phases...), Phase{Name: "total", Duration: s.last.Sub(s.start)})
+	width := 0
+	for _, phase := range phases {
+		width = max(width, len(phase.Name))
+	}
+
+	_, _ = fmt.Fprintln(s.out, "Timing:")
+	for _, phase := range phases {
+		_, _ = fmt.Fprintf(s.out, "  %-*s  %s\n", width, phase.Name, phase.Duration.Round(timingPrecision))
+	}
+}
@@ -0,0 +1,87 @@
+package profile
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFromArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want Options
+	}{
+		{
+			name: "mixed with command flags",
+			args: []string{"file", "hash", "--algo", "sha512", "--timing", "--cpuprofile", "cpu.out", "big.iso"},
+			want: Options{Timing: true, CPUProfile: "cpu.out"},
+		},
+		{
+			name: "equals form",
+			args: []string{"--memprofile=mem.out", "--trace=trace.out", "-v", "system", "info"},
+			want: Options{MemProfile: "mem.out", Trace: "trace.out"},
+		},
+		{
+			name: "after double dash",
+			args: []string{"utils", "string", "upper", "--", "--timing"},
+			want: Options{},
+		},
+		{
+			name: "help and invalid values",
+			args: []string{"--help", "--timing=maybe"},
+			want: Options{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FromArgs(tt.args); got != tt.want {
+				t.Errorf("FromArgs() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSession(t *testing.T) {
+	dir := t.TempDir()
+	opts := Options{
+		Timing:     true,
+		CPUProfile: filepath.Join(dir, "cpu.out"),
+		MemProfile: filepath.Join(dir, "mem.out"),
+		Trace:      filepath.Join(dir, "trace.out"),
+	}
+
+	var out bytes.Buffer
+	session, err := Start(opts, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session.Mark("config init")
+	session.Mark("command run")
+	if err := session.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	if phases := session.Phases(); len(phases) != 2 || phases[1].Name != "command run" {
+		t.Errorf("Phases() = %+v", phases)
+	}
+	for _, want := range []string{"Timing:\n", "  config init  ", "  command run  ", "  total        "} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("timing output %q does not contain %q", out.String(), want)
+		}
+	}
+	for _, path := range []string{opts.CPUProfile, opts.MemProfile, opts.Trace} {
+		if info, err := os.Stat(path); err != nil || info.Size() == 0 {
+			t.Errorf("%s was not written: %v", filepath.Base(path), err)
+		}
+	}
+}
+
+func TestStartError(t *testing.T) {
+	_, err := Start(Options{CPUProfile: filepath.Join(t.TempDir(), "missing", "cpu.out")}, &bytes.Buffer{})
+	if err == nil {
+		t.Fatal("Start() succeeded with an unwritable profile path")
+	}
+}
@@ -0,0 +1,195 @@
+package theme
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/pflag"
+
+	"github.com/nate3d/go-toolbox/internal/config"
+)
+
+// ColorMode is the value of the --color flag.
+type ColorMode string
+
+const (
+	// ColorAuto colors output when stdout is a terminal, subject to the environment and config.
+	ColorAuto ColorMode = "auto"
+	// ColorAlways colors output even when it is redirected.
+	ColorAlways ColorMode = "always"
+	// ColorNever disables color.
+	ColorNever ColorMode = "never"
+)
+
+// ColorModes lists the values accepted by --color.
+var ColorModes = []string{string(ColorAuto), string(ColorAlways), string(ColorNever)}
+
+// ErrUnknownTheme is returned when the configured palette does not exist.
+var ErrUnknownTheme = errors.New("unknown theme")
+
+// Register the theme rule so config.Init rejects unknown palette names.
+func init() {
+	config.RegisterRule("tui.theme", func(value string) error {
+		if _, ok := Builtin(value); ok || config.IsSet("themes."+strings.ToLower(value)) {
+			return nil
+		}
+		return fmt.Errorf("%w: %w %q (built-in: %s)", config.ErrInvalidValue, ErrUnknownTheme, value,
+			strings.Join(Names(), ", "))
+	})
+}
+
+// ParseColorMode parses auto, always or never.
+func ParseColorMode(s string) (ColorMode, error) {
+	switch m := ColorMode(strings.ToLower(strings.TrimSpace(s))); m {
+	case ColorAuto, ColorAlways, ColorNever:
+		return m, nil
+	default:
+		return "", fmt.Errorf("invalid color mode %q (want auto, always or never)", s)
+	}
+}
+
+// ColorEnabled decides whether to use color. An explicit --color=always or
+// never wins. In auto mode the environment is consulted next, in this order:
+//
+//   - FORCE_COLOR set to anything but "" or "0" enables color; "0" disables it
+//   - NO_COLOR set to a non-empty value disables color
+//   - CLICOLOR_FORCE set to anything but "" or "0" enables color
+//   - CLICOLOR=0 disables color
+//
+// Otherwise color follows cli.color_output and whether stdout is a terminal.
+func ColorEnabled(mode ColorMode, lookupEnv func(string) (string, bool), configEnabled, isTerminal bool) bool {
+	switch mode {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
+
+	if value, ok := lookupEnv("FORCE_COLOR"); ok && value != "" {
+		return value != "0" && !strings.EqualFold(value, "false")
+	}
+	if value, ok := lookupEnv("NO_COLOR"); ok && value != "" {
+		return false
+	}
+	if value, ok := lookupEnv("CLICOLOR_FORCE"); ok && value != "" && value != "0" {
+		return true
+	}
+	if value, ok := lookupEnv("CLICOLOR"); ok && value == "0" {
+		return false
+	}
+	if value, ok := lookupEnv("TERM"); ok && value == "dumb" {
+		return false
+	}
+	return configEnabled && isTerminal
+}
+
+// SetMode changes the color mode and re-evaluates the active theme.
+func SetMode(m ColorMode) {
+	t := Current()
+
+	mu.Lock()
+	defer mu.Unlock()
+	mode = m
+	current = New(t.Name, t.Palette, resolveEnabled())
+	apply(current)
+}
+
+// Load activates the palette named by tui.theme, which may be a built-in
+// palette or one defined under "themes" in the configuration:
+//
+//	themes:
+//	  ocean:
+//	    base: dracula      # optional built-in palette to start from
+//	    info: "#00AFFF"
+//	    accent: cyan
+//
+// It also applies cli.color_output. Call it after config.Init.
+func Load() error {
+	name := strings.ToLower(config.GetString("tui.theme"))
+	if name == "" {
+		name = DefaultName
+	}
+	palette, err := lookup(name)
+	if err != nil {
+		return err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	configColor = config.GetBool("cli.color_output")
+	current = New(name, palette, resolveEnabled())
+	apply(current)
+	return nil
+}
+
+// customPalette is a palette defined in the configuration.
+type customPalette struct {
+	Base    string `mapstructure:"base"`
+	Palette `mapstructure:",squash"`
+}
+
+// lookup resolves a palette name from the configuration or the built-ins.
+func lookup(name string) (Palette, error) {
+	key := "themes." + name
+	if !config.IsSet(key) {
+		base, ok := Builtin(name)
+		if !ok {
+			return Palette{}, fmt.Errorf("%w %q (built-in: %s)", ErrUnknownTheme, name, strings.Join(Names(), ", "))
+		}
+		return base, nil
+	}
+
+	var custom customPalette
+	if err := config.UnmarshalKey(key, &custom); err != nil {
+		return Palette{}, fmt.Errorf("error reading theme %q: %w", name, err)
+	}
+	baseName := custom.Base
+	if baseName == "" {
+		baseName = DefaultName
+	}
+	base, ok := Builtin(baseName)
+	if !ok {
+		return Palette{}, fmt.Errorf("theme %q: %w base %q", name, ErrUnknownTheme, baseName)
+	}
+
+	palette, err := base.Merge(custom.Palette).Normalize()
+	if err != nil {
+		return Palette{}, fmt.Errorf("theme %q: %w", name, err)
+	}
+	return palette, nil
+}
+
+// ColorFlag is a pflag.Value for --color. Setting it switches the color mode
+// immediately, so it works on any command without extra wiring.
+type ColorFlag struct {
+	mode ColorMode
+}
+
+var _ pflag.Value = (*ColorFlag)(nil)
+
+// NewColorFlag creates a --color flag value defaulting to auto.
+func NewColorFlag() *ColorFlag {
+	return &ColorFlag{mode: ColorAuto}
+}
+
+// Set implements pflag.Value.
+func (f *ColorFlag) Set(s string) error {
+	m, err := ParseColorMode(s)
+	if err != nil {
+		return err
+	}
+	f.mode = m
+	SetMode(m)
+	return nil
+}
+
+// String implements pflag.Value.
+func (f *ColorFlag) String() string {
+	return string(f.mode)
+}
+
+// Type implements pflag.Value.
+func (f *ColorFlag) Type() string {
+	return "mode"
+}
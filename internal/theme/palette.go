@@ -0,0 +1,160 @@
+package theme
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultName is the palette used when none is configured.
+const DefaultName = "default"
+
+// ErrInvalidColor is returned when a palette color cannot be parsed.
+var ErrInvalidColor = errors.New("invalid color")
+
+// Palette assigns a color to each role. Colors are ANSI numbers ("0" to
+// "255"), hex values ("#7D56F4" or "#FFF") or the names of the 16 basic
+// ANSI colors ("cyan", "bright-red"). An empty color leaves text unstyled.
+type Palette struct {
+	Info      string `mapstructure:"info"`
+	Success   string `mapstructure:"success"`
+	Warn      string `mapstructure:"warn"`
+	Error     string `mapstructure:"error"`
+	Header    string `mapstructure:"header"`
+	Muted     string `mapstructure:"muted"`
+	Accent    string `mapstructure:"accent"`
+	Highlight string `mapstructure:"highlight"`
+	TitleFg   string `mapstructure:"title_fg"`
+	TitleBg   string `mapstructure:"title_bg"`
+	InputFg   string `mapstructure:"input_fg"`
+	InputBg   string `mapstructure:"input_bg"`
+}
+
+var hexColorPattern = regexp.MustCompile(`^#(?:[0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// colorNames maps the basic ANSI color names to their numbers.
+var colorNames = map[string]int{
+	"black": 0, "red": 1, "green": 2, "yellow": 3,
+	"blue": 4, "magenta": 5, "cyan": 6, "white": 7,
+	"bright-black": 8, "gray": 8, "grey": 8, "bright-red": 9, "bright-green": 10,
+	"bright-yellow": 11, "bright-blue": 12, "bright-magenta": 13, "bright-cyan": 14,
+	"bright-white": 15,
+}
+
+// builtins are the palettes selectable by name.
+var builtins = map[string]Palette{
+	DefaultName: {
+		Info: "6", Success: "2", Warn: "3", Error: "1", Header: "4",
+		Muted: "241", Accent: "170", Highlight: "9",
+		TitleFg: "#FAFAFA", TitleBg: "#7D56F4", InputFg: "33", InputBg: "240",
+	},
+	"light": {
+		Info: "25", Success: "28", Warn: "130", Error: "160", Header: "18",
+		Muted: "245", Accent: "127", Highlight: "160",
+		TitleFg: "#FFFFFF", TitleBg: "#5A3FC0", InputFg: "18", InputBg: "252",
+	},
+	"dracula": {
+		Info: "#8BE9FD", Success: "#50FA7B", Warn: "#F1FA8C", Error: "#FF5555", Header: "#BD93F9",
+		Muted: "#6272A4", Accent: "#FF79C6", Highlight: "#FFB86C",
+		TitleFg: "#282A36", TitleBg: "#BD93F9", InputFg: "#F8F8F2", InputBg: "#44475A",
+	},
+	"solarized": {
+		Info: "#2AA198", Success: "#859900", Warn: "#B58900", Error: "#DC322F", Header: "#268BD2",
+		Muted: "#586E75", Accent: "#D33682", Highlight: "#CB4B16",
+		TitleFg: "#FDF6E3", TitleBg: "#268BD2", InputFg: "#93A1A1", InputBg: "#073642",
+	},
+	// monochrome relies on bold and faint text only
+	"monochrome": {},
+}
+
+// Builtin returns the built-in palette with the given name.
+func Builtin(name string) (Palette, bool) {
+	palette, ok := builtins[strings.ToLower(name)]
+	return palette, ok
+}
+
+// Names returns the names of the built-in palettes, sorted.
+func Names() []string {
+	names := make([]string, 0, len(builtins))
+	for name := range builtins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Merge returns p with every non-empty color of overrides applied.
+func (p Palette) Merge(overrides Palette) Palette {
+	merge := func(base *string, override string) {
+		if override != "" {
+			*base = override
+		}
+	}
+	merge(&p.Info, overrides.Info)
+	merge(&p.Success, overrides.Success)
+	merge(&p.Warn, overrides.Warn)
+	merge(&p.Error, overrides.Error)
+	merge(&p.Header, overrides.Header)
+	merge(&p.Muted, overrides.Muted)
+	merge(&p.Accent, overrides.Accent)
+	merge(&p.Highlight, overrides.Highlight)
+	merge(&p.TitleFg, overrides.TitleFg)
+	merge(&p.TitleBg, overrides.TitleBg)
+	merge(&p.InputFg, overrides.InputFg)
+	merge(&p.InputBg, overrides.InputBg)
+	return p
+}
+
+// Normalize returns the palette with every color in canonical form: an ANSI
+// number or a six-digit hex value.
+func (p Palette) Normalize() (Palette, error) {
+	var errs []error
+	normalize := func(role string, value *string) {
+		normalized, err := NormalizeColor(*value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", role, err))
+			return
+		}
+		*value = normalized
+	}
+	normalize("info", &p.Info)
+	normalize("success", &p.Success)
+	normalize("warn", &p.Warn)
+	normalize("error", &p.Error)
+	normalize("header", &p.Header)
+	normalize("muted", &p.Muted)
+	normalize("accent", &p.Accent)
+	normalize("highlight", &p.Highlight)
+	normalize("title_fg", &p.TitleFg)
+	normalize("title_bg", &p.TitleBg)
+	normalize("input_fg", &p.InputFg)
+	normalize("input_bg", &p.InputBg)
+	return p, errors.Join(errs...)
+}
+
+// NormalizeColor converts a color name, ANSI number or hex value to an ANSI
+// number or a six-digit upper-case hex value. The empty string is kept.
+func NormalizeColor(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	switch {
+	case value == "":
+		return "", nil
+	case hexColorPattern.MatchString(value):
+		hex := strings.ToUpper(value[1:])
+		if len(hex) == 3 {
+			hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+		}
+		return "#" + hex, nil
+	}
+
+	if n, ok := colorNames[strings.ToLower(value)]; ok {
+		return strconv.Itoa(n), nil
+	}
+	if n, err := strconv.Atoi(value); err == nil && n >= 0 && n <= 255 {
+		return strconv.Itoa(n), nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrInvalidColor, value)
+}
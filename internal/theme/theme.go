@@ -0,0 +1,229 @@
+// Package theme provides the color palette shared by the CLI printers and
+// the terminal UIs, and decides whether output is colored at all.
+package theme
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fatih/color"
+	"github.com/muesli/termenv"
+	"golang.org/x/term"
+)
+
+// Role names the purpose of a piece of text.
+type Role int
+
+const (
+	Info Role = iota
+	Success
+	Warn
+	Error
+	Header
+	Muted
+	Accent
+	Highlight
+)
+
+// Layout values shared by the terminal UIs
+const (
+	paddingSmall  = 2
+	paddingMedium = 4
+
+	// hexColorLength is the length of "#RRGGBB"
+	hexColorLength = 7
+	// basicColors is the number of ANSI colors with dedicated SGR codes
+	basicColors = 8
+	// brightColors is the end of the bright ANSI color range
+	brightColors = 16
+
+	// SGR parameters for 256-color and RGB foregrounds: 38;5;n and 38;2;r;g;b
+	sgrForeground = 38
+	sgrPalette    = 5
+	sgrRGB        = 2
+)
+
+// Theme is a palette together with the decision whether to use color.
+type Theme struct {
+	Name    string
+	Palette Palette
+	enabled bool
+}
+
+// Styles are the lipgloss styles used by the terminal UIs.
+type Styles struct {
+	Title    lipgloss.Style
+	Item     lipgloss.Style
+	Selected lipgloss.Style
+	Help     lipgloss.Style
+	Quit     lipgloss.Style
+	Error    lipgloss.Style
+	Success  lipgloss.Style
+	Input    lipgloss.Style
+	Muted    lipgloss.Style
+}
+
+var (
+	mu      sync.RWMutex
+	current *Theme
+	mode    = ColorAuto
+	// configColor mirrors cli.color_output once Load has run
+	configColor = true
+)
+
+// New creates a theme. The palette must already be normalized.
+func New(name string, palette Palette, enabled bool) *Theme {
+	return &Theme{Name: name, Palette: palette, enabled: enabled}
+}
+
+// Current returns the active theme. Before Load runs it is the default
+// palette with color decided by the environment and the terminal.
+func Current() *Theme {
+	mu.RLock()
+	t := current
+	mu.RUnlock()
+	if t != nil {
+		return t
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if current == nil {
+		base, _ := Builtin(DefaultName)
+		current = New(DefaultName, base, resolveEnabled())
+		apply(current)
+	}
+	return current
+}
+
+// Set makes t the active theme.
+func Set(t *Theme) {
+	mu.Lock()
+	defer mu.Unlock()
+	current = t
+	apply(t)
+}
+
+// Enabled reports whether the theme emits color.
+func (t *Theme) Enabled() bool {
+	return t.enabled
+}
+
+// Color returns a fatih/color printer for role.
+func (t *Theme) Color(role Role) *color.Color {
+	c := color.New(sgr(t.roleColor(role))...)
+	if role == Header {
+		c.Add(color.Bold)
+	}
+	if t.enabled {
+		c.EnableColor()
+	} else {
+		c.DisableColor()
+	}
+	return c
+}
+
+// Sprint returns s colored for role, or s unchanged when color is disabled.
+func (t *Theme) Sprint(role Role, s string) string {
+	return t.Color(role).Sprint(s)
+}
+
+// Styles returns the lipgloss styles for the terminal UIs.
+func (t *Theme) Styles() Styles {
+	p := t.Palette
+	return Styles{
+		Title: withBackground(withForeground(lipgloss.NewStyle().Bold(true), p.TitleFg), p.TitleBg).
+			Padding(0, 1),
+		Item:     lipgloss.NewStyle().PaddingLeft(paddingMedium),
+		Selected: withForeground(lipgloss.NewStyle().PaddingLeft(paddingSmall).Bold(p.Accent == ""), p.Accent),
+		Help: withForeground(lipgloss.NewStyle().PaddingLeft(paddingMedium).PaddingTop(1).Faint(p.Muted == ""),
+			p.Muted),
+		Quit:    lipgloss.NewStyle().Margin(1, 0, paddingSmall, paddingMedium),
+		Error:   withForeground(lipgloss.NewStyle().Bold(true), p.Error),
+		Success: withForeground(lipgloss.NewStyle().Bold(true), p.Success),
+		Input:   withBackground(withForeground(lipgloss.NewStyle(), p.InputFg), p.InputBg).Padding(0, 1),
+		Muted:   withForeground(lipgloss.NewStyle().Faint(p.Muted == ""), p.Muted),
+	}
+}
+
+// roleColor returns the palette color for role.
+func (t *Theme) roleColor(role Role) string {
+	switch role {
+	case Info:
+		return t.Palette.Info
+	case Success:
+		return t.Palette.Success
+	case Warn:
+		return t.Palette.Warn
+	case Error:
+		return t.Palette.Error
+	case Header:
+		return t.Palette.Header
+	case Muted:
+		return t.Palette.Muted
+	case Accent:
+		return t.Palette.Accent
+	default:
+		return t.Palette.Highlight
+	}
+}
+
+func withForeground(style lipgloss.Style, value string) lipgloss.Style {
+	if value == "" {
+		return style
+	}
+	return style.Foreground(lipgloss.Color(value))
+}
+
+func withBackground(style lipgloss.Style, value string) lipgloss.Style {
+	if value == "" {
+		return style
+	}
+	return style.Background(lipgloss.Color(value))
+}
+
+// sgr converts a normalized color to SGR attributes for fatih/color.
+func sgr(value string) []color.Attribute {
+	if len(value) == hexColorLength && strings.HasPrefix(value, "#") {
+		rgb, err := strconv.ParseUint(value[1:], 16, 32)
+		if err != nil {
+			return nil
+		}
+		return []color.Attribute{sgrForeground, sgrRGB,
+			color.Attribute(rgb >> 16 & 0xFF), color.Attribute(rgb >> 8 & 0xFF), color.Attribute(rgb & 0xFF)}
+	}
+	n, err := strconv.Atoi(value)
+	switch {
+	case err != nil:
+		return nil
+	case n < basicColors:
+		return []color.Attribute{color.FgBlack + color.Attribute(n)}
+	case n < brightColors:
+		return []color.Attribute{color.FgHiBlack + color.Attribute(n-basicColors)}
+	default:
+		return []color.Attribute{sgrForeground, sgrPalette, color.Attribute(n)}
+	}
+}
+
+// apply makes other color users follow t: fatih/color's global switch, used
+// by the logger, and the lipgloss color profile used by the terminal UIs.
+func apply(t *Theme) {
+	color.NoColor = !t.enabled
+	switch {
+	case !t.enabled:
+		lipgloss.SetColorProfile(termenv.Ascii)
+	case os.Getenv("COLORTERM") == "truecolor" || os.Getenv("COLORTERM") == "24bit":
+		lipgloss.SetColorProfile(termenv.TrueColor)
+	default:
+		lipgloss.SetColorProfile(termenv.ANSI256)
+	}
+}
+
+// resolveEnabled decides whether to use color from the current settings.
+// Callers must hold mu.
+func resolveEnabled() bool {
+	return ColorEnabled(mode, os.LookupEnv, configColor, term.IsTerminal(int(os.Stdout.Fd())))
+}
@@ -0,0 +1,137 @@
+package theme
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/nate3d/go-toolbox/internal/config"
+)
+
+func envFrom(values map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := values[key]
+		return value, ok
+	}
+}
+
+func TestColorEnabled(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     ColorMode
+		env      map[string]string
+		config   bool
+		terminal bool
+		want     bool
+	}{
+		{"auto terminal", ColorAuto, nil, true, true, true},
+		{"auto redirected", ColorAuto, nil, true, false, false},
+		{"config off", ColorAuto, nil, false, true, false},
+		{"always beats NO_COLOR", ColorAlways, map[string]string{"NO_COLOR": "1"}, true, false, true},
+		{"never beats FORCE_COLOR", ColorNever, map[string]string{"FORCE_COLOR": "1"}, true, true, false},
+		{"NO_COLOR", ColorAuto, map[string]string{"NO_COLOR": "1"}, true, true, false},
+		{"empty NO_COLOR ignored", ColorAuto, map[string]string{"NO_COLOR": ""}, true, true, true},
+		{"FORCE_COLOR redirected", ColorAuto, map[string]string{"FORCE_COLOR": "1"}, false, false, true},
+		{"FORCE_COLOR=0", ColorAuto, map[string]string{"FORCE_COLOR": "0"}, true, true, false},
+		{"FORCE_COLOR beats NO_COLOR", ColorAuto, map[string]string{"FORCE_COLOR": "1", "NO_COLOR": "1"}, true, true, true},
+		{"CLICOLOR_FORCE", ColorAuto, map[string]string{"CLICOLOR_FORCE": "1"}, true, false, true},
+		{"CLICOLOR=0", ColorAuto, map[string]string{"CLICOLOR": "0"}, true, true, false},
+		{"dumb terminal", ColorAuto, map[string]string{"TERM": "dumb"}, true, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ColorEnabled(tt.mode, envFrom(tt.env), tt.config, tt.terminal); got != tt.want {
+				t.Errorf("ColorEnabled() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeColor(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"", "", false},
+		{"cyan", "6", false},
+		{"Bright-Red", "9", false},
+		{"170", "170", false},
+		{"#7d56f4", "#7D56F4", false},
+		{"#0af", "#00AAFF", false},
+		{"256", "", true},
+		{"#12", "", true},
+		{"teal-ish", "", true},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeColor(tt.input)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("NormalizeColor(%q) = %q, %v, want %q, error %v", tt.input, got, err, tt.want, tt.wantErr)
+		}
+		if err != nil && !errors.Is(err, ErrInvalidColor) {
+			t.Errorf("NormalizeColor(%q) error = %v, want ErrInvalidColor", tt.input, err)
+		}
+	}
+}
+
+func TestThemeColor(t *testing.T) {
+	palette := Palette{Info: "6", Warn: "11", Accent: "170", Highlight: "#FF8000"}
+	enabled := New("test", palette, true)
+	tests := []struct {
+		role   Role
+		prefix string
+	}{
+		{Info, "\x1b[36mx\x1b[0"},
+		{Warn, "\x1b[93mx\x1b[0"},
+		{Accent, "\x1b[38;5;170mx\x1b[0"},
+		{Highlight, "\x1b[38;2;255;128;0mx\x1b[0"},
+	}
+	for _, tt := range tests {
+		if got := enabled.Sprint(tt.role, "x"); !strings.HasPrefix(got, tt.prefix) {
+			t.Errorf("Sprint(%d) = %q, want prefix %q", tt.role, got, tt.prefix)
+		}
+	}
+
+	if got := New("test", palette, false).Sprint(Info, "x"); got != "x" {
+		t.Errorf("disabled Sprint() = %q, want %q", got, "x")
+	}
+}
+
+func TestLoadCustomPalette(t *testing.T) {
+	config.Set("tui.theme", "ocean")
+	config.Set("themes", map[string]interface{}{
+		"ocean": map[string]interface{}{"base": "dracula", "info": "blue"},
+	})
+	config.Set("cli.color_output", false)
+	t.Cleanup(func() {
+		config.Set("tui.theme", DefaultName)
+		config.Set("themes", map[string]interface{}{})
+	})
+
+	if err := Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	current := Current()
+	dracula, _ := Builtin("dracula")
+	if current.Name != "ocean" || current.Palette.Info != "4" || current.Palette.Error != dracula.Error {
+		t.Errorf("Load() palette = %s %+v", current.Name, current.Palette)
+	}
+	if current.Enabled() && !ColorEnabled(ColorAuto, envFrom(nil), false, true) {
+		t.Error("Load() ignored cli.color_output = false")
+	}
+
+	// Disabled themes render TUI styles without escape sequences
+	SetMode(ColorNever)
+	if got := Current().Styles().Title.Render("Toolbox"); strings.Contains(got, "\x1b[") {
+		t.Errorf("Title.Render() = %q, want no escapes", got)
+	}
+}
+
+func TestLoadUnknownTheme(t *testing.T) {
+	config.Set("tui.theme", "nope")
+	t.Cleanup(func() { config.Set("tui.theme", DefaultName) })
+
+	if err := Load(); !errors.Is(err, ErrUnknownTheme) {
+		t.Errorf("Load() error = %v, want ErrUnknownTheme", err)
+	}
+}
@@ -0,0 +1,416 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+const (
+	// SniffLength is how much of the content Detect inspects
+	SniffLength = 8192
+
+	// peHeaderOffset is where an MS-DOS stub stores the offset of the PE header
+	peHeaderOffset = 0x3c
+
+	// maxFatArchs tells Mach-O universal binaries from Java class files,
+	// which share the CAFEBABE magic but store a version of 45 or more
+	maxFatArchs = 20
+)
+
+// Text encodings reported by Detect
+const (
+	EncodingUTF8    = "utf-8"
+	EncodingUTF8BOM = "utf-8-bom"
+	EncodingUTF16LE = "utf-16le"
+	EncodingUTF16BE = "utf-16be"
+	EncodingLatin1  = "iso-8859-1"
+	EncodingBinary  = "binary"
+)
+
+// Line ending styles reported by Detect
+const (
+	LineEndingLF    = "lf"
+	LineEndingCRLF  = "crlf"
+	LineEndingCR    = "cr"
+	LineEndingMixed = "mixed"
+)
+
+// Generic MIME types used when no signature matches
+const (
+	mimeBinary = "application/octet-stream"
+	mimeText   = "text/plain"
+	mimeEmpty  = "inode/x-empty"
+)
+
+// Byte order marks
+var (
+	bomUTF8    = []byte{0xef, 0xbb, 0xbf}
+	bomUTF16LE = []byte{0xff, 0xfe}
+	bomUTF16BE = []byte{0xfe, 0xff}
+)
+
+// Detection describes what a file contains.
+type Detection struct {
+	Kind        string `json:"kind"                   yaml:"kind"`
+	Description string `json:"description"            yaml:"description"`
+	MIMEType    string `json:"mime_type"              yaml:"mime_type"`
+	Encoding    string `json:"encoding"               yaml:"encoding"`
+	LineEndings string `json:"line_endings,omitempty" yaml:"line_endings,omitempty"`
+	Interpreter string `json:"interpreter,omitempty"  yaml:"interpreter,omitempty"`
+}
+
+// IsText reports whether the content is text in a known encoding
+func (d Detection) IsText() bool {
+	return d.Encoding != EncodingBinary && d.Kind != "empty"
+}
+
+// signature is a magic number identifying a file format.
+type signature struct {
+	kind        string
+	description string
+	mimeType    string
+	offset      int
+	magic       []byte
+	// check, when set, must also accept the content
+	check func(data []byte) bool
+}
+
+// signatures is the table of formats recognised by their magic numbers.
+// More specific entries come first.
+var signatures = []signature{
+	{kind: "png", description: "PNG image", mimeType: "image/png",
+		magic: []byte("\x89PNG\r\n\x1a\n")},
+	{kind: "jpeg", description: "JPEG image", mimeType: "image/jpeg",
+		magic: []byte{0xff, 0xd8, 0xff}},
+	{kind: "gif", description: "GIF image", mimeType: "image/gif",
+		magic: []byte("GIF87a")},
+	{kind: "gif", description: "GIF image", mimeType: "image/gif",
+		magic: []byte("GIF89a")},
+	{kind: "pdf", description: "PDF document", mimeType: "application/pdf",
+		magic: []byte("%PDF-")},
+	{kind: "zip", description: "Zip archive", mimeType: "application/zip",
+		magic: []byte("PK\x03\x04")},
+	{kind: "zip", description: "Zip archive (empty)", mimeType: "application/zip",
+		magic: []byte("PK\x05\x06")},
+	{kind: "gzip", description: "gzip compressed data", mimeType: "application/gzip",
+		magic: []byte{0x1f, 0x8b}},
+	{kind: "zstd", description: "Zstandard compressed data", mimeType: "application/zstd",
+		magic: []byte{0x28, 0xb5, 0x2f, 0xfd}},
+	{kind: "xz", description: "XZ compressed data", mimeType: "application/x-xz",
+		magic: []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}},
+	{kind: "elf", description: "ELF executable", mimeType: "application/x-executable",
+		magic: []byte("\x7fELF")},
+	{kind: "macho", description: "Mach-O executable", mimeType: "application/x-mach-binary",
+		magic: []byte{0xfe, 0xed, 0xfa, 0xce}},
+	{kind: "macho", description: "Mach-O executable", mimeType: "application/x-mach-binary",
+		magic: []byte{0xfe, 0xed, 0xfa, 0xcf}},
+	{kind: "macho", description: "Mach-O executable", mimeType: "application/x-mach-binary",
+		magic: []byte{0xce, 0xfa, 0xed, 0xfe}},
+	{kind: "macho", description: "Mach-O executable", mimeType: "application/x-mach-binary",
+		magic: []byte{0xcf, 0xfa, 0xed, 0xfe}},
+	{kind: "macho", description: "Mach-O universal binary", mimeType: "application/x-mach-binary",
+		magic: []byte{0xca, 0xfe, 0xba, 0xbe}, check: isFatBinary},
+	{kind: "pe", description: "PE executable", mimeType: "application/vnd.microsoft.portable-executable",
+		magic: []byte("MZ"), check: hasPEHeader},
+	{kind: "sqlite", description: "SQLite 3 database", mimeType: "application/vnd.sqlite3",
+		magic: []byte("SQLite format 3\x00")},
+	{kind: "wasm", description: "WebAssembly binary", mimeType: "application/wasm",
+		magic: []byte("\x00asm")},
+	{kind: "tar", description: "POSIX tar archive", mimeType: "application/x-tar",
+		offset: 257, magic: []byte("ustar")},
+}
+
+// zipFormats are the formats stored as zip archives, recognised by the
+// entry names near the start of the archive.
+var zipFormats = []struct {
+	entry string
+	sig   signature
+}{
+	{"META-INF/MANIFEST.MF", signature{kind: "jar", description: "Java archive",
+		mimeType: "application/java-archive"}},
+	{"word/", signature{kind: "docx", description: "Microsoft Word document",
+		mimeType: "application/vnd.openxmlformats-officedocument.wordprocessingml.document"}},
+	{"xl/", signature{kind: "xlsx", description: "Microsoft Excel spreadsheet",
+		mimeType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"}},
+	{"ppt/", signature{kind: "pptx", description: "Microsoft PowerPoint presentation",
+		mimeType: "application/vnd.openxmlformats-officedocument.presentationml.presentation"}},
+}
+
+// scriptTypes maps shebang interpreters to MIME types
+var scriptTypes = map[string]string{
+	"sh":      "text/x-shellscript",
+	"bash":    "text/x-shellscript",
+	"dash":    "text/x-shellscript",
+	"ksh":     "text/x-shellscript",
+	"zsh":     "text/x-shellscript",
+	"python":  "text/x-python",
+	"python2": "text/x-python",
+	"python3": "text/x-python",
+	"perl":    "text/x-perl",
+	"ruby":    "text/x-ruby",
+	"node":    "text/javascript",
+	"php":     "text/x-php",
+	"lua":     "text/x-lua",
+}
+
+// DetectUtils identifies file formats and text encodings from content
+type DetectUtils struct{}
+
+// Detect returns a new DetectUtils instance
+func Detect() *DetectUtils {
+	return &DetectUtils{}
+}
+
+// Bytes classifies content by its leading bytes. Only the first
+// SniffLength bytes are inspected.
+func (d *DetectUtils) Bytes(data []byte) Detection {
+	if len(data) > SniffLength {
+		data = data[:SniffLength]
+	}
+	if len(data) == 0 {
+		return Detection{Kind: "empty", Description: "empty", MIMEType: mimeEmpty, Encoding: EncodingBinary}
+	}
+	if sig, ok := matchSignature(data); ok {
+		return Detection{
+			Kind:        sig.kind,
+			Description: sig.description,
+			MIMEType:    sig.mimeType,
+			Encoding:    EncodingBinary,
+		}
+	}
+	return detectText(data)
+}
+
+// Reader classifies the first SniffLength bytes read from r
+func (d *DetectUtils) Reader(r io.Reader) (Detection, error) {
+	head := make([]byte, SniffLength)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return Detection{}, err
+	}
+	return d.Bytes(head[:n]), nil
+}
+
+// File classifies the content of a file. Generic text and binary results
+// are refined by the file extension, which is more specific than content
+// for formats such as CSS or JSON.
+func (d *DetectUtils) File(path string) (Detection, error) {
+	file, err := os.Open(path) // #nosec G304 - callers choose which file to inspect
+	if err != nil {
+		return Detection{}, err
+	}
+	defer func() { _ = file.Close() }()
+
+	detection, err := d.Reader(file)
+	if err != nil {
+		return Detection{}, err
+	}
+	if detection.Kind == "text" || detection.Kind == "data" {
+		if byExt := mime.TypeByExtension(filepath.Ext(path)); byExt != "" {
+			detection.MIMEType = byExt
+		}
+	}
+	return detection, nil
+}
+
+func matchSignature(data []byte) (signature, bool) {
+	for _, sig := range signatures {
+		end := sig.offset + len(sig.magic)
+		if len(data) < end || !bytes.Equal(data[sig.offset:end], sig.magic) {
+			continue
+		}
+		if sig.check != nil && !sig.check(data) {
+			continue
+		}
+		if sig.kind == "zip" {
+			return zipFormat(data, sig), true
+		}
+		return sig, true
+	}
+	return signature{}, false
+}
+
+// zipFormat refines a zip archive by the names of its first entries
+func zipFormat(data []byte, zip signature) signature {
+	for _, format := range zipFormats {
+		if bytes.Contains(data, []byte(format.entry)) {
+			return format.sig
+		}
+	}
+	return zip
+}
+
+// isFatBinary tells a Mach-O universal binary, which stores a small
+// architecture count after the magic, from a Java class file.
+func isFatBinary(data []byte) bool {
+	const countEnd = 8
+	return len(data) >= countEnd && binary.BigEndian.Uint32(data[4:countEnd]) < maxFatArchs
+}
+
+// hasPEHeader checks that an MS-DOS stub points to a PE header, so text
+// that happens to start with "MZ" is not taken for an executable.
+func hasPEHeader(data []byte) bool {
+	if len(data) < peHeaderOffset+4 {
+		return false
+	}
+	offset := int(binary.LittleEndian.Uint32(data[peHeaderOffset:]))
+	return offset >= 0 && len(data) >= offset+4 && string(data[offset:offset+4]) == "PE\x00\x00"
+}
+
+// detectText classifies content without a known signature as text in some
+// encoding or as binary data.
+func detectText(data []byte) Detection {
+	text, encoding := decodeText(data)
+	if encoding == EncodingBinary {
+		return Detection{Kind: "data", Description: "data", MIMEType: mimeBinary, Encoding: EncodingBinary}
+	}
+
+	detection := Detection{
+		Kind:        "text",
+		Description: "text",
+		MIMEType:    mimeText + "; charset=" + strings.TrimSuffix(encoding, "-bom"),
+		Encoding:    encoding,
+		LineEndings: lineEndings(text),
+	}
+	if interpreter := shebangInterpreter(text); interpreter != "" {
+		detection.Kind = "script"
+		detection.Description = interpreter + " script"
+		detection.Interpreter = interpreter
+		if scriptType, ok := scriptTypes[interpreter]; ok {
+			detection.MIMEType = scriptType
+		}
+	}
+	return detection
+}
+
+// decodeText returns data as a string along with its encoding, or reports
+// EncodingBinary when it does not look like text.
+func decodeText(data []byte) (string, string) {
+	switch {
+	case bytes.HasPrefix(data, bomUTF8):
+		return string(data[len(bomUTF8):]), EncodingUTF8BOM
+	case bytes.HasPrefix(data, bomUTF16LE):
+		return decodeUTF16(data[len(bomUTF16LE):], binary.LittleEndian), EncodingUTF16LE
+	case bytes.HasPrefix(data, bomUTF16BE):
+		return decodeUTF16(data[len(bomUTF16BE):], binary.BigEndian), EncodingUTF16BE
+	}
+
+	for _, b := range data {
+		if isBinaryByte(b) {
+			return "", EncodingBinary
+		}
+	}
+	if utf8.Valid(trimPartialRune(data)) {
+		return string(data), EncodingUTF8
+	}
+	// Latin-1 has no printable characters in the C1 control range
+	for _, b := range data {
+		if b >= 0x80 && b < 0xa0 {
+			return "", EncodingBinary
+		}
+	}
+	return string(data), EncodingLatin1
+}
+
+// isBinaryByte reports control characters that do not occur in text
+func isBinaryByte(b byte) bool {
+	switch b {
+	case '\t', '\n', '\v', '\f', '\r', '\b', 0x1b:
+		return false
+	}
+	return b < ' ' || b == 0x7f
+}
+
+// trimPartialRune drops a multi-byte character cut off by the sniff limit
+func trimPartialRune(data []byte) []byte {
+	for i := 1; i < utf8.UTFMax && i <= len(data); i++ {
+		b := data[len(data)-i]
+		if !utf8.RuneStart(b) {
+			continue
+		}
+		if !utf8.FullRune(data[len(data)-i:]) {
+			return data[:len(data)-i]
+		}
+		break
+	}
+	return data
+}
+
+func decodeUTF16(data []byte, order binary.ByteOrder) string {
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = order.Uint16(data[2*i:])
+	}
+	return string(utf16.Decode(units))
+}
+
+// lineEndings reports the line terminator style of text, or an empty
+// string when it has no line breaks.
+func lineEndings(text string) string {
+	crlf := strings.Count(text, "\r\n")
+	lf := strings.Count(text, "\n") - crlf
+	cr := strings.Count(text, "\r") - crlf
+
+	styles := 0
+	style := ""
+	for _, s := range []struct {
+		count int
+		name  string
+	}{{lf, LineEndingLF}, {crlf, LineEndingCRLF}, {cr, LineEndingCR}} {
+		if s.count > 0 {
+			styles++
+			style = s.name
+		}
+	}
+	if styles > 1 {
+		return LineEndingMixed
+	}
+	return style
+}
+
+// shebangInterpreter returns the program named by a "#!" line, looking
+// through /usr/bin/env and its options.
+func shebangInterpreter(text string) string {
+	line, ok := strings.CutPrefix(text, "#!")
+	if !ok {
+		return ""
+	}
+	if end := strings.IndexAny(line, "\r\n"); end >= 0 {
+		line = line[:end]
+	}
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return ""
+	}
+	if filepath.Base(fields[0]) == "env" {
+		fields = envCommand(fields[1:])
+		if len(fields) == 0 {
+			return ""
+		}
+	}
+	return filepath.Base(fields[0])
+}
+
+// envCommand skips the options and variable assignments given to env
+func envCommand(args []string) []string {
+	for len(args) > 0 {
+		arg := args[0]
+		switch {
+		case arg == "-u" || arg == "--unset":
+			args = args[min(2, len(args)):]
+		case strings.HasPrefix(arg, "-"), strings.Contains(arg, "="):
+			args = args[1:]
+		default:
+			return args
+		}
+	}
+	return nil
+}
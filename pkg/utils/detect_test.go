@@ -0,0 +1,187 @@
+package utils_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nate3d/go-toolbox/pkg/utils"
+)
+
+func zipWith(t *testing.T, names ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, name := range names {
+		if _, err := w.Create(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDetectSignatures(t *testing.T) {
+	detect := utils.Detect()
+
+	pe := make([]byte, 0x90)
+	copy(pe, "MZ")
+	pe[0x3c] = 0x80
+	copy(pe[0x80:], "PE\x00\x00")
+
+	tar := make([]byte, 512)
+	copy(tar, "file.txt")
+	copy(tar[257:], "ustar\x0000")
+
+	tests := []struct {
+		name string
+		data []byte
+		kind string
+		mime string
+	}{
+		{"png", []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"), "png", "image/png"},
+		{"jpeg", []byte{0xff, 0xd8, 0xff, 0xe0, 0x00, 0x10, 'J', 'F', 'I', 'F'}, "jpeg", "image/jpeg"},
+		{"gif", []byte("GIF89a\x01\x00\x01\x00"), "gif", "image/gif"},
+		{"pdf", []byte("%PDF-1.7\n"), "pdf", "application/pdf"},
+		{"zip", zipWith(t, "a.txt"), "zip", "application/zip"},
+		{"jar", zipWith(t, "META-INF/MANIFEST.MF", "Main.class"), "jar", "application/java-archive"},
+		{"docx", zipWith(t, "[Content_Types].xml", "word/document.xml"), "docx",
+			"application/vnd.openxmlformats-officedocument.wordprocessingml.document"},
+		{"gzip", []byte{0x1f, 0x8b, 0x08, 0x00}, "gzip", "application/gzip"},
+		{"zstd", []byte{0x28, 0xb5, 0x2f, 0xfd, 0x04}, "zstd", "application/zstd"},
+		{"xz", []byte{0xfd, '7', 'z', 'X', 'Z', 0x00, 0x00}, "xz", "application/x-xz"},
+		{"elf", []byte("\x7fELF\x02\x01\x01"), "elf", "application/x-executable"},
+		{"macho", []byte{0xcf, 0xfa, 0xed, 0xfe, 0x07, 0x00}, "macho", "application/x-mach-binary"},
+		{"fat macho", []byte{0xca, 0xfe, 0xba, 0xbe, 0x00, 0x00, 0x00, 0x02}, "macho", "application/x-mach-binary"},
+		{"java class", []byte{0xca, 0xfe, 0xba, 0xbe, 0x00, 0x00, 0x00, 0x41}, "data", "application/octet-stream"},
+		{"pe", pe, "pe", "application/vnd.microsoft.portable-executable"},
+		{"MZ text", []byte("MZ is not an executable\n"), "text", "text/plain; charset=utf-8"},
+		{"sqlite", []byte("SQLite format 3\x00\x10\x00"), "sqlite", "application/vnd.sqlite3"},
+		{"wasm", []byte("\x00asm\x01\x00\x00\x00"), "wasm", "application/wasm"},
+		{"tar", tar, "tar", "application/x-tar"},
+		{"empty", nil, "empty", "inode/x-empty"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := detect.Bytes(test.data)
+			if got.Kind != test.kind || got.MIMEType != test.mime {
+				t.Errorf("Bytes() = %+v, expected kind %q and MIME type %q", got, test.kind, test.mime)
+			}
+		})
+	}
+}
+
+func TestDetectText(t *testing.T) {
+	detect := utils.Detect()
+
+	t.Run("Encoding", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			data     []byte
+			expected string
+		}{
+			{"ascii", []byte("hello\n"), utils.EncodingUTF8},
+			{"utf-8", []byte("héllo wörld\n"), utils.EncodingUTF8},
+			{"utf-8 bom", []byte("\xef\xbb\xbfhello\n"), utils.EncodingUTF8BOM},
+			{"utf-16le", []byte("\xff\xfeh\x00i\x00\n\x00"), utils.EncodingUTF16LE},
+			{"utf-16be", []byte("\xfe\xff\x00h\x00i\x00\n"), utils.EncodingUTF16BE},
+			{"latin-1", []byte("caf\xe9 cr\xe8me\n"), utils.EncodingLatin1},
+			{"nul bytes", []byte("abc\x00def"), utils.EncodingBinary},
+			{"c1 controls", []byte("abc\x85\x90def"), utils.EncodingBinary},
+		}
+
+		for _, test := range tests {
+			if got := detect.Bytes(test.data); got.Encoding != test.expected {
+				t.Errorf("%s: Encoding = %q, expected %q", test.name, got.Encoding, test.expected)
+			}
+		}
+	})
+
+	t.Run("TruncatedRune", func(t *testing.T) {
+		data := []byte(strings.Repeat("a", utils.SniffLength-1) + "é")
+		if got := detect.Bytes(data); got.Encoding != utils.EncodingUTF8 {
+			t.Errorf("Encoding = %q, expected %q", got.Encoding, utils.EncodingUTF8)
+		}
+	})
+
+	t.Run("LineEndings", func(t *testing.T) {
+		tests := []struct {
+			data     string
+			expected string
+		}{
+			{"a\nb\n", utils.LineEndingLF},
+			{"a\r\nb\r\n", utils.LineEndingCRLF},
+			{"a\rb\r", utils.LineEndingCR},
+			{"a\r\nb\n", utils.LineEndingMixed},
+			{"no newline", ""},
+		}
+
+		for _, test := range tests {
+			if got := detect.Bytes([]byte(test.data)); got.LineEndings != test.expected {
+				t.Errorf("LineEndings(%q) = %q, expected %q", test.data, got.LineEndings, test.expected)
+			}
+		}
+	})
+
+	t.Run("Shebang", func(t *testing.T) {
+		tests := []struct {
+			data        string
+			interpreter string
+			mime        string
+		}{
+			{"#!/bin/sh\necho hi\n", "sh", "text/x-shellscript"},
+			{"#!/usr/bin/env python3\nprint()\n", "python3", "text/x-python"},
+			{"#!/usr/bin/env -S node --experimental\n", "node", "text/javascript"},
+			{"#!/usr/bin/env -u HOME LANG=C perl -w\n", "perl", "text/x-perl"},
+			{"#!/opt/bin/awk -f\n", "awk", "text/plain; charset=utf-8"},
+			{"#!\n", "", "text/plain; charset=utf-8"},
+		}
+
+		for _, test := range tests {
+			got := detect.Bytes([]byte(test.data))
+			if got.Interpreter != test.interpreter || got.MIMEType != test.mime {
+				t.Errorf("Bytes(%q) = %+v, expected interpreter %q and MIME type %q",
+					test.data, got, test.interpreter, test.mime)
+			}
+		}
+	})
+}
+
+func TestDetectFile(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"style.css": "body { color: red; }\n",
+		"blob.bin":  "\x00\x01\x02",
+		"image.png": "\x89PNG\r\n\x1a\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := map[string]string{
+		"style.css": "text/css; charset=utf-8",
+		"blob.bin":  "application/octet-stream",
+		"image.png": "image/png",
+	}
+	for name, expected := range tests {
+		got, err := utils.Detect().File(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.MIMEType != expected {
+			t.Errorf("File(%s).MIMEType = %q, expected %q", name, got.MIMEType, expected)
+		}
+	}
+
+	if _, err := utils.Detect().File(filepath.Join(dir, "missing")); err == nil {
+		t.Error("File(missing) expected an error")
+	}
+}
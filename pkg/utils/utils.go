@@ -72,38 +72,54 @@ func (s *StringUtils) PadRight(str string, totalLen int, padChar rune) string {
 	return str + strings.Repeat(string(padChar), totalLen-strLen)
 }
 
-// ToCamelCase converts a string to camelCase
+// ToCamelCase converts a string to camelCase, e.g. "HTTP server" to
+// "httpServer"
 func (s *StringUtils) ToCamelCase(str string) string {
-	words := strings.FieldsFunc(str, func(c rune) bool {
-		return !unicode.IsLetter(c) && !unicode.IsNumber(c)
-	})
-
-	if len(words) == 0 {
-		return ""
-	}
-
-	result := strings.ToLower(words[0])
-	for i := 1; i < len(words); i++ {
-		word := strings.ToLower(words[i])
-		if len(word) > 0 {
-			result += strings.ToUpper(word[:1]) + word[1:]
+	var b strings.Builder
+	for i, word := range splitWords(str) {
+		runes := []rune(strings.ToLower(word))
+		if i > 0 {
+			runes[0] = unicode.ToUpper(runes[0])
 		}
+		b.WriteString(string(runes))
 	}
-	return result
+	return b.String()
 }
 
-// ToSnakeCase converts a string to snake_case
+// ToSnakeCase converts a string to snake_case, e.g. "HTTPServer2" to
+// "http_server2"
 func (s *StringUtils) ToSnakeCase(str string) string {
-	re := regexp.MustCompile("([a-z0-9])([A-Z])")
-	snake := re.ReplaceAllString(str, "${1}_${2}")
-	return strings.ToLower(snake)
+	return strings.ToLower(strings.Join(splitWords(str), "_"))
 }
 
-// ToKebabCase converts a string to kebab-case
+// ToKebabCase converts a string to kebab-case, e.g. "HTTPServer2" to
+// "http-server2"
 func (s *StringUtils) ToKebabCase(str string) string {
-	re := regexp.MustCompile("([a-z0-9])([A-Z])")
-	kebab := re.ReplaceAllString(str, "${1}-${2}")
-	return strings.ToLower(kebab)
+	return strings.ToLower(strings.Join(splitWords(str), "-"))
+}
+
+// splitWords splits a string into words at characters other than letters
+// and digits and at case changes, keeping acronyms together:
+// "HTTPServer error_code" becomes HTTP, Server, error, code.
+func splitWords(str string) []string {
+	var words []string
+	for _, field := range strings.FieldsFunc(str, func(c rune) bool {
+		return !unicode.IsLetter(c) && !unicode.IsNumber(c)
+	}) {
+		runes := []rune(field)
+		start := 0
+		for i := 1; i < len(runes); i++ {
+			prev, cur := runes[i-1], runes[i]
+			lowerToUpper := (unicode.IsLower(prev) || unicode.IsDigit(prev)) && unicode.IsUpper(cur)
+			acronymEnd := unicode.IsUpper(prev) && unicode.IsUpper(cur) && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if lowerToUpper || acronymEnd {
+				words = append(words, string(runes[start:i]))
+				start = i
+			}
+		}
+		words = append(words, string(runes[start:]))
+	}
+	return words
 }
 
 // SliceUtils provides slice manipulation utilities
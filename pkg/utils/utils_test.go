@@ -77,6 +77,9 @@ func TestStringUtils(t *testing.T) {
 			{"hello_world", "helloWorld"},
 			{"hello-world", "helloWorld"},
 			{"HELLO WORLD", "helloWorld"},
+			{"HTTPServer", "httpServer"},
+			{"parse JSON2 file", "parseJson2File"},
+			{"", ""},
 		}
 
 		for _, test := range tests {
@@ -86,6 +89,30 @@ func TestStringUtils(t *testing.T) {
 			}
 		}
 	})
+
+	t.Run("ToSnakeCase and ToKebabCase", func(t *testing.T) {
+		tests := []struct {
+			input string
+			snake string
+			kebab string
+		}{
+			{"HelloWorld", "hello_world", "hello-world"},
+			{"HTTPServer", "http_server", "http-server"},
+			{"getHTTPResponseCode", "get_http_response_code", "get-http-response-code"},
+			{"version2Update", "version2_update", "version2-update"},
+			{"Report 2024 final", "report_2024_final", "report-2024-final"},
+			{"already_snake-case", "already_snake_case", "already-snake-case"},
+		}
+
+		for _, test := range tests {
+			if result := str.ToSnakeCase(test.input); result != test.snake {
+				t.Errorf("ToSnakeCase(%q) = %q, expected %q", test.input, result, test.snake)
+			}
+			if result := str.ToKebabCase(test.input); result != test.kebab {
+				t.Errorf("ToKebabCase(%q) = %q, expected %q", test.input, result, test.kebab)
+			}
+		}
+	})
 }
 
 func TestSliceUtils(t *testing.T) {